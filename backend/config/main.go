@@ -1,7 +1,12 @@
 // Package config provides configuration management for the application using Viper.
 package config
 
-import "github.com/spf13/viper"
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/viper"
+)
 
 var config *viper.Viper
 var AppConfig *viper.Viper
@@ -13,8 +18,21 @@ func init() {
 	config.AddConfigPath("./")
 	config.SetConfigType("yml")
 
+	// Tests run from their package directory and fall back to the example config, so they need no
+	// local config.yml
+	if testing.Testing() {
+		config.AddConfigPath("../")
+		config.AddConfigPath("../../")
+	}
+
 	err := config.ReadInConfig()
 
+	var notFound viper.ConfigFileNotFoundError
+	if errors.As(err, &notFound) && testing.Testing() {
+		config.SetConfigName("config.example")
+		err = config.ReadInConfig()
+	}
+
 	if err != nil {
 		panic(err)
 	}
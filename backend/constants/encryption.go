@@ -4,14 +4,22 @@ import "api.workzen.odoo/config"
 
 var (
 	// encryption JWT
-	EncryptionJWTSecret = config.GetConfig().GetString("encryption.jwt.secret")
-	EncryptionJWTExpire = config.GetConfig().GetInt64("encryption.jwt.expire") // in hours
+	EncryptionJWTSecret       = config.GetConfig().GetString("encryption.jwt.secret")
+	EncryptionJWTExpire       = config.GetConfig().GetInt64("encryption.jwt.expire")        // in days, lifetime of a session's refresh token
+	EncryptionJWTAccessExpire = config.GetConfig().GetInt64("encryption.jwt.access_expire") // in minutes, lifetime of an access token
 
 	// encryption AES
 	EncryptionAESKey = config.GetConfig().GetString("encryption.aes.key")
 
 	// encryption AES ID
-	EncryptionAESIDKey = config.GetConfig().GetString("encryption.aes.ids.key")
+	EncryptionAESIDKey          = config.GetConfig().GetString("encryption.aes.ids.key")           // legacy AES-CFB key of IDs issued before AES-GCM
+	EncryptionAESIDKeys         = config.GetConfig().GetStringMapString("encryption.aes.ids.keys") // AES-GCM keys by version
+	EncryptionAESIDCurrentKey   = config.GetConfig().GetInt("encryption.aes.ids.current")          // version of the key new IDs are encrypted with
+	EncryptionAESIDAcceptLegacy = config.GetConfig().GetBool("encryption.aes.ids.accept_legacy")   // still decrypt legacy AES-CFB IDs
+
+	// encryption AES fields, a dedicated key for sensitive fields stored in the database
+	EncryptionAESFieldKeys       = config.GetConfig().GetStringMapString("encryption.aes.fields.keys") // AES-GCM keys by version
+	EncryptionAESFieldCurrentKey = config.GetConfig().GetInt("encryption.aes.fields.current")          // version of the key new values are encrypted with
 
 	// encryption AES with rounds
 	EncryptionAESRounds = config.GetConfig().GetInt("encryption.aes.rounds")
@@ -28,5 +36,10 @@ var (
 	EncryptionPasswordSalt   = config.GetConfig().GetString("encryption.password.salt")
 	EncryptionPasswordSecret = config.GetConfig().GetString("encryption.password.secret")
 	EncryptionPasswordPrefix = config.GetConfig().GetString("encryption.password.prefix")
-	EncryptionPasswordRounds = config.GetConfig().GetInt("encryption.password.rounds")
+	EncryptionPasswordRounds = config.GetConfig().GetInt("encryption.password.rounds") // legacy hashes only
+
+	// argon2id cost of new password hashes
+	EncryptionPasswordArgon2Memory      = config.GetConfig().GetInt("encryption.password.argon2.memory") // in KiB
+	EncryptionPasswordArgon2Iterations  = config.GetConfig().GetInt("encryption.password.argon2.iterations")
+	EncryptionPasswordArgon2Parallelism = config.GetConfig().GetInt("encryption.password.argon2.parallelism")
 )
@@ -0,0 +1,9 @@
+package constants
+
+import "api.workzen.odoo/config"
+
+var (
+	// jobs (enabled unless explicitly turned off)
+	JobsEnabled        = !config.GetConfig().IsSet("jobs.enabled") || config.GetConfig().GetBool("jobs.enabled")
+	JobsAttendanceTime = config.GetConfig().GetString("jobs.attendance_time")
+)
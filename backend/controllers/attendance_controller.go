@@ -1,13 +1,16 @@
 package controllers
 
 import (
+	"fmt"
 	"strconv"
 
 	"api.workzen.odoo/constants"
 	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
 	"api.workzen.odoo/middlewares"
 	"api.workzen.odoo/services"
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type AttendanceController struct {
@@ -20,11 +23,13 @@ func NewAttendanceController() *AttendanceController {
 	}
 }
 
-// CheckIn handles employee check-in
+// CheckIn handles employee check-in with optional client location
 func (ac *AttendanceController) CheckIn(c *fiber.Ctx) error {
-	userID, err := middlewares.GetAuthUserID(c)
-	if err != nil {
-		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	var req services.CheckInRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+		}
 	}
 
 	// Get user role
@@ -33,9 +38,9 @@ func (ac *AttendanceController) CheckIn(c *fiber.Ctx) error {
 		return constants.HTTPErrors.Unauthorized(c, err.Error())
 	}
 
-	// Admin and SuperAdmin cannot mark attendance
-	if user.Role == models.RoleAdmin || user.Role == models.RoleSuperAdmin {
-		return constants.HTTPErrors.Forbidden(c, "Administrators do not need to mark attendance")
+	// SuperAdmin and roles without attendance.mark (Admin by default) do not mark attendance
+	if user.IsSuperAdmin || !services.HasPermission(user, models.PermAttendanceMark, user.Company) {
+		return constants.HTTPErrors.Forbidden(c, "Your role does not mark attendance")
 	}
 
 	companyID, err := middlewares.GetAuthCompanyID(c)
@@ -43,7 +48,7 @@ func (ac *AttendanceController) CheckIn(c *fiber.Ctx) error {
 		return constants.HTTPErrors.Unauthorized(c, err.Error())
 	}
 
-	attendance, err := ac.service.CheckIn(userID, companyID)
+	attendance, err := ac.service.CheckIn(&req, user, companyID, c.IP())
 	if err != nil {
 		return constants.HTTPErrors.BadRequest(c, err.Error())
 	}
@@ -64,9 +69,9 @@ func (ac *AttendanceController) CheckOut(c *fiber.Ctx) error {
 		return constants.HTTPErrors.Unauthorized(c, err.Error())
 	}
 
-	// Admin and SuperAdmin cannot mark attendance
-	if user.Role == models.RoleAdmin || user.Role == models.RoleSuperAdmin {
-		return constants.HTTPErrors.Forbidden(c, "Administrators do not need to mark attendance")
+	// SuperAdmin and roles without attendance.mark (Admin by default) do not mark attendance
+	if user.IsSuperAdmin || !services.HasPermission(user, models.PermAttendanceMark, user.Company) {
+		return constants.HTTPErrors.Forbidden(c, "Your role does not mark attendance")
 	}
 
 	err = ac.service.CheckOut(userID)
@@ -121,7 +126,7 @@ func (ac *AttendanceController) ListAttendance(c *fiber.Ctx) error {
 	return constants.HTTPSuccess.OkWithPagination(c, "Attendance list retrieved successfully", attendances, page, limit, total)
 }
 
-// ResetAttendance allows resetting today's attendance
+// ResetAttendance allows resetting today's own check-in, past records go through regularization
 func (ac *AttendanceController) ResetAttendance(c *fiber.Ctx) error {
 	userID, err := middlewares.GetAuthUserID(c)
 	if err != nil {
@@ -152,3 +157,394 @@ func (ac *AttendanceController) GetAttendanceSummary(c *fiber.Ctx) error {
 
 	return constants.HTTPSuccess.OK(c, "Attendance summary retrieved successfully", summary)
 }
+
+// RequestRegularization submits a correction request for a past attendance record
+func (ac *AttendanceController) RequestRegularization(c *fiber.Ctx) error {
+	var req services.RegularizationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	regularization, err := ac.service.RequestRegularization(&req, userID, companyID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	resp, err := services.ConvertRegularizationToResponse(regularization)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.Created(c, "Regularization request submitted successfully", resp)
+}
+
+// ListRegularizations retrieves regularization requests visible to the current user
+func (ac *AttendanceController) ListRegularizations(c *fiber.Ctx) error {
+	page, _ := strconv.ParseInt(c.Query("page", "1"), 10, 64)
+	limit, _ := strconv.ParseInt(c.Query("limit", "10"), 10, 64)
+
+	user, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	regularizations, total, err := ac.service.ListRegularizations(user, c.Query("status"), page, limit)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	responses := []services.AttendanceRegularizationResponse{}
+	for i := range regularizations {
+		resp, err := services.ConvertRegularizationToResponse(&regularizations[i])
+		if err != nil {
+			return constants.HTTPErrors.InternalServerError(c, err.Error())
+		}
+		responses = append(responses, *resp)
+	}
+
+	return constants.HTTPSuccess.OkWithPagination(c, "Regularization requests retrieved successfully", responses, page, limit, total)
+}
+
+// ApproveRegularization approves a regularization request (manager or HR)
+func (ac *AttendanceController) ApproveRegularization(c *fiber.Ctx) error {
+	regularizationID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid regularization ID")
+	}
+
+	var req services.ReviewRegularizationRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+		}
+	}
+
+	user, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	err = ac.service.ApproveRegularization(regularizationID, user, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Regularization approved successfully")
+}
+
+// RejectRegularization rejects a regularization request (manager or HR)
+func (ac *AttendanceController) RejectRegularization(c *fiber.Ctx) error {
+	regularizationID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid regularization ID")
+	}
+
+	var req services.ReviewRegularizationRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+		}
+	}
+
+	user, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	err = ac.service.RejectRegularization(regularizationID, user, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Regularization rejected successfully")
+}
+
+// SaveConfiguration creates or updates the company attendance configuration
+func (ac *AttendanceController) SaveConfiguration(c *fiber.Ctx) error {
+	var req services.SaveAttendanceConfigurationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	config, err := ac.service.SaveConfiguration(&req, companyID, userID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Attendance configuration saved successfully", config)
+}
+
+// GetConfiguration retrieves the company attendance configuration
+func (ac *AttendanceController) GetConfiguration(c *fiber.Ctx) error {
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	config, err := ac.service.GetConfiguration(companyID)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Attendance configuration retrieved successfully", config)
+}
+
+// ListJobRuns retrieves the history of the daily attendance job (HR/Admin)
+func (ac *AttendanceController) ListJobRuns(c *fiber.Ctx) error {
+	page, _ := strconv.ParseInt(c.Query("page", "1"), 10, 64)
+	limit, _ := strconv.ParseInt(c.Query("limit", "10"), 10, 64)
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	runs, total, err := ac.service.ListJobRuns(companyID, c.Query("date"), page, limit)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OkWithPagination(c, "Job runs retrieved successfully", runs, page, limit, total)
+}
+
+// RerunJob re-processes absences and open check-ins for a past date (HR/Admin)
+func (ac *AttendanceController) RerunJob(c *fiber.Ctx) error {
+	var req services.RerunAttendanceJobRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	run, err := ac.service.RerunDailyJob(&req, companyID, userID)
+	if err != nil {
+		if run != nil {
+			return constants.HTTPErrors.InternalServerError(c, err.Error())
+		}
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Attendance job completed successfully", run)
+}
+
+// IngestPunches accepts punches pushed by a biometric device or integration (HR/Admin)
+func (ac *AttendanceController) IngestPunches(c *fiber.Ctx) error {
+	var req services.IngestPunchesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	result, err := ac.service.IngestPunches(&req, companyID, userID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Punches ingested successfully", result)
+}
+
+// ImportPunches imports a CSV or ZKTeco attendance log file (HR/Admin)
+func (ac *AttendanceController) ImportPunches(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "File is required")
+	}
+
+	format := c.FormValue("format", "csv")
+	deviceID := c.FormValue("device_id")
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	result, err := ac.service.ImportPunchFile(file, format, deviceID, companyID, userID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Punch file imported successfully", result)
+}
+
+// ListPunchErrors retrieves punches waiting for review (HR/Admin)
+func (ac *AttendanceController) ListPunchErrors(c *fiber.Ctx) error {
+	page, _ := strconv.ParseInt(c.Query("page", "1"), 10, 64)
+	limit, _ := strconv.ParseInt(c.Query("limit", "10"), 10, 64)
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	punchErrors, total, err := ac.service.ListPunchErrors(companyID, c.Query("status", "open"), page, limit)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	responses := []services.PunchErrorResponse{}
+	for i := range punchErrors {
+		resp, err := services.ConvertPunchErrorToResponse(&punchErrors[i])
+		if err != nil {
+			return constants.HTTPErrors.InternalServerError(c, err.Error())
+		}
+		responses = append(responses, *resp)
+	}
+
+	return constants.HTTPSuccess.OkWithPagination(c, "Punch errors retrieved successfully", responses, page, limit, total)
+}
+
+// ResolvePunchError imports a queued punch for the selected employee (HR/Admin)
+func (ac *AttendanceController) ResolvePunchError(c *fiber.Ctx) error {
+	errorID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid punch error ID")
+	}
+
+	var req services.ResolvePunchErrorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	employeeID, err := helpers.DecryptObjectID(req.EmployeeID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid employee_id")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	result, err := ac.service.ResolvePunchError(errorID, employeeID, companyID, userID, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Punch error resolved successfully", result)
+}
+
+// DismissPunchError closes a queued punch without importing it (HR/Admin)
+func (ac *AttendanceController) DismissPunchError(c *fiber.Ctx) error {
+	errorID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid punch error ID")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	if err := ac.service.DismissPunchError(errorID, companyID, userID); err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Punch error dismissed successfully")
+}
+
+// GetMusterRoll returns the monthly muster roll as JSON or as a CSV, XLSX or PDF download (HR/Admin)
+func (ac *AttendanceController) GetMusterRoll(c *fiber.Ctx) error {
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	var departmentID primitive.ObjectID
+	if departmentIDStr := c.Query("department_id"); departmentIDStr != "" {
+		departmentID, err = helpers.DecryptObjectID(departmentIDStr)
+		if err != nil {
+			return constants.HTTPErrors.BadRequest(c, "Invalid department_id")
+		}
+	}
+
+	roll, err := ac.service.GetMusterRoll(companyID, c.Query("month"), departmentID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	format := c.Query("format", "json")
+	if format == "json" {
+		return constants.HTTPSuccess.OK(c, "Muster roll retrieved successfully", roll)
+	}
+
+	headers, rows := roll.Table()
+	filename := fmt.Sprintf("muster-roll-%s.%s", roll.Month, format)
+
+	var content []byte
+	switch format {
+	case "csv":
+		content, err = helpers.ExportCSV(headers, rows)
+		c.Set(fiber.HeaderContentType, "text/csv")
+	case "xlsx":
+		content, err = helpers.ExportXLSX("Muster Roll", headers, rows)
+		c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	case "pdf":
+		widths := []float64{18, 40, 28}
+		for range roll.Dates {
+			widths = append(widths, 8)
+		}
+		widths = append(widths, 8, 8, 8, 8, 8, 8, 12, 9, 10)
+		content, err = helpers.ExportPDF("Muster Roll - "+roll.Month, headers, rows, widths)
+		c.Set(fiber.HeaderContentType, "application/pdf")
+	default:
+		return constants.HTTPErrors.BadRequest(c, "format must be json, csv, xlsx or pdf")
+	}
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, "Failed to export muster roll")
+	}
+
+	c.Set(fiber.HeaderContentDisposition, "attachment; filename=\""+filename+"\"")
+	return c.Send(content)
+}
@@ -2,7 +2,11 @@
 package controllers
 
 import (
+	"errors"
+	"strconv"
+
 	"api.workzen.odoo/constants"
+	"api.workzen.odoo/helpers"
 	"api.workzen.odoo/middlewares"
 	"api.workzen.odoo/services"
 	"github.com/gofiber/fiber/v2"
@@ -40,15 +44,24 @@ func (ctrl *AuthController) Login(c *fiber.Ctx) error {
 		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
 	}
 
-	loginResponse, err := ctrl.authService.Login(&req)
+	loginResponse, err := ctrl.authService.Login(&req, loginClient(c))
 	if err != nil {
-		return constants.HTTPErrors.Unauthorized(c, err.Error())
+		return loginFailed(c, err)
+	}
+
+	if loginResponse.PasswordChangeRequired {
+		return constants.HTTPSuccess.OK(c, "Password expired, please set a new one", loginResponse)
+	}
+	if loginResponse.ChallengeToken != "" {
+		return constants.HTTPSuccess.OK(c, "Two-factor authentication required", loginResponse)
 	}
 
 	return constants.HTTPSuccess.OK(c, "Login successful", fiber.Map{
-		"token":   loginResponse.Token,
-		"user":    loginResponse.User,
-		"company": loginResponse.Company,
+		"token":         loginResponse.Token,
+		"refresh_token": loginResponse.RefreshToken,
+		"expires_in":    loginResponse.ExpiresIn,
+		"user":          loginResponse.User,
+		"company":       loginResponse.Company,
 	})
 }
 
@@ -84,7 +97,99 @@ func (ctrl *AuthController) ChangePassword(c *fiber.Ctx) error {
 		return constants.HTTPErrors.BadRequest(c, err.Error())
 	}
 
-	return constants.HTTPSuccess.OKWithoutData(c, "Password changed successfully")
+	return constants.HTTPSuccess.OKWithoutData(c, "Password changed successfully, please log in again")
+}
+
+// RefreshToken handles POST /api/v1/auth/refresh
+func (ctrl *AuthController) RefreshToken(c *fiber.Ctx) error {
+	var req services.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	tokens, err := ctrl.authService.RefreshSession(&req, loginClient(c))
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Token refreshed successfully", tokens)
+}
+
+// Logout handles POST /api/v1/auth/logout
+func (ctrl *AuthController) Logout(c *fiber.Ctx) error {
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	sessionID, err := middlewares.GetAuthSessionID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	if err := ctrl.authService.Logout(userID, sessionID); err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Logged out successfully")
+}
+
+// LogoutAll handles POST /api/v1/auth/logout-all
+func (ctrl *AuthController) LogoutAll(c *fiber.Ctx) error {
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	if err := ctrl.authService.LogoutAll(userID); err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Logged out of all devices")
+}
+
+// ListSessions handles GET /api/v1/auth/sessions
+func (ctrl *AuthController) ListSessions(c *fiber.Ctx) error {
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	sessionID, err := middlewares.GetAuthSessionID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	sessions, err := ctrl.authService.ListSessions(userID, sessionID)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Sessions retrieved successfully", sessions)
+}
+
+// RevokeSession handles DELETE /api/v1/auth/sessions/:id
+func (ctrl *AuthController) RevokeSession(c *fiber.Ctx) error {
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	currentSessionID, err := middlewares.GetAuthSessionID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	sessionID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid session ID")
+	}
+
+	if err := ctrl.authService.RevokeSession(userID, sessionID, currentSessionID); err != nil {
+		return constants.HTTPErrors.NotFound(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Session revoked successfully")
 }
 
 // VerifyEmail handles GET /api/v1/auth/verify-email
@@ -119,3 +224,192 @@ func (ctrl *AuthController) ResendVerificationEmail(c *fiber.Ctx) error {
 
 	return constants.HTTPSuccess.OKWithoutData(c, "Verification email sent successfully")
 }
+
+// ForgotPassword handles POST /api/v1/auth/forgot-password
+func (ctrl *AuthController) ForgotPassword(c *fiber.Ctx) error {
+	var req services.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	if err := ctrl.authService.ForgotPassword(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "If an account exists for this email, a password reset link has been sent")
+}
+
+// ResetPassword handles POST /api/v1/auth/reset-password
+func (ctrl *AuthController) ResetPassword(c *fiber.Ctx) error {
+	var req services.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	if err := ctrl.authService.ResetPassword(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Password reset successfully, please log in with your new password")
+}
+
+// VerifyTwoFactorLogin handles POST /api/v1/auth/login/2fa
+func (ctrl *AuthController) VerifyTwoFactorLogin(c *fiber.Ctx) error {
+	var req services.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	loginResponse, err := ctrl.authService.VerifyTwoFactorLogin(&req, loginClient(c))
+	if err != nil {
+		return loginFailed(c, err)
+	}
+
+	if loginResponse.PasswordChangeRequired {
+		return constants.HTTPSuccess.OK(c, "Password expired, please set a new one", loginResponse)
+	}
+
+	return constants.HTTPSuccess.OK(c, "Login successful", loginResponse)
+}
+
+// ChangeExpiredPassword handles POST /api/v1/auth/login/password
+func (ctrl *AuthController) ChangeExpiredPassword(c *fiber.Ctx) error {
+	var req services.ExpiredPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	loginResponse, err := ctrl.authService.ChangeExpiredPassword(&req, loginClient(c))
+	if err != nil {
+		var throttled *services.LoginThrottledError
+		if errors.As(err, &throttled) {
+			return loginFailed(c, err)
+		}
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Password changed, login successful", loginResponse)
+}
+
+// SetupTwoFactorWithChallenge handles POST /api/v1/auth/login/2fa/setup
+func (ctrl *AuthController) SetupTwoFactorWithChallenge(c *fiber.Ctx) error {
+	var req services.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	setup, err := ctrl.authService.SetupTwoFactorWithChallenge(req.ChallengeToken)
+	if err != nil {
+		return loginFailed(c, err)
+	}
+
+	return constants.HTTPSuccess.OK(c, "Scan the QR code with your authenticator app", setup)
+}
+
+// EnableTwoFactorWithChallenge handles POST /api/v1/auth/login/2fa/enable
+func (ctrl *AuthController) EnableTwoFactorWithChallenge(c *fiber.Ctx) error {
+	var req services.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	enabled, err := ctrl.authService.EnableTwoFactorWithChallenge(&req, loginClient(c))
+	if err != nil {
+		return loginFailed(c, err)
+	}
+
+	return constants.HTTPSuccess.OK(c, "Two-factor authentication enabled, login successful", enabled)
+}
+
+// SetupTwoFactor handles POST /api/v1/auth/2fa/setup
+func (ctrl *AuthController) SetupTwoFactor(c *fiber.Ctx) error {
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	setup, err := ctrl.authService.SetupTwoFactor(userID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Scan the QR code with your authenticator app", setup)
+}
+
+// EnableTwoFactor handles POST /api/v1/auth/2fa/enable
+func (ctrl *AuthController) EnableTwoFactor(c *fiber.Ctx) error {
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	var req services.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	enabled, err := ctrl.authService.EnableTwoFactor(userID, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Two-factor authentication enabled", enabled)
+}
+
+// DisableTwoFactor handles POST /api/v1/auth/2fa/disable
+func (ctrl *AuthController) DisableTwoFactor(c *fiber.Ctx) error {
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	var req services.DisableTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	if err := ctrl.authService.DisableTwoFactor(userID, &req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Two-factor authentication disabled")
+}
+
+// RegenerateRecoveryCodes handles POST /api/v1/auth/2fa/recovery-codes
+func (ctrl *AuthController) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	var req services.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	codes, err := ctrl.authService.RegenerateRecoveryCodes(userID, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Recovery codes regenerated", fiber.Map{"recovery_codes": codes})
+}
+
+// loginClient describes the device a login or token refresh comes from
+func loginClient(c *fiber.Ctx) services.LoginClient {
+	return services.LoginClient{
+		IPAddress: c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+}
+
+// loginFailed answers a failed login step: 429 with Retry-After while the account or IP address must
+// wait, 401 otherwise
+func loginFailed(c *fiber.Ctx, err error) error {
+	var throttled *services.LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(throttled.RetryAfter.Seconds())))
+		return constants.HTTPErrors.TooManyRequests(c, err.Error())
+	}
+	return constants.HTTPErrors.Unauthorized(c, err.Error())
+}
@@ -5,6 +5,7 @@ import (
 
 	"api.workzen.odoo/constants"
 	"api.workzen.odoo/encryptions"
+	"api.workzen.odoo/middlewares"
 	"api.workzen.odoo/services"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -116,3 +117,83 @@ func (cc *CompanyController) DeactivateCompany(c *fiber.Ctx) error {
 
 	return constants.HTTPSuccess.OKWithoutData(c, "Company deactivated successfully")
 }
+
+// GetTwoFactorPolicy returns who in the user's company must use two-factor authentication
+func (cc *CompanyController) GetTwoFactorPolicy(c *fiber.Ctx) error {
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	policy, err := cc.service.GetTwoFactorPolicy(companyID)
+	if err != nil {
+		return constants.HTTPErrors.NotFound(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Two-factor policy retrieved successfully", policy)
+}
+
+// SaveTwoFactorPolicy enforces two-factor authentication for the whole company or some roles (Admin only)
+func (cc *CompanyController) SaveTwoFactorPolicy(c *fiber.Ctx) error {
+	var req services.SaveTwoFactorPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	policy, err := cc.service.SaveTwoFactorPolicy(companyID, userID, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Two-factor policy saved successfully", policy)
+}
+
+// GetPasswordPolicy returns the password rules of the user's company
+func (cc *CompanyController) GetPasswordPolicy(c *fiber.Ctx) error {
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	policy, err := cc.service.GetPasswordPolicy(companyID)
+	if err != nil {
+		return constants.HTTPErrors.NotFound(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Password policy retrieved successfully", policy)
+}
+
+// SavePasswordPolicy sets the password rules of the company (Admin only)
+func (cc *CompanyController) SavePasswordPolicy(c *fiber.Ctx) error {
+	var req services.SavePasswordPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	policy, err := cc.service.SavePasswordPolicy(companyID, userID, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Password policy saved successfully", policy)
+}
@@ -29,7 +29,7 @@ func (dc *DashboardController) GetDashboard(c *fiber.Ctx) error {
 		return constants.HTTPErrors.Unauthorized(c, err.Error())
 	}
 
-	stats, err := dc.service.GetAdminDashboard(companyID, user.Role)
+	stats, err := dc.service.GetAdminDashboard(companyID, user)
 	if err != nil {
 		return constants.HTTPErrors.InternalServerError(c, err.Error())
 	}
@@ -49,7 +49,7 @@ func (dc *DashboardController) GetAdminDashboard(c *fiber.Ctx) error {
 		return constants.HTTPErrors.Unauthorized(c, err.Error())
 	}
 
-	stats, err := dc.service.GetAdminDashboard(companyID, user.Role)
+	stats, err := dc.service.GetAdminDashboard(companyID, user)
 	if err != nil {
 		return constants.HTTPErrors.InternalServerError(c, err.Error())
 	}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"api.workzen.odoo/constants"
+	"api.workzen.odoo/databases/models"
 	"api.workzen.odoo/helpers"
 	"api.workzen.odoo/middlewares"
 	"api.workzen.odoo/services"
@@ -58,6 +59,15 @@ func (dc *DocumentController) UploadDocument(c *fiber.Ctx) error {
 		employeeID = empID.Hex()
 	}
 
+	// Employees upload their own documents, e.g. a medical certificate for a leave
+	user, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+	if !services.HasPermission(user, models.PermDocumentViewAll, companyID) {
+		employeeID = uploadedBy.Hex()
+	}
+
 	req := &services.UploadDocumentRequest{
 		Category:    category,
 		Description: description,
@@ -90,9 +100,8 @@ func (dc *DocumentController) ListDocuments(c *fiber.Ctx) error {
 		return constants.HTTPErrors.Unauthorized(c, err.Error())
 	}
 
-	// For regular employees, filter to show only their own documents
-	// HR and Admin can see all documents
-	if !user.IsSuperAdmin && user.Role != "admin" && user.Role != "hr" {
+	// Without document.view_all, users only see their own documents
+	if !services.HasPermission(user, models.PermDocumentViewAll, companyID) {
 		// Override employee_id to current user's ID
 		userID, _ := middlewares.GetAuthUserID(c)
 		employeeID = userID.Hex()
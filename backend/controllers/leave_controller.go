@@ -1,13 +1,17 @@
 package controllers
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 
 	"api.workzen.odoo/constants"
+	"api.workzen.odoo/databases/models"
 	"api.workzen.odoo/helpers"
 	"api.workzen.odoo/middlewares"
 	"api.workzen.odoo/services"
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type LeaveController struct {
@@ -69,10 +73,12 @@ func (lc *LeaveController) ListLeaves(c *fiber.Ctx) error {
 	if status := c.Query("status"); status != "" {
 		filters["status"] = status
 	}
+	if documentStatus := c.Query("document_status"); documentStatus != "" {
+		filters["document_status"] = documentStatus
+	}
 
-	// For regular employees, filter to show only their own leaves
-	// HR and Admin can see all leaves
-	if !user.IsSuperAdmin && user.Role != "admin" && user.Role != "hr" {
+	// Without leave.view_all, users only see their own leaves
+	if !services.HasPermission(user, models.PermLeaveViewAll, companyID) {
 		userID, _ := middlewares.GetAuthUserID(c)
 		filters["employee_id"] = userID.Hex()
 	}
@@ -95,44 +101,946 @@ func (lc *LeaveController) ListLeaves(c *fiber.Ctx) error {
 	return constants.HTTPSuccess.OkWithPagination(c, "Leaves retrieved successfully", responses, page, limit, total)
 }
 
-// ApproveLeave approves a leave request
+// ApproveLeave approves the current step of a leave's approval chain. Attendance or staffing
+// conflicts are refused with 409 unless the body sets acknowledge_conflicts.
 func (lc *LeaveController) ApproveLeave(c *fiber.Ctx) error {
+	var req services.ApproveLeaveRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+		}
+	}
+
 	id := c.Params("id")
 	leaveID, err := helpers.DecryptObjectID(id)
 	if err != nil {
 		return constants.HTTPErrors.BadRequest(c, "Invalid leave ID")
 	}
 
-	approverID, err := middlewares.GetAuthUserID(c)
+	approver, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
 	if err != nil {
 		return constants.HTTPErrors.Unauthorized(c, err.Error())
 	}
 
-	err = lc.service.ApproveLeave(leaveID, approverID)
+	leave, conflicts, err := lc.service.ApproveLeave(leaveID, companyID, approver, &req)
+	if errors.Is(err, services.ErrLeaveConflicts) {
+		return constants.HTTPErrors.Conflict(c, err.Error())
+	}
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	resp, err := services.ConvertLeaveToResponse(leave)
 	if err != nil {
 		return constants.HTTPErrors.InternalServerError(c, err.Error())
 	}
 
-	return constants.HTTPSuccess.OKWithoutData(c, "Leave approved successfully")
+	message := "Leave approved successfully"
+	if leave.Status == models.LeavePending {
+		message = "Leave approved and forwarded to the next approver"
+	}
+	return constants.HTTPSuccess.OK(c, message, services.LeaveApprovalResponse{Leave: resp, Conflicts: conflicts})
+}
+
+// GetLeaveConflicts shows the attendance and team staffing a leave would conflict with
+func (lc *LeaveController) GetLeaveConflicts(c *fiber.Ctx) error {
+	leaveID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid leave ID")
+	}
+
+	user, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	conflicts, err := lc.service.GetLeaveConflicts(leaveID, companyID, user)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Leave conflicts retrieved successfully", conflicts)
 }
 
-// RejectLeave rejects a leave request
+// RejectLeave rejects a leave at the current step of its approval chain
 func (lc *LeaveController) RejectLeave(c *fiber.Ctx) error {
+	var req services.RejectLeaveRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+		}
+	}
+
 	id := c.Params("id")
 	leaveID, err := helpers.DecryptObjectID(id)
 	if err != nil {
 		return constants.HTTPErrors.BadRequest(c, "Invalid leave ID")
 	}
 
-	approverID, err := middlewares.GetAuthUserID(c)
+	approver, err := middlewares.GetAuthUser(c)
 	if err != nil {
 		return constants.HTTPErrors.Unauthorized(c, err.Error())
 	}
 
-	err = lc.service.RejectLeave(leaveID, approverID)
+	companyID, err := middlewares.GetAuthCompanyID(c)
 	if err != nil {
-		return constants.HTTPErrors.InternalServerError(c, err.Error())
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	err = lc.service.RejectLeave(leaveID, companyID, approver, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
 	}
 
 	return constants.HTTPSuccess.OKWithoutData(c, "Leave rejected successfully")
 }
+
+// ListPendingApprovals retrieves the pending leaves waiting on the current user
+func (lc *LeaveController) ListPendingApprovals(c *fiber.Ctx) error {
+	page, _ := strconv.ParseInt(c.Query("page", "1"), 10, 64)
+	limit, _ := strconv.ParseInt(c.Query("limit", "10"), 10, 64)
+
+	user, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	leaves, total, err := lc.service.ListPendingApprovals(user, companyID, page, limit)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	responses := []services.LeaveResponse{}
+	for _, leave := range leaves {
+		resp, err := services.ConvertLeaveToResponseWithUser(&leave)
+		if err != nil {
+			return constants.HTTPErrors.InternalServerError(c, err.Error())
+		}
+		responses = append(responses, *resp)
+	}
+
+	return constants.HTTPSuccess.OkWithPagination(c, "Pending approvals retrieved successfully", responses, page, limit, total)
+}
+
+// CreateDelegation hands the current user's leave approvals to another user for a period
+func (lc *LeaveController) CreateDelegation(c *fiber.Ctx) error {
+	var req services.CreateLeaveDelegationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	delegation, err := lc.service.CreateDelegation(&req, userID, companyID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	resp, err := services.ConvertLeaveDelegationToResponse(delegation)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.Created(c, "Delegation created successfully", resp)
+}
+
+// ListDelegations retrieves the delegations the current user gave or received
+func (lc *LeaveController) ListDelegations(c *fiber.Ctx) error {
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	delegations, err := lc.service.ListDelegations(userID, companyID)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	responses := []services.LeaveDelegationResponse{}
+	for i := range delegations {
+		resp, err := services.ConvertLeaveDelegationToResponse(&delegations[i])
+		if err != nil {
+			return constants.HTTPErrors.InternalServerError(c, err.Error())
+		}
+		responses = append(responses, *resp)
+	}
+
+	return constants.HTTPSuccess.OK(c, "Delegations retrieved successfully", responses)
+}
+
+// RevokeDelegation ends one of the current user's delegations
+func (lc *LeaveController) RevokeDelegation(c *fiber.Ctx) error {
+	delegationID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid delegation ID")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	if err := lc.service.RevokeDelegation(delegationID, userID); err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Delegation revoked successfully")
+}
+
+// CancelLeave cancels the employee's own leave, or part of it when from_date is given
+func (lc *LeaveController) CancelLeave(c *fiber.Ctx) error {
+	var req services.CancelLeaveRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	leaveID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid leave ID")
+	}
+
+	employeeID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	leave, err := lc.service.CancelLeave(leaveID, employeeID, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	resp, err := services.ConvertLeaveToResponse(leave)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	if resp.PendingChange != nil {
+		return constants.HTTPSuccess.OK(c, "Leave cancellation submitted for approval", resp)
+	}
+	return constants.HTTPSuccess.OK(c, "Leave cancelled successfully", resp)
+}
+
+// ModifyLeave moves the employee's own leave to new dates
+func (lc *LeaveController) ModifyLeave(c *fiber.Ctx) error {
+	var req services.ModifyLeaveRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	leaveID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid leave ID")
+	}
+
+	employeeID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	leave, err := lc.service.ModifyLeave(leaveID, employeeID, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	resp, err := services.ConvertLeaveToResponse(leave)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	if resp.PendingChange != nil {
+		return constants.HTTPSuccess.OK(c, "Leave change submitted for approval", resp)
+	}
+	return constants.HTTPSuccess.OK(c, "Leave updated successfully", resp)
+}
+
+// AttachLeaveDocuments adds supporting documents, such as a medical certificate, to the user's own leave
+func (lc *LeaveController) AttachLeaveDocuments(c *fiber.Ctx) error {
+	var req services.AttachLeaveDocumentsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	leaveID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid leave ID")
+	}
+
+	employeeID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	leave, err := lc.service.AttachLeaveDocuments(leaveID, employeeID, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	resp, err := services.ConvertLeaveToResponse(leave)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Documents attached successfully", resp)
+}
+
+// ApproveLeaveChange applies the pending cancellation or date change of an approved leave
+func (lc *LeaveController) ApproveLeaveChange(c *fiber.Ctx) error {
+	return lc.reviewLeaveChange(c, true)
+}
+
+// RejectLeaveChange discards the pending cancellation or date change of an approved leave
+func (lc *LeaveController) RejectLeaveChange(c *fiber.Ctx) error {
+	return lc.reviewLeaveChange(c, false)
+}
+
+func (lc *LeaveController) reviewLeaveChange(c *fiber.Ctx, approve bool) error {
+	var req services.ReviewLeaveChangeRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+		}
+	}
+
+	leaveID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid leave ID")
+	}
+
+	reviewer, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	var leave *models.Leave
+	message := "Leave change approved successfully"
+	if approve {
+		leave, err = lc.service.ApproveLeaveChange(leaveID, companyID, reviewer, &req)
+	} else {
+		leave, err = lc.service.RejectLeaveChange(leaveID, companyID, reviewer, &req)
+		message = "Leave change rejected successfully"
+	}
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	resp, err := services.ConvertLeaveToResponse(leave)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, message, resp)
+}
+
+// resolveEmployeeID returns the employee whose leave data is requested.
+// Employees always get their own; HR and Admin may pass an encrypted employee_id.
+func resolveEmployeeID(c *fiber.Ctx, encryptedID string) (primitive.ObjectID, error) {
+	user, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	if encryptedID == "" {
+		return user.ID, nil
+	}
+
+	if !services.HasPermission(user, models.PermLeaveViewAll, user.Company) {
+		return primitive.NilObjectID, fiber.NewError(fiber.StatusForbidden, "Insufficient permissions")
+	}
+
+	return helpers.DecryptObjectID(encryptedID)
+}
+
+// SaveConfiguration creates or updates the company leave configuration (Admin)
+func (lc *LeaveController) SaveConfiguration(c *fiber.Ctx) error {
+	var req services.SaveLeaveConfigurationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	config, err := lc.service.SaveConfiguration(&req, companyID, userID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Leave configuration saved successfully", config)
+}
+
+// GetConfiguration retrieves the company leave configuration
+func (lc *LeaveController) GetConfiguration(c *fiber.Ctx) error {
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	config, err := lc.service.GetConfiguration(companyID)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Leave configuration retrieved successfully", config)
+}
+
+// ListLeaveTypes retrieves the company's leave types; include_inactive=true also returns disabled ones
+func (lc *LeaveController) ListLeaveTypes(c *fiber.Ctx) error {
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	leaveTypes, err := lc.service.ListLeaveTypes(companyID, c.QueryBool("include_inactive"))
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Leave types retrieved successfully", leaveTypes)
+}
+
+// CreateLeaveType adds a leave type for the company (Admin)
+func (lc *LeaveController) CreateLeaveType(c *fiber.Ctx) error {
+	var req services.SaveLeaveTypeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	leaveType, err := lc.service.CreateLeaveType(&req, companyID, userID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.Created(c, "Leave type created successfully", leaveType)
+}
+
+// UpdateLeaveType updates a leave type by its code (Admin)
+func (lc *LeaveController) UpdateLeaveType(c *fiber.Ctx) error {
+	var req services.SaveLeaveTypeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	leaveType, err := lc.service.UpdateLeaveType(c.Params("code"), &req, companyID, userID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Leave type updated successfully", leaveType)
+}
+
+// ListPolicies retrieves the company's leave policies
+func (lc *LeaveController) ListPolicies(c *fiber.Ctx) error {
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	policies, err := lc.service.ListPolicies(companyID)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Leave policies retrieved successfully", policies)
+}
+
+// SavePolicy creates or updates the policy of a leave type (Admin)
+func (lc *LeaveController) SavePolicy(c *fiber.Ctx) error {
+	var req services.SaveLeavePolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	policy, err := lc.service.SavePolicy(&req, companyID, userID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Leave policy saved successfully", policy)
+}
+
+// GetBalances retrieves leave balances of the current user, or of an employee for HR/Admin
+func (lc *LeaveController) GetBalances(c *fiber.Ctx) error {
+	employeeID, err := resolveEmployeeID(c, c.Query("employee_id"))
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok && fiberErr.Code == fiber.StatusForbidden {
+			return constants.HTTPErrors.Forbidden(c, fiberErr.Message)
+		}
+		return constants.HTTPErrors.BadRequest(c, "Invalid employee_id")
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	balances, err := lc.service.GetBalances(employeeID, companyID)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Leave balances retrieved successfully", balances)
+}
+
+// ListLedger retrieves leave ledger entries of the current user, or of an employee for HR/Admin
+func (lc *LeaveController) ListLedger(c *fiber.Ctx) error {
+	page, _ := strconv.ParseInt(c.Query("page", "1"), 10, 64)
+	limit, _ := strconv.ParseInt(c.Query("limit", "20"), 10, 64)
+
+	employeeID, err := resolveEmployeeID(c, c.Query("employee_id"))
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok && fiberErr.Code == fiber.StatusForbidden {
+			return constants.HTTPErrors.Forbidden(c, fiberErr.Message)
+		}
+		return constants.HTTPErrors.BadRequest(c, "Invalid employee_id")
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	entries, total, err := lc.service.ListLedger(employeeID, companyID, c.Query("leave_type"), page, limit)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	responses := []services.LeaveLedgerEntryResponse{}
+	for i := range entries {
+		resp, err := services.ConvertLedgerEntryToResponse(&entries[i])
+		if err != nil {
+			return constants.HTTPErrors.InternalServerError(c, err.Error())
+		}
+		responses = append(responses, *resp)
+	}
+
+	return constants.HTTPSuccess.OkWithPagination(c, "Leave ledger retrieved successfully", responses, page, limit, total)
+}
+
+// AdjustBalance records a manual balance correction (HR/Admin)
+func (lc *LeaveController) AdjustBalance(c *fiber.Ctx) error {
+	var req services.AdjustLeaveBalanceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	employeeID, err := helpers.DecryptObjectID(req.EmployeeID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid employee_id")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	entry, err := lc.service.AdjustBalance(&req, employeeID, companyID, userID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	resp, err := services.ConvertLedgerEntryToResponse(entry)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.Created(c, "Leave balance adjusted successfully", resp)
+}
+
+// RunAccrual credits due leave accruals for the company (HR/Admin)
+func (lc *LeaveController) RunAccrual(c *fiber.Ctx) error {
+	var req services.RunAccrualRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+		}
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	result, err := lc.service.RunAccrual(&req, companyID, userID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Leave accrual completed successfully", result)
+}
+
+// RunRollover closes a leave year for the company, or previews it with dry_run (HR/Admin)
+func (lc *LeaveController) RunRollover(c *fiber.Ctx) error {
+	var req services.RunLeaveRolloverRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	rollover, err := lc.service.RunRollover(&req, companyID, userID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	resp, err := services.ConvertLeaveRolloverToResponse(rollover)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	if req.DryRun {
+		return constants.HTTPSuccess.OK(c, "Leave rollover preview generated successfully", resp)
+	}
+	return constants.HTTPSuccess.Created(c, "Leave rollover completed successfully", resp)
+}
+
+// ListRollovers lists the company's leave rollovers (HR/Admin)
+func (lc *LeaveController) ListRollovers(c *fiber.Ctx) error {
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	rollovers, err := lc.service.ListRollovers(companyID)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	responses := make([]services.LeaveRolloverResponse, 0, len(rollovers))
+	for i := range rollovers {
+		resp, err := services.ConvertLeaveRolloverToResponse(&rollovers[i])
+		if err != nil {
+			return constants.HTTPErrors.InternalServerError(c, err.Error())
+		}
+		responses = append(responses, *resp)
+	}
+
+	return constants.HTTPSuccess.OK(c, "Leave rollovers retrieved successfully", responses)
+}
+
+// ReverseRollover undoes a leave rollover whose encashment has not been paid yet (HR/Admin)
+func (lc *LeaveController) ReverseRollover(c *fiber.Ctx) error {
+	rolloverID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid rollover ID")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	rollover, err := lc.service.ReverseRollover(rolloverID, companyID, userID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	resp, err := services.ConvertLeaveRolloverToResponse(rollover)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Leave rollover reversed successfully", resp)
+}
+
+// RequestCompOff claims comp-off for a worked weekly-off or holiday
+func (lc *LeaveController) RequestCompOff(c *fiber.Ctx) error {
+	var req services.RequestCompOffRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	credit, err := lc.service.RequestCompOff(&req, userID, companyID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	resp, err := services.ConvertCompOffToResponse(credit)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.Created(c, "Comp-off requested successfully", resp)
+}
+
+// ListCompOffs retrieves comp-off credits visible to the current user
+func (lc *LeaveController) ListCompOffs(c *fiber.Ctx) error {
+	page, _ := strconv.ParseInt(c.Query("page", "1"), 10, 64)
+	limit, _ := strconv.ParseInt(c.Query("limit", "10"), 10, 64)
+
+	user, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	credits, total, err := lc.service.ListCompOffs(user, companyID, c.Query("status"), page, limit)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	responses := []services.CompOffCreditResponse{}
+	for i := range credits {
+		resp, err := services.ConvertCompOffToResponse(&credits[i])
+		if err != nil {
+			return constants.HTTPErrors.InternalServerError(c, err.Error())
+		}
+		responses = append(responses, *resp)
+	}
+
+	return constants.HTTPSuccess.OkWithPagination(c, "Comp-offs retrieved successfully", responses, page, limit, total)
+}
+
+// ApproveCompOff grants a comp-off credit (manager or HR)
+func (lc *LeaveController) ApproveCompOff(c *fiber.Ctx) error {
+	creditID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid comp-off ID")
+	}
+
+	var req services.ReviewCompOffRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+		}
+	}
+
+	user, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	credit, err := lc.service.ApproveCompOff(creditID, companyID, user, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	resp, err := services.ConvertCompOffToResponse(credit)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Comp-off approved successfully", resp)
+}
+
+// RejectCompOff rejects a comp-off credit (manager or HR)
+func (lc *LeaveController) RejectCompOff(c *fiber.Ctx) error {
+	creditID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid comp-off ID")
+	}
+
+	var req services.ReviewCompOffRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+		}
+	}
+
+	user, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	if err := lc.service.RejectCompOff(creditID, companyID, user, &req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Comp-off rejected successfully")
+}
+
+// GetLeaveCalendar returns the pending and approved leave of the user's team, department or company
+// between from and to (default: the current month)
+func (lc *LeaveController) GetLeaveCalendar(c *fiber.Ctx) error {
+	user, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	var departmentID primitive.ObjectID
+	if departmentIDStr := c.Query("department_id"); departmentIDStr != "" {
+		departmentID, err = helpers.DecryptObjectID(departmentIDStr)
+		if err != nil {
+			return constants.HTTPErrors.BadRequest(c, "Invalid department_id")
+		}
+	}
+
+	calendar, err := lc.service.GetLeaveCalendar(user, companyID, c.Query("from"), c.Query("to"), departmentID)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	resp, err := services.ConvertLeaveCalendarToResponse(calendar)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Leave calendar retrieved successfully", resp)
+}
+
+// CreateCalendarFeed issues a new iCalendar feed URL for the user; any previous URL stops working
+func (lc *LeaveController) CreateCalendarFeed(c *fiber.Ctx) error {
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	token, err := lc.service.CreateCalendarFeedToken(userID)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.Created(c, "Calendar feed created successfully", fiber.Map{
+		"url": c.BaseURL() + "/api/v1/calendar/feed/" + token + ".ics",
+	})
+}
+
+// RevokeCalendarFeed disables the user's iCalendar feed URL
+func (lc *LeaveController) RevokeCalendarFeed(c *fiber.Ctx) error {
+	userID, err := middlewares.GetAuthUserID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	if err := lc.service.RevokeCalendarFeedToken(userID); err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Calendar feed revoked successfully")
+}
+
+// GetCalendarFeed serves a user's leave calendar as iCalendar; the secret token in the URL
+// authenticates calendar apps that cannot send a bearer token
+func (lc *LeaveController) GetCalendarFeed(c *fiber.Ctx) error {
+	token := strings.TrimSuffix(c.Params("token"), ".ics")
+
+	content, err := lc.service.GetCalendarFeed(token)
+	if err != nil {
+		return constants.HTTPErrors.NotFound(c, err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderCacheControl, "private, max-age=900")
+	return c.Send(content)
+}
@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"api.workzen.odoo/constants"
+	"api.workzen.odoo/databases/models"
 	"api.workzen.odoo/helpers"
 	"api.workzen.odoo/middlewares"
 	"api.workzen.odoo/services"
@@ -96,9 +97,8 @@ func (pc *PayrollController) ListPayruns(c *fiber.Ctx) error {
 		return constants.HTTPErrors.Unauthorized(c, err.Error())
 	}
 
-	// For regular employees, they can only see their own payroll
-	// Admin and Payroll roles can see all payruns
-	if !user.IsSuperAdmin && user.Role != "admin" && user.Role != "payroll" {
+	// Without payroll.view_all, users only see their own payroll
+	if !services.HasPermission(user, models.PermPayrollViewAll, companyID) {
 		// Return empty list for regular employees on this endpoint
 		// They should use /payrolls/:employee_id endpoint instead
 		return constants.HTTPSuccess.OkWithPagination(c, "Payruns retrieved successfully", []interface{}{}, page, limit, 0)
@@ -125,6 +125,15 @@ func (pc *PayrollController) GetEmployeePayroll(c *fiber.Ctx) error {
 		return constants.HTTPErrors.BadRequest(c, "Month parameter is required")
 	}
 
+	// Without payroll.view_all, users only see their own payslips
+	user, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+	if user.ID != employeeID && !services.HasPermission(user, models.PermPayrollViewAll, user.Company) {
+		return constants.HTTPErrors.Forbidden(c, "Insufficient permissions")
+	}
+
 	payroll, err := pc.service.GetEmployeePayroll(employeeID, month)
 	if err != nil {
 		return constants.HTTPErrors.NotFound(c, err.Error())
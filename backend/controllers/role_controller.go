@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"api.workzen.odoo/constants"
+	"api.workzen.odoo/helpers"
+	"api.workzen.odoo/middlewares"
+	"api.workzen.odoo/services"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RoleController struct {
+	service *services.RoleService
+}
+
+func NewRoleController() *RoleController {
+	return &RoleController{
+		service: services.NewRoleService(),
+	}
+}
+
+// ListPermissions lists every permission a custom role can hold
+func (rc *RoleController) ListPermissions(c *fiber.Ctx) error {
+	return constants.HTTPSuccess.OK(c, "Permissions retrieved successfully", rc.service.ListPermissions())
+}
+
+// ListRoles lists the built-in roles and the company's custom roles with their permissions
+func (rc *RoleController) ListRoles(c *fiber.Ctx) error {
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	roles, err := rc.service.ListRoles(companyID)
+	if err != nil {
+		return constants.HTTPErrors.InternalServerError(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Roles retrieved successfully", roles)
+}
+
+// CreateRole creates a custom role for the company
+func (rc *RoleController) CreateRole(c *fiber.Ctx) error {
+	var req services.SaveRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	authUser, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	role, err := rc.service.CreateRole(companyID, authUser, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.Created(c, "Role created successfully", role)
+}
+
+// UpdateRole updates the name, description or permissions of a custom role
+func (rc *RoleController) UpdateRole(c *fiber.Ctx) error {
+	roleID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid role ID")
+	}
+
+	var req services.SaveRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	authUser, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	role, err := rc.service.UpdateRole(roleID, companyID, authUser, &req)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Role updated successfully", role)
+}
+
+// DeleteRole soft deletes a custom role and unassigns it from its users
+func (rc *RoleController) DeleteRole(c *fiber.Ctx) error {
+	roleID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid role ID")
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	authUser, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	if err := rc.service.DeleteRole(roleID, companyID, authUser); err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Role deleted successfully")
+}
+
+// AssignRole gives a user a custom role, or returns them to their built-in role
+func (rc *RoleController) AssignRole(c *fiber.Ctx) error {
+	userID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid user ID")
+	}
+
+	var req services.AssignRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
+	}
+
+	roleID := primitive.NilObjectID
+	if req.CustomRoleID != "" {
+		roleID, err = helpers.DecryptObjectID(req.CustomRoleID)
+		if err != nil {
+			return constants.HTTPErrors.BadRequest(c, "Invalid role ID")
+		}
+	}
+
+	companyID, err := middlewares.GetAuthCompanyID(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	authUser, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	if err := rc.service.AssignRole(userID, roleID, companyID, authUser); err != nil {
+		return constants.HTTPErrors.BadRequest(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Role assigned successfully")
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"api.workzen.odoo/constants"
+	"api.workzen.odoo/databases/models"
 	"api.workzen.odoo/helpers"
 	"api.workzen.odoo/middlewares"
 	"api.workzen.odoo/services"
@@ -46,6 +47,15 @@ func (sc *SalaryController) GetSalaryStructure(c *fiber.Ctx) error {
 		return constants.HTTPErrors.BadRequest(c, "Invalid employee ID")
 	}
 
+	// Without salary.view_all, users only see their own salary structure
+	user, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+	if user.ID != employeeID && !services.HasPermission(user, models.PermSalaryViewAll, user.Company) {
+		return constants.HTTPErrors.Forbidden(c, "Insufficient permissions")
+	}
+
 	salary, err := sc.service.GetSalaryStructure(employeeID)
 	if err != nil {
 		return constants.HTTPErrors.NotFound(c, err.Error())
@@ -32,6 +32,8 @@ type CreateUserRequestAPI struct {
 	DepartmentID string `json:"department_id"`
 	ManagerID    string `json:"manager_id"`
 	DateOfJoin   string `json:"date_of_join"`
+	Gender       string `json:"gender"`
+	WorkFromHome bool   `json:"work_from_home_allowed"`
 }
 
 // CreateUser creates a new employee
@@ -46,20 +48,22 @@ func (uc *UserController) CreateUser(c *fiber.Ctx) error {
 		return constants.HTTPErrors.Unauthorized(c, err.Error())
 	}
 
-	authUserID, err := middlewares.GetAuthUserID(c)
+	authUser, err := middlewares.GetAuthUser(c)
 	if err != nil {
 		return constants.HTTPErrors.Unauthorized(c, err.Error())
 	}
 
 	// Convert API request to service request
 	req := services.CreateUserRequest{
-		FirstName:   reqAPI.FirstName,
-		LastName:    reqAPI.LastName,
-		Email:       reqAPI.Email,
-		Phone:       reqAPI.Phone,
-		Role:        models.Role(reqAPI.Role),
-		Designation: reqAPI.Designation,
-		DateOfJoin:  reqAPI.DateOfJoin,
+		FirstName:    reqAPI.FirstName,
+		LastName:     reqAPI.LastName,
+		Email:        reqAPI.Email,
+		Phone:        reqAPI.Phone,
+		Role:         models.Role(reqAPI.Role),
+		Designation:  reqAPI.Designation,
+		DateOfJoin:   reqAPI.DateOfJoin,
+		Gender:       models.Gender(reqAPI.Gender),
+		WorkFromHome: reqAPI.WorkFromHome,
 	}
 
 	// Decrypt department_id if provided
@@ -80,7 +84,7 @@ func (uc *UserController) CreateUser(c *fiber.Ctx) error {
 		req.ManagerID = &mgrID
 	}
 
-	user, password, err := uc.service.CreateUser(&req, companyID, authUserID)
+	user, password, err := uc.service.CreateUser(&req, companyID, authUser)
 	if err != nil {
 		return constants.HTTPErrors.InternalServerError(c, err.Error())
 	}
@@ -149,6 +153,8 @@ type UpdateUserRequestAPI struct {
 	Designation  string `json:"designation"`
 	DepartmentID string `json:"department_id"`
 	Password     string `json:"password"`
+	Gender       string `json:"gender"`
+	WorkFromHome *bool  `json:"work_from_home_allowed"`
 }
 
 // UpdateUser updates user details
@@ -164,20 +170,22 @@ func (uc *UserController) UpdateUser(c *fiber.Ctx) error {
 		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
 	}
 
-	authUserID, err := middlewares.GetAuthUserID(c)
+	authUser, err := middlewares.GetAuthUser(c)
 	if err != nil {
 		return constants.HTTPErrors.Unauthorized(c, err.Error())
 	}
 
 	// Convert API request to service request
 	req := services.UpdateUserRequest{
-		FirstName:   reqAPI.FirstName,
-		LastName:    reqAPI.LastName,
-		Email:       reqAPI.Email,
-		Phone:       reqAPI.Phone,
-		Role:        models.Role(reqAPI.Role),
-		Designation: reqAPI.Designation,
-		Password:    reqAPI.Password,
+		FirstName:    reqAPI.FirstName,
+		LastName:     reqAPI.LastName,
+		Email:        reqAPI.Email,
+		Phone:        reqAPI.Phone,
+		Role:         models.Role(reqAPI.Role),
+		Designation:  reqAPI.Designation,
+		Password:     reqAPI.Password,
+		Gender:       models.Gender(reqAPI.Gender),
+		WorkFromHome: reqAPI.WorkFromHome,
 	}
 
 	// Decrypt department_id if provided
@@ -189,9 +197,9 @@ func (uc *UserController) UpdateUser(c *fiber.Ctx) error {
 		req.DepartmentID = &deptID
 	}
 
-	user, err := uc.service.UpdateUser(userID, authUserID, &req)
+	user, err := uc.service.UpdateUser(userID, authUser, &req)
 	if err != nil {
-		return constants.HTTPErrors.InternalServerError(c, err.Error())
+		return constants.HTTPErrors.BadRequest(c, err.Error())
 	}
 
 	return constants.HTTPSuccess.OK(c, "User updated successfully", user)
@@ -225,7 +233,7 @@ func (uc *UserController) UpdateUserStatus(c *fiber.Ctx) error {
 	return constants.HTTPSuccess.OKWithoutData(c, "User status updated successfully")
 }
 
-// UpdateBankDetails updates user bank details
+// UpdateBankDetails updates a user's bank details: one's own, or anyone's with salary.manage
 func (uc *UserController) UpdateBankDetails(c *fiber.Ctx) error {
 	id := c.Params("id")
 	userID, err := helpers.DecryptObjectID(id)
@@ -233,7 +241,7 @@ func (uc *UserController) UpdateBankDetails(c *fiber.Ctx) error {
 		return constants.HTTPErrors.BadRequest(c, "Invalid user ID")
 	}
 
-	authUserID, err := middlewares.GetAuthUserID(c)
+	authUser, err := middlewares.GetAuthUser(c)
 	if err != nil {
 		return constants.HTTPErrors.Unauthorized(c, err.Error())
 	}
@@ -243,14 +251,55 @@ func (uc *UserController) UpdateBankDetails(c *fiber.Ctx) error {
 		return constants.HTTPErrors.BadRequest(c, "Invalid request body")
 	}
 
-	err = uc.service.UpdateBankDetails(userID, authUserID, &req)
+	err = uc.service.UpdateBankDetails(userID, authUser, c.IP(), &req)
 	if err != nil {
-		return constants.HTTPErrors.InternalServerError(c, err.Error())
+		return constants.HTTPErrors.BadRequest(c, err.Error())
 	}
 
 	return constants.HTTPSuccess.OKWithoutData(c, "Bank details updated successfully")
 }
 
+// RevealBankDetails returns a user's unmasked bank details to payroll officers and admins; every
+// reveal is logged
+func (uc *UserController) RevealBankDetails(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userID, err := helpers.DecryptObjectID(id)
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid user ID")
+	}
+
+	authUser, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	details, err := uc.service.RevealBankDetails(userID, authUser, c.IP())
+	if err != nil {
+		return constants.HTTPErrors.NotFound(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Bank details revealed", details)
+}
+
+// UnlockUser lifts the lockout of a user after repeated failed logins
+func (uc *UserController) UnlockUser(c *fiber.Ctx) error {
+	userID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid user ID")
+	}
+
+	authUser, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	if err := uc.service.UnlockUser(userID, authUser); err != nil {
+		return constants.HTTPErrors.NotFound(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "User unlocked successfully")
+}
+
 // DeleteUser soft deletes a user
 func (uc *UserController) DeleteUser(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -271,3 +320,66 @@ func (uc *UserController) DeleteUser(c *fiber.Ctx) error {
 
 	return constants.HTTPSuccess.OKWithoutData(c, "User deleted successfully")
 }
+
+// ListUserSessions lists the active sessions of an employee
+func (uc *UserController) ListUserSessions(c *fiber.Ctx) error {
+	userID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid user ID")
+	}
+
+	authUser, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	sessions, err := uc.service.ListUserSessions(authUser, userID)
+	if err != nil {
+		return constants.HTTPErrors.NotFound(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OK(c, "Sessions retrieved successfully", sessions)
+}
+
+// RevokeUserSession ends one session of an employee
+func (uc *UserController) RevokeUserSession(c *fiber.Ctx) error {
+	userID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid user ID")
+	}
+
+	sessionID, err := helpers.DecryptObjectID(c.Params("sessionId"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid session ID")
+	}
+
+	authUser, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	if err := uc.service.RevokeUserSession(authUser, userID, sessionID); err != nil {
+		return constants.HTTPErrors.NotFound(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "Session revoked successfully")
+}
+
+// RevokeUserSessions signs an employee out of every device
+func (uc *UserController) RevokeUserSessions(c *fiber.Ctx) error {
+	userID, err := helpers.DecryptObjectID(c.Params("id"))
+	if err != nil {
+		return constants.HTTPErrors.BadRequest(c, "Invalid user ID")
+	}
+
+	authUser, err := middlewares.GetAuthUser(c)
+	if err != nil {
+		return constants.HTTPErrors.Unauthorized(c, err.Error())
+	}
+
+	if err := uc.service.RevokeUserSessions(authUser, userID); err != nil {
+		return constants.HTTPErrors.NotFound(c, err.Error())
+	}
+
+	return constants.HTTPSuccess.OKWithoutData(c, "User signed out of all devices")
+}
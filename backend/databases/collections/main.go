@@ -6,20 +6,39 @@ const (
 	Companies   = "companies"
 	Users       = "users"
 	Departments = "departments"
+	Sessions    = "sessions"
+
+	// Security
+	LoginAttempts = "login_attempts"
+	RateLimits    = "rate_limits"
+	CustomRoles   = "custom_roles"
 
 	// Attendance & Leave
-	Attendances = "attendances"
-	Leaves      = "leaves"
+	Attendances               = "attendances"
+	AttendanceRegularizations = "attendance_regularizations"
+	AttendanceConfigurations  = "attendance_configurations"
+	AttendancePunches         = "attendance_punches"
+	PunchErrors               = "attendance_punch_errors"
+	Leaves                    = "leaves"
+	LeaveTypes                = "leave_types"
+	LeaveConfigurations       = "leave_configurations"
+	LeavePolicies             = "leave_policies"
+	LeaveLedger               = "leave_ledger"
+	LeaveDelegations          = "leave_delegations"
+	LeaveRollovers            = "leave_rollovers"
+	CompOffCredits            = "comp_off_credits"
 
 	// Payroll & Salary
 	SalaryStructures      = "salary_structures"
 	PayrollConfigurations = "payroll_configurations"
 	Payruns               = "payruns"
 	Payrolls              = "payrolls"
+	PayrollAdjustments    = "payroll_adjustments"
 
 	// Documents
 	Documents = "documents"
 
 	// Audit & Logs
 	ActivityLogs = "activity_logs"
+	JobRuns      = "job_runs"
 )
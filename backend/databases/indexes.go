@@ -0,0 +1,93 @@
+package databases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// indexes lists the indexes the services rely on for correctness, by collection
+var indexes = map[string][]mongo.IndexModel{
+	// One counter per client, route and window, dropped once the window has passed
+	collections.RateLimits: {
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetName("key").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0),
+		},
+	},
+	// One accrual per employee, leave type and period, however many accrual runs overlap
+	collections.LeaveLedger: {
+		{
+			Keys: bson.D{
+				{Key: "employee_id", Value: 1},
+				{Key: "leave_type", Value: 1},
+				{Key: "entry_type", Value: 1},
+				{Key: "period", Value: 1},
+			},
+			Options: options.Index().
+				SetName("accrual_period").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"entry_type": models.LedgerAccrual}),
+		},
+	},
+	// One comp-off credit per employee and worked day; a rejected one may be claimed again. Partial
+	// indexes on MongoDB 4.4 take neither $ne nor $in, so the filter relies on approved, expired and
+	// pending all sorting before rejected.
+	collections.CompOffCredits: {
+		{
+			Keys: bson.D{{Key: "employee_id", Value: 1}, {Key: "work_date", Value: 1}},
+			Options: options.Index().
+				SetName("employee_work_date_claimed").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": bson.M{"$lt": models.CompOffRejected}}),
+		},
+	},
+	// Re-importing a device log stores each punch once
+	collections.AttendancePunches: {
+		{
+			Keys:    bson.D{{Key: "company", Value: 1}, {Key: "employee_id", Value: 1}, {Key: "timestamp", Value: 1}},
+			Options: options.Index().SetName("company_employee_timestamp").SetUnique(true),
+		},
+	},
+	// One attendance record per employee and day, whichever of check-in, punches or leave creates it
+	collections.Attendances: {
+		{
+			Keys:    bson.D{{Key: "employee_id", Value: 1}, {Key: "date", Value: 1}},
+			Options: options.Index().SetName("employee_date").SetUnique(true),
+		},
+	},
+	// One live rollover per company and year. Running and completed rollovers have no
+	// reversed_at, so a second one collides; reversed ones keep their reversal time and a
+	// year can be rolled over again after a reversal.
+	collections.LeaveRollovers: {
+		{
+			Keys:    bson.D{{Key: "company", Value: 1}, {Key: "year", Value: 1}, {Key: "reversed_at", Value: 1}},
+			Options: options.Index().SetName("company_year_live").SetUnique(true),
+		},
+	},
+}
+
+// EnsureIndexes creates the indexes above; creating an existing index is a no-op
+func EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for collection, collectionIndexes := range indexes {
+		if _, err := MongoDBDatabase.Collection(collection).Indexes().CreateMany(ctx, collectionIndexes); err != nil {
+			return fmt.Errorf("failed to create indexes on %s: %w", collection, err)
+		}
+	}
+
+	return nil
+}
@@ -7,6 +7,11 @@ func InitDB() (bool, error) {
 		return false, err
 	}
 
+	// Create the indexes the services depend on
+	if err := EnsureIndexes(); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
 
@@ -6,11 +6,16 @@ import "go.mongodb.org/mongo-driver/bson/primitive"
 type AttendanceStatus string
 
 const (
-	StatusPresent AttendanceStatus = "present"
-	StatusOnLeave AttendanceStatus = "on_leave"
-	StatusAbsent  AttendanceStatus = "absent"
+	StatusPresent      AttendanceStatus = "present"
+	StatusWorkFromHome AttendanceStatus = "work_from_home"
+	StatusOnLeave      AttendanceStatus = "on_leave"
+	StatusHalfDay      AttendanceStatus = "half_day" // half-day leave, present for the other half
+	StatusAbsent       AttendanceStatus = "absent"
 )
 
+// AttendanceSourceDevice marks records built from biometric or external device punches
+const AttendanceSourceDevice = "device"
+
 // Attendance represents daily employee attendance log
 type Attendance struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
@@ -19,9 +24,29 @@ type Attendance struct {
 	Date       string             `bson:"date" json:"date"`                               // YYYY-MM-DD
 	CheckIn    string             `bson:"check_in,omitempty" json:"check_in,omitempty"`   // HH:MM:SS
 	CheckOut   string             `bson:"check_out,omitempty" json:"check_out,omitempty"` // HH:MM:SS
-	Status     AttendanceStatus   `bson:"status" json:"status"`                           // present | on_leave | absent
+	Status     AttendanceStatus   `bson:"status" json:"status"`                           // present | work_from_home | on_leave | half_day | absent
 	WorkHours  float64            `bson:"work_hours,omitempty" json:"work_hours,omitempty"`
 	Remarks    string             `bson:"remarks,omitempty" json:"remarks,omitempty"`
+	LeaveID    primitive.ObjectID `bson:"leave_id,omitempty" json:"leave_id,omitempty"`       // approved leave that created or changed the record
+	LeaveHalf  LeaveDuration      `bson:"leave_half,omitempty" json:"leave_half,omitempty"`   // first_half | second_half on half_day records
+	AutoClosed bool               `bson:"auto_closed,omitempty" json:"auto_closed,omitempty"` // closed by the daily job
+	Source     string             `bson:"source,omitempty" json:"source,omitempty"`           // web (default) | device
+
+	// Check-in origin, validated against the company's office locations and IP ranges
+	Location    *GeoPoint `bson:"location,omitempty" json:"location,omitempty"`
+	IPAddress   string    `bson:"ip_address,omitempty" json:"ip_address,omitempty"`
+	Flagged     bool      `bson:"flagged,omitempty" json:"flagged,omitempty"`
+	FlagReasons []string  `bson:"flag_reasons,omitempty" json:"flag_reasons,omitempty"`
+
+	// Regularization audit: values before the first approved correction
+	RegularizationID primitive.ObjectID  `bson:"regularization_id,omitempty" json:"regularization_id,omitempty"`
+	Original         *AttendanceSnapshot `bson:"original,omitempty" json:"original,omitempty"`
 
 	TimeStamp
 }
+
+// GeoPoint is a latitude/longitude pair reported by the client
+type GeoPoint struct {
+	Latitude  float64 `bson:"latitude" json:"latitude"`
+	Longitude float64 `bson:"longitude" json:"longitude"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type AutoClosePolicy string
+
+const (
+	AutoCloseShiftEnd     AutoClosePolicy = "shift_end"     // check-out set to the shift end time
+	AutoCloseDefaultHours AutoClosePolicy = "default_hours" // check-out set to check-in + default work hours
+	AutoCloseAbsent       AutoClosePolicy = "absent"        // missing check-out turns the day into an absence
+)
+
+type CheckInPolicy string
+
+const (
+	CheckInPolicyOff    CheckInPolicy = "off"    // location and IP are recorded but not checked
+	CheckInPolicyReject CheckInPolicy = "reject" // check-ins outside the rules are refused
+	CheckInPolicyFlag   CheckInPolicy = "flag"   // check-ins outside the rules are accepted and flagged
+)
+
+// OfficeLocation is a geofence employees must be inside to check in from the office
+type OfficeLocation struct {
+	Name         string  `bson:"name" json:"name"`
+	Latitude     float64 `bson:"latitude" json:"latitude"`
+	Longitude    float64 `bson:"longitude" json:"longitude"`
+	RadiusMeters float64 `bson:"radius_meters" json:"radius_meters"`
+}
+
+// AttendanceConfiguration holds company-wide attendance rules used by check-in and the daily job
+type AttendanceConfiguration struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Company          primitive.ObjectID `bson:"company" json:"company"`
+	WorkingDays      []int              `bson:"working_days" json:"working_days"`             // 0 = Sunday ... 6 = Saturday
+	Holidays         []string           `bson:"holidays" json:"holidays"`                     // YYYY-MM-DD
+	ShiftStart       string             `bson:"shift_start" json:"shift_start"`               // HH:MM:SS
+	ShiftEnd         string             `bson:"shift_end" json:"shift_end"`                   // HH:MM:SS
+	DefaultWorkHours float64            `bson:"default_work_hours" json:"default_work_hours"` // standard day, hours above count as overtime
+	LateGraceMinutes int                `bson:"late_grace_minutes" json:"late_grace_minutes"` // check-in after shift start + grace is late
+	AutoClosePolicy  AutoClosePolicy    `bson:"auto_close_policy" json:"auto_close_policy"`   // shift_end | default_hours | absent
+
+	// Check-in restrictions
+	OfficeLocations []OfficeLocation `bson:"office_locations" json:"office_locations"`
+	AllowedIPRanges []string         `bson:"allowed_ip_ranges" json:"allowed_ip_ranges"` // CIDR or single IP
+	CheckInPolicy   CheckInPolicy    `bson:"check_in_policy" json:"check_in_policy"`     // off | reject | flag
+
+	TimeStamp
+}
+
+// DefaultAttendanceConfiguration returns the rules applied when a company has not saved its own
+func DefaultAttendanceConfiguration(companyID primitive.ObjectID) AttendanceConfiguration {
+	return AttendanceConfiguration{
+		Company:          companyID,
+		WorkingDays:      []int{1, 2, 3, 4, 5},
+		Holidays:         []string{},
+		ShiftStart:       "09:00:00",
+		ShiftEnd:         "18:00:00",
+		DefaultWorkHours: 8,
+		LateGraceMinutes: 10,
+		AutoClosePolicy:  AutoCloseShiftEnd,
+		OfficeLocations:  []OfficeLocation{},
+		AllowedIPRanges:  []string{},
+		CheckInPolicy:    CheckInPolicyOff,
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type PunchSource string
+type PunchErrorStatus string
+
+const (
+	PunchSourceAPI    PunchSource = "api"
+	PunchSourceCSV    PunchSource = "csv"
+	PunchSourceZKTeco PunchSource = "zkteco"
+
+	PunchErrorOpen      PunchErrorStatus = "open"
+	PunchErrorResolved  PunchErrorStatus = "resolved"
+	PunchErrorDismissed PunchErrorStatus = "dismissed"
+)
+
+// AttendancePunch is a single raw punch received from a biometric or external device
+type AttendancePunch struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Company      primitive.ObjectID `bson:"company" json:"company"`
+	EmployeeID   primitive.ObjectID `bson:"employee_id" json:"employee_id"`
+	DeviceUserID string             `bson:"device_user_id" json:"device_user_id"` // matches User.EmployeeCode
+	DeviceID     string             `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	Timestamp    string             `bson:"timestamp" json:"timestamp"` // YYYY-MM-DD HH:MM:SS
+	Date         string             `bson:"date" json:"date"`           // YYYY-MM-DD
+	Time         string             `bson:"time" json:"time"`           // HH:MM:SS
+	Source       PunchSource        `bson:"source" json:"source"`       // api | csv | zkteco
+
+	TimeStamp
+}
+
+// PunchError is a punch that could not be imported and is waiting for review
+type PunchError struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Company      primitive.ObjectID `bson:"company" json:"company"`
+	DeviceUserID string             `bson:"device_user_id,omitempty" json:"device_user_id,omitempty"`
+	DeviceID     string             `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	Timestamp    string             `bson:"timestamp,omitempty" json:"timestamp,omitempty"` // as received
+	RawLine      string             `bson:"raw_line,omitempty" json:"raw_line,omitempty"`
+	Source       PunchSource        `bson:"source" json:"source"`
+	Reason       string             `bson:"reason" json:"reason"`
+	Status       PunchErrorStatus   `bson:"status" json:"status"` // open | resolved | dismissed
+	PunchID      primitive.ObjectID `bson:"punch_id,omitempty" json:"punch_id,omitempty"`
+	ReviewedBy   primitive.ObjectID `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewedAt   string             `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"` // YYYY-MM-DD HH:MM:SS
+
+	TimeStamp
+}
@@ -0,0 +1,40 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type RegularizationStatus string
+
+const (
+	RegularizationPending  RegularizationStatus = "pending"
+	RegularizationApproved RegularizationStatus = "approved"
+	RegularizationRejected RegularizationStatus = "rejected"
+)
+
+// AttendanceSnapshot keeps the values of an attendance record before it was corrected
+type AttendanceSnapshot struct {
+	Exists    bool             `bson:"exists" json:"exists"` // false if there was no record for the date
+	CheckIn   string           `bson:"check_in,omitempty" json:"check_in,omitempty"`
+	CheckOut  string           `bson:"check_out,omitempty" json:"check_out,omitempty"`
+	Status    AttendanceStatus `bson:"status,omitempty" json:"status,omitempty"`
+	WorkHours float64          `bson:"work_hours,omitempty" json:"work_hours,omitempty"`
+}
+
+// AttendanceRegularization represents an employee request to correct a past attendance record
+type AttendanceRegularization struct {
+	ID                primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	EmployeeID        primitive.ObjectID   `bson:"employee_id" json:"employee_id"`
+	Company           primitive.ObjectID   `bson:"company" json:"company"`
+	AttendanceID      primitive.ObjectID   `bson:"attendance_id,omitempty" json:"attendance_id,omitempty"`
+	Date              string               `bson:"date" json:"date"`                                                   // YYYY-MM-DD
+	RequestedCheckIn  string               `bson:"requested_check_in,omitempty" json:"requested_check_in,omitempty"`   // HH:MM:SS
+	RequestedCheckOut string               `bson:"requested_check_out,omitempty" json:"requested_check_out,omitempty"` // HH:MM:SS
+	RequestedStatus   AttendanceStatus     `bson:"requested_status" json:"requested_status"`                           // present | absent
+	Reason            string               `bson:"reason" json:"reason"`
+	Status            RegularizationStatus `bson:"status" json:"status"` // pending | approved | rejected
+	Original          *AttendanceSnapshot  `bson:"original,omitempty" json:"original,omitempty"`
+	ReviewedBy        primitive.ObjectID   `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewedAt        string               `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"` // YYYY-MM-DD HH:MM:SS
+	ReviewRemarks     string               `bson:"review_remarks,omitempty" json:"review_remarks,omitempty"`
+
+	TimeStamp
+}
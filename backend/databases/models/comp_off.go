@@ -0,0 +1,35 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type CompOffStatus string
+
+const (
+	CompOffPending  CompOffStatus = "pending"
+	CompOffApproved CompOffStatus = "approved" // credited to the comp-off balance
+	CompOffRejected CompOffStatus = "rejected" // must sort after every other status, see the comp_off_credits index
+	CompOffExpired  CompOffStatus = "expired"  // validity ended, any unused days lapsed
+)
+
+// CompOffLeaveType is the leave type comp-off credits are granted into
+const CompOffLeaveType LeaveType = "comp_off"
+
+// CompOffCredit is compensatory leave earned by working on a weekly-off or holiday
+type CompOffCredit struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	EmployeeID    primitive.ObjectID `bson:"employee_id" json:"employee_id"`
+	Company       primitive.ObjectID `bson:"company" json:"company"`
+	AttendanceID  primitive.ObjectID `bson:"attendance_id" json:"attendance_id"`
+	WorkDate      string             `bson:"work_date" json:"work_date"` // YYYY-MM-DD, the non-working day worked
+	WorkHours     float64            `bson:"work_hours" json:"work_hours"`
+	Days          float64            `bson:"days" json:"days"`                                 // 1 for a full day, 0.5 for half of one
+	ExpiresOn     string             `bson:"expires_on,omitempty" json:"expires_on,omitempty"` // YYYY-MM-DD, last day the credit can be used
+	Reason        string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Status        CompOffStatus      `bson:"status" json:"status"` // pending | approved | rejected | expired
+	ReviewedBy    primitive.ObjectID `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewedAt    string             `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"` // YYYY-MM-DD HH:MM:SS
+	ReviewRemarks string             `bson:"review_remarks,omitempty" json:"review_remarks,omitempty"`
+	LapsedDays    float64            `bson:"lapsed_days,omitempty" json:"lapsed_days,omitempty"` // unused days removed at expiry
+
+	TimeStamp
+}
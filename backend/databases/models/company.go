@@ -16,6 +16,55 @@ type Company struct {
 	ApprovedBy primitive.ObjectID `bson:"approved_by,omitempty" json:"approved_by,omitempty"`
 	IsApproved bool               `bson:"is_approved" json:"is_approved"`
 	IsActive   bool               `bson:"is_active" json:"is_active"`
+	TwoFactor  TwoFactorPolicy    `bson:"two_factor,omitempty" json:"two_factor"`                     // who must sign in with 2FA
+	Passwords  *PasswordPolicy    `bson:"password_policy,omitempty" json:"password_policy,omitempty"` // unset: DefaultPasswordPolicy
 
 	TimeStamp
 }
+
+// TwoFactorPolicy decides which users of a company must use two-factor authentication
+type TwoFactorPolicy struct {
+	Required bool   `bson:"required" json:"required"`               // everyone in the company
+	Roles    []Role `bson:"roles,omitempty" json:"roles,omitempty"` // only these roles, when not required for everyone
+}
+
+// RequiresTwoFactor reports whether the company makes users of the role use two-factor authentication
+func (c *Company) RequiresTwoFactor(role Role) bool {
+	if c.TwoFactor.Required {
+		return true
+	}
+	for _, required := range c.TwoFactor.Roles {
+		if required == role {
+			return true
+		}
+	}
+	return false
+}
+
+// PasswordPolicy sets the rules for the passwords of a company's users
+type PasswordPolicy struct {
+	MinLength            int  `bson:"min_length" json:"min_length"`
+	RequireUppercase     bool `bson:"require_uppercase" json:"require_uppercase"`
+	RequireLowercase     bool `bson:"require_lowercase" json:"require_lowercase"`
+	RequireDigit         bool `bson:"require_digit" json:"require_digit"`
+	RequireSymbol        bool `bson:"require_symbol" json:"require_symbol"`
+	DisallowPersonalInfo bool `bson:"disallow_personal_info" json:"disallow_personal_info"` // no username, name or email parts
+	DisallowCommon       bool `bson:"disallow_common" json:"disallow_common"`               // not on the bundled common-password list
+	HistoryCount         int  `bson:"history_count" json:"history_count"`                   // the last N passwords cannot be reused; 0 allows reuse
+	MaxAgeDays           int  `bson:"max_age_days" json:"max_age_days"`                     // passwords must be changed after this; 0 never expires
+}
+
+// DefaultPasswordPolicy applies to companies that have not set their own, and to SuperAdmin
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:            8,
+	DisallowPersonalInfo: true,
+	DisallowCommon:       true,
+}
+
+// PasswordPolicy returns the rules for passwords of the company's users; nil (SuperAdmin) gets the default
+func (c *Company) PasswordPolicy() PasswordPolicy {
+	if c == nil || c.Passwords == nil {
+		return DefaultPasswordPolicy
+	}
+	return *c.Passwords
+}
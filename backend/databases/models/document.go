@@ -10,6 +10,7 @@ const (
 	DocumentCategoryPayslip DocumentCategory = "payslip"
 	DocumentCategoryPolicy  DocumentCategory = "policy"
 	DocumentCategoryReport  DocumentCategory = "report"
+	DocumentCategoryMedical DocumentCategory = "medical" // medical certificates attached to leaves
 	DocumentCategoryOther   DocumentCategory = "other"
 )
 
@@ -20,12 +21,12 @@ type Document struct {
 	FilePath    string             `bson:"file_path" json:"file_path"`                         // Local file path
 	FileURL     string             `bson:"file_url" json:"file_url"`                           // Public access URL
 	FileType    string             `bson:"file_type" json:"file_type"`                         // e.g. pdf, jpg, png, docx
-	Category    DocumentCategory   `bson:"category" json:"category"`                           // resume | id_proof | payslip | policy | report | other
+	Category    DocumentCategory   `bson:"category" json:"category"`                           // resume | id_proof | payslip | policy | report | medical | other
 	UploadedBy  primitive.ObjectID `bson:"uploaded_by,omitempty" json:"uploaded_by,omitempty"` // User who uploaded the file
 	Company     primitive.ObjectID `bson:"company,omitempty" json:"company,omitempty"`         // Company context
 	EmployeeID  primitive.ObjectID `bson:"employee_id,omitempty" json:"employee_id,omitempty"` // Optional (if document belongs to an employee)
 	Description string             `bson:"description,omitempty" json:"description,omitempty"`
-	IsPrivate   bool               `bson:"is_private" json:"is_private"`         // If true, restricted access (e.g., payslip)
+	IsPrivate   bool               `bson:"is_private" json:"is_private"`         // If true, restricted access (e.g., payslip, medical)
 	Size        int64              `bson:"size,omitempty" json:"size,omitempty"` // File size in bytes
 
 	TimeStamp
@@ -0,0 +1,35 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type JobRunStatus string
+type JobTrigger string
+
+const (
+	JobRunRunning   JobRunStatus = "running"
+	JobRunCompleted JobRunStatus = "completed"
+	JobRunFailed    JobRunStatus = "failed"
+
+	JobTriggerScheduled JobTrigger = "scheduled"
+	JobTriggerManual    JobTrigger = "manual"
+
+	JobAttendanceDaily = "attendance_daily"
+)
+
+// JobRun records one execution of a background job for a company
+type JobRun struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Job         string             `bson:"job" json:"job"`
+	Company     primitive.ObjectID `bson:"company" json:"company"`
+	Date        string             `bson:"date" json:"date"`       // YYYY-MM-DD the run applies to
+	Trigger     JobTrigger         `bson:"trigger" json:"trigger"` // scheduled | manual
+	TriggeredBy primitive.ObjectID `bson:"triggered_by,omitempty" json:"triggered_by,omitempty"`
+	Status      JobRunStatus       `bson:"status" json:"status"` // running | completed | failed
+	Stats       map[string]int     `bson:"stats,omitempty" json:"stats,omitempty"`
+	Message     string             `bson:"message,omitempty" json:"message,omitempty"`
+	Error       string             `bson:"error,omitempty" json:"error,omitempty"`
+	StartedAt   string             `bson:"started_at" json:"started_at"`                       // YYYY-MM-DD HH:MM:SS
+	FinishedAt  string             `bson:"finished_at,omitempty" json:"finished_at,omitempty"` // YYYY-MM-DD HH:MM:SS
+
+	TimeStamp
+}
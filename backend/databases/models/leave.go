@@ -4,31 +4,86 @@ import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type LeaveStatus string
 type LeaveType string
+type LeaveDuration string
+type LeaveChangeType string
+type LeaveDocumentStatus string
 
 const (
-	LeavePending  LeaveStatus = "pending"
-	LeaveApproved LeaveStatus = "approved"
-	LeaveRejected LeaveStatus = "rejected"
+	LeavePending   LeaveStatus = "pending"
+	LeaveApproved  LeaveStatus = "approved"
+	LeaveRejected  LeaveStatus = "rejected"
+	LeaveCancelled LeaveStatus = "cancelled"
 
-	LeaveSick     LeaveType = "sick"
-	LeaveCasual   LeaveType = "casual"
-	LeaveVacation LeaveType = "vacation"
+	LeaveFullDay    LeaveDuration = "full_day"
+	LeaveFirstHalf  LeaveDuration = "first_half"
+	LeaveSecondHalf LeaveDuration = "second_half"
+	LeaveHourly     LeaveDuration = "hourly" // short leave counted against the monthly allowance
+
+	LeaveChangeCancel  LeaveChangeType = "cancel"  // the whole leave is withdrawn
+	LeaveChangeShorten LeaveChangeType = "shorten" // partial cancellation, the employee returns early
+	LeaveChangeModify  LeaveChangeType = "modify"  // new start and end dates
+
+	LeaveDocumentPending   LeaveDocumentStatus = "pending"   // required, due after the employee returns
+	LeaveDocumentSubmitted LeaveDocumentStatus = "submitted" // at least one document is attached
+	LeaveDocumentOverdue   LeaveDocumentStatus = "overdue"   // the due date passed without a document
 )
 
+// IsHalfDay reports whether the duration covers half of a working day
+func (d LeaveDuration) IsHalfDay() bool {
+	return d == LeaveFirstHalf || d == LeaveSecondHalf
+}
+
 // Leave represents a leave application request
 type Leave struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	EmployeeID primitive.ObjectID `bson:"employee_id" json:"employee_id"`
-	Company    primitive.ObjectID `bson:"company" json:"company"`
-	LeaveType  LeaveType          `bson:"leave_type" json:"leave_type"` // sick | casual | vacation
-	Reason     string             `bson:"reason" json:"reason"`
-	StartDate  string             `bson:"start_date" json:"start_date"` // YYYY-MM-DD
-	EndDate    string             `bson:"end_date" json:"end_date"`     // YYYY-MM-DD
-	Days       int                `bson:"days" json:"days"`
-	Status     LeaveStatus        `bson:"status" json:"status"` // pending | approved | rejected
-	ApprovedBy primitive.ObjectID `bson:"approved_by,omitempty" json:"approved_by,omitempty"`
-	RejectedBy primitive.ObjectID `bson:"rejected_by,omitempty" json:"rejected_by,omitempty"`
-	ReviewedAt string             `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"` // YYYY-MM-DD HH:MM:SS
+	ID         primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	EmployeeID primitive.ObjectID   `bson:"employee_id" json:"employee_id"`
+	Company    primitive.ObjectID   `bson:"company" json:"company"`
+	LeaveType  LeaveType            `bson:"leave_type" json:"leave_type"` // code of a company leave type
+	Reason     string               `bson:"reason" json:"reason"`
+	StartDate  string               `bson:"start_date" json:"start_date"`                 // YYYY-MM-DD
+	EndDate    string               `bson:"end_date" json:"end_date"`                     // YYYY-MM-DD
+	Duration   LeaveDuration        `bson:"duration,omitempty" json:"duration,omitempty"` // full_day (default) | first_half | second_half | hourly
+	Hours      float64              `bson:"hours,omitempty" json:"hours,omitempty"`       // hourly leave only
+	Days       float64              `bson:"days" json:"days"`
+	Unpaid     bool                 `bson:"unpaid,omitempty" json:"unpaid,omitempty"` // deducted as loss of pay
+	Documents  []primitive.ObjectID `bson:"documents,omitempty" json:"documents,omitempty"`
+	Status     LeaveStatus          `bson:"status" json:"status"` // pending | approved | rejected | cancelled
+	ApprovedBy primitive.ObjectID   `bson:"approved_by,omitempty" json:"approved_by,omitempty"`
+	RejectedBy primitive.ObjectID   `bson:"rejected_by,omitempty" json:"rejected_by,omitempty"`
+	ReviewedAt string               `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"` // YYYY-MM-DD HH:MM:SS
+
+	// Set when the leave type requires a supporting document for this leave; empty otherwise
+	DocumentStatus  LeaveDocumentStatus `bson:"document_status,omitempty" json:"document_status,omitempty"`     // pending | submitted | overdue
+	DocumentDueDate string              `bson:"document_due_date,omitempty" json:"document_due_date,omitempty"` // YYYY-MM-DD
+
+	// Approval chain resolved when the leave was applied; the current approver is copied out for querying
+	ApprovalSteps     []LeaveApprovalStep   `bson:"approval_steps,omitempty" json:"approval_steps,omitempty"`
+	CurrentStep       int                   `bson:"current_step" json:"current_step"`
+	CurrentApprover   ApproverRole          `bson:"current_approver,omitempty" json:"current_approver,omitempty"`
+	CurrentApproverID primitive.ObjectID    `bson:"current_approver_id,omitempty" json:"current_approver_id,omitempty"`
+	StepStartedAt     primitive.DateTime    `bson:"step_started_at,omitempty" json:"step_started_at,omitempty"` // for escalation
+	ApprovalHistory   []LeaveApprovalAction `bson:"approval_history,omitempty" json:"approval_history,omitempty"`
+
+	// Changes to an approved leave wait for review; the history keeps every applied or rejected change
+	PendingChange *LeaveChange  `bson:"pending_change,omitempty" json:"pending_change,omitempty"`
+	ChangeHistory []LeaveChange `bson:"change_history,omitempty" json:"change_history,omitempty"`
 
 	TimeStamp
 }
+
+// LeaveChange is a cancellation, partial cancellation or date modification of a leave
+type LeaveChange struct {
+	Type          LeaveChangeType    `bson:"type" json:"type"`                                 // cancel | shorten | modify
+	StartDate     string             `bson:"start_date,omitempty" json:"start_date,omitempty"` // new dates, empty for cancel
+	EndDate       string             `bson:"end_date,omitempty" json:"end_date,omitempty"`
+	Days          float64            `bson:"days" json:"days"` // new length, 0 for cancel
+	Reason        string             `bson:"reason" json:"reason"`
+	Status        LeaveStatus        `bson:"status" json:"status"` // pending | approved | rejected
+	PreviousStart string             `bson:"previous_start" json:"previous_start"`
+	PreviousEnd   string             `bson:"previous_end" json:"previous_end"`
+	RequestedBy   primitive.ObjectID `bson:"requested_by" json:"requested_by"`
+	RequestedAt   string             `bson:"requested_at" json:"requested_at"` // YYYY-MM-DD HH:MM:SS
+	ReviewedBy    primitive.ObjectID `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewedAt    string             `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+	ReviewRemarks string             `bson:"review_remarks,omitempty" json:"review_remarks,omitempty"`
+}
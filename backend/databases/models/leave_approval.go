@@ -0,0 +1,74 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type ApproverRole string
+type ApprovalStatus string
+
+const (
+	ApproverManager        ApproverRole = "reporting_manager" // User.ManagerID
+	ApproverDepartmentHead ApproverRole = "department_head"   // Department.HeadID
+	ApproverHR             ApproverRole = "hr"                // any HR officer or admin
+
+	ApprovalWaiting   ApprovalStatus = "waiting" // a previous step is still open
+	ApprovalPending   ApprovalStatus = "pending"
+	ApprovalApproved  ApprovalStatus = "approved"
+	ApprovalRejected  ApprovalStatus = "rejected"
+	ApprovalEscalated ApprovalStatus = "escalated" // left pending too long and passed on
+)
+
+// IsValidApproverRole reports whether role is one of the supported approvers
+func IsValidApproverRole(role ApproverRole) bool {
+	switch role {
+	case ApproverManager, ApproverDepartmentHead, ApproverHR:
+		return true
+	}
+	return false
+}
+
+// ApprovalStepRule is one level of a leave type's approval chain
+type ApprovalStepRule struct {
+	Approver ApproverRole `bson:"approver" json:"approver"`   // reporting_manager | department_head | hr
+	OverDays float64      `bson:"over_days" json:"over_days"` // the step only applies to leave longer than this, 0 = always
+}
+
+// DefaultApprovalChain is used by leave types without their own chain: the reporting manager,
+// then HR for leave over 5 days
+func DefaultApprovalChain() []ApprovalStepRule {
+	return []ApprovalStepRule{
+		{Approver: ApproverManager},
+		{Approver: ApproverHR, OverDays: 5},
+	}
+}
+
+// LeaveApprovalStep is a level of the approval chain resolved for one leave
+type LeaveApprovalStep struct {
+	Approver   ApproverRole       `bson:"approver" json:"approver"`
+	ApproverID primitive.ObjectID `bson:"approver_id,omitempty" json:"approver_id,omitempty"` // empty for the HR step
+	Status     ApprovalStatus     `bson:"status" json:"status"`
+}
+
+// LeaveApprovalAction records a decision or escalation on a leave's approval chain
+type LeaveApprovalAction struct {
+	Step       int                `bson:"step" json:"step"`
+	Approver   ApproverRole       `bson:"approver" json:"approver"`
+	Action     ApprovalStatus     `bson:"action" json:"action"`                                 // approved | rejected | escalated
+	By         primitive.ObjectID `bson:"by,omitempty" json:"by,omitempty"`                     // empty for automatic escalation
+	OnBehalfOf primitive.ObjectID `bson:"on_behalf_of,omitempty" json:"on_behalf_of,omitempty"` // set when acting as a delegate
+	At         string             `bson:"at" json:"at"`                                         // YYYY-MM-DD HH:MM:SS
+	Remarks    string             `bson:"remarks,omitempty" json:"remarks,omitempty"`
+}
+
+// LeaveDelegation lets another user approve leave on the delegator's behalf while they are away
+type LeaveDelegation struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Company     primitive.ObjectID `bson:"company" json:"company"`
+	DelegatorID primitive.ObjectID `bson:"delegator_id" json:"delegator_id"`
+	DelegateID  primitive.ObjectID `bson:"delegate_id" json:"delegate_id"`
+	StartDate   string             `bson:"start_date" json:"start_date"` // YYYY-MM-DD
+	EndDate     string             `bson:"end_date" json:"end_date"`     // YYYY-MM-DD
+	Reason      string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	IsActive    bool               `bson:"is_active" json:"is_active"` // false once revoked
+
+	TimeStamp
+}
@@ -0,0 +1,27 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// LeaveConfiguration holds company-wide leave rules that are not tied to one leave type
+type LeaveConfiguration struct {
+	ID                     primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Company                primitive.ObjectID `bson:"company" json:"company"`
+	ShortLeaveMonthlyHours float64            `bson:"short_leave_monthly_hours" json:"short_leave_monthly_hours"` // hourly leave allowed per month, 0 disables it
+	ShortLeaveMaxHours     float64            `bson:"short_leave_max_hours" json:"short_leave_max_hours"`         // longest single hourly leave
+	MinStaffingPercent     float64            `bson:"min_staffing_percent" json:"min_staffing_percent"`           // share of a team that must stay available, 0 disables the check
+	EscalationHours        float64            `bson:"escalation_hours" json:"escalation_hours"`                   // pending approval steps older than this move on, 0 disables escalation
+	CompOffValidityDays    int                `bson:"comp_off_validity_days" json:"comp_off_validity_days"`       // days after the worked day a comp-off credit can be used, 0 = no expiry
+
+	TimeStamp
+}
+
+// DefaultLeaveConfiguration returns the rules applied when a company has not saved its own
+func DefaultLeaveConfiguration(companyID primitive.ObjectID) LeaveConfiguration {
+	return LeaveConfiguration{
+		Company:                companyID,
+		ShortLeaveMonthlyHours: 4,
+		ShortLeaveMaxHours:     2,
+		EscalationHours:        48,
+		CompOffValidityDays:    90,
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type LedgerEntryType string
+
+const (
+	LedgerAccrual     LedgerEntryType = "accrual"
+	LedgerConsumption LedgerEntryType = "consumption"
+	LedgerAdjustment  LedgerEntryType = "adjustment"
+	LedgerExpiry      LedgerEntryType = "expiry"
+	LedgerReversal    LedgerEntryType = "reversal"
+	LedgerEncashment  LedgerEntryType = "encashment"
+	LedgerCompOff     LedgerEntryType = "comp_off" // credit for working a non-working day
+)
+
+// LeaveLedgerEntry is a signed movement of an employee's leave balance.
+// The balance of a leave type is the sum of its entries.
+type LeaveLedgerEntry struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Company       primitive.ObjectID `bson:"company" json:"company"`
+	EmployeeID    primitive.ObjectID `bson:"employee_id" json:"employee_id"`
+	LeaveType     LeaveType          `bson:"leave_type" json:"leave_type"`
+	EntryType     LedgerEntryType    `bson:"entry_type" json:"entry_type"`             // accrual | consumption | adjustment | expiry | reversal | encashment | comp_off
+	Days          float64            `bson:"days" json:"days"`                         // positive credits, negative debits
+	Period        string             `bson:"period,omitempty" json:"period,omitempty"` // YYYY-MM or YYYY for accruals
+	LeaveID       primitive.ObjectID `bson:"leave_id,omitempty" json:"leave_id,omitempty"`
+	RolloverID    primitive.ObjectID `bson:"rollover_id,omitempty" json:"rollover_id,omitempty"` // year-end lapse and encashment
+	CompOffID     primitive.ObjectID `bson:"comp_off_id,omitempty" json:"comp_off_id,omitempty"` // comp-off credit and its expiry
+	EffectiveDate string             `bson:"effective_date" json:"effective_date"`               // YYYY-MM-DD
+	Remarks       string             `bson:"remarks,omitempty" json:"remarks,omitempty"`
+
+	TimeStamp
+}
@@ -0,0 +1,42 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type AccrualFrequency string
+
+const (
+	AccrualMonthly AccrualFrequency = "monthly" // quota / 12 credited every month
+	AccrualYearly  AccrualFrequency = "yearly"  // full quota credited at the start of the year
+)
+
+// LeavePolicy defines how a leave type is earned and how far its balance may go
+type LeavePolicy struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Company          primitive.ObjectID `bson:"company" json:"company"`
+	LeaveType        LeaveType          `bson:"leave_type" json:"leave_type"`
+	AnnualQuota      float64            `bson:"annual_quota" json:"annual_quota"`           // days per year
+	AccrualFrequency AccrualFrequency   `bson:"accrual_frequency" json:"accrual_frequency"` // monthly | yearly
+	ProRata          bool               `bson:"pro_rata" json:"pro_rata"`                   // pro-rate the first period for mid-period joiners
+	MaxBalance       float64            `bson:"max_balance" json:"max_balance"`             // 0 = no cap
+	AllowNegative    bool               `bson:"allow_negative" json:"allow_negative"`
+	NegativeLimit    float64            `bson:"negative_limit" json:"negative_limit"` // days the balance may go below zero
+
+	// Year-end rollover: unused days carry forward up to the cap, the rest is encashed up to its cap and lapses
+	CarryForward    bool    `bson:"carry_forward" json:"carry_forward"`
+	CarryForwardMax float64 `bson:"carry_forward_max" json:"carry_forward_max"` // 0 = no cap
+	Encashable      bool    `bson:"encashable" json:"encashable"`
+	EncashmentMax   float64 `bson:"encashment_max" json:"encashment_max"` // 0 = no cap
+
+	TimeStamp
+}
+
+// DefaultLeavePolicies returns the policies applied to the built-in leave types a company has not configured
+func DefaultLeavePolicies(companyID primitive.ObjectID) []LeavePolicy {
+	return []LeavePolicy{
+		{Company: companyID, LeaveType: "casual", AnnualQuota: 12, AccrualFrequency: AccrualMonthly, ProRata: true},
+		{Company: companyID, LeaveType: "sick", AnnualQuota: 12, AccrualFrequency: AccrualYearly, ProRata: true, CarryForward: true, CarryForwardMax: 30},
+		{Company: companyID, LeaveType: "vacation", AnnualQuota: 18, AccrualFrequency: AccrualMonthly, ProRata: true, MaxBalance: 45, CarryForward: true, CarryForwardMax: 15, Encashable: true, EncashmentMax: 10},
+		// Comp-off is only earned through approved credits, which expire on their own
+		{Company: companyID, LeaveType: CompOffLeaveType, AccrualFrequency: AccrualYearly, CarryForward: true},
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type LeaveRolloverStatus string
+
+const (
+	RolloverPreview   LeaveRolloverStatus = "preview" // dry run, never stored
+	RolloverRunning   LeaveRolloverStatus = "running" // claimed, ledger and encashment still being written
+	RolloverCompleted LeaveRolloverStatus = "completed"
+	RolloverReversed  LeaveRolloverStatus = "reversed"
+)
+
+// LeaveRollover records the year-end processing of a company's leave balances
+type LeaveRollover struct {
+	ID      primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	Company primitive.ObjectID  `bson:"company" json:"company"`
+	Year    int                 `bson:"year" json:"year"` // calendar leave year that was closed
+	Status  LeaveRolloverStatus `bson:"status" json:"status"`
+	Lines   []LeaveRolloverLine `bson:"lines" json:"lines"`
+
+	TotalCarriedForward   float64 `bson:"total_carried_forward" json:"total_carried_forward"`
+	TotalLapsed           float64 `bson:"total_lapsed" json:"total_lapsed"`
+	TotalEncashed         float64 `bson:"total_encashed" json:"total_encashed"`
+	TotalEncashmentAmount float64 `bson:"total_encashment_amount" json:"total_encashment_amount"`
+
+	ReversedBy primitive.ObjectID `bson:"reversed_by,omitempty" json:"reversed_by,omitempty"`
+	ReversedAt string             `bson:"reversed_at,omitempty" json:"reversed_at,omitempty"` // YYYY-MM-DD HH:MM:SS
+
+	TimeStamp
+}
+
+// LeaveRolloverLine is the year-end outcome for one employee and leave type
+type LeaveRolloverLine struct {
+	EmployeeID       primitive.ObjectID `bson:"employee_id" json:"employee_id"`
+	LeaveType        LeaveType          `bson:"leave_type" json:"leave_type"`
+	Accrued          float64            `bson:"accrued" json:"accrued"` // credited during the year
+	Used             float64            `bson:"used" json:"used"`       // approved leave starting in the year
+	Balance          float64            `bson:"balance" json:"balance"` // unused at year end
+	CarriedForward   float64            `bson:"carried_forward" json:"carried_forward"`
+	Lapsed           float64            `bson:"lapsed" json:"lapsed"`
+	Encashed         float64            `bson:"encashed" json:"encashed"`
+	EncashmentAmount float64            `bson:"encashment_amount" json:"encashment_amount"`
+}
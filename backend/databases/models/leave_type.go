@@ -0,0 +1,56 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// CompanyLeaveType is a leave type defined by a company, with the rules that govern applying for it
+type CompanyLeaveType struct {
+	ID                   primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Company              primitive.ObjectID `bson:"company" json:"company"`
+	Code                 LeaveType          `bson:"code" json:"code"` // stored on leaves, policies and ledger entries
+	Name                 string             `bson:"name" json:"name"`
+	Description          string             `bson:"description,omitempty" json:"description,omitempty"`
+	Paid                 bool               `bson:"paid" json:"paid"`                                     // unpaid days are deducted as loss of pay
+	RequiresDocument     bool               `bson:"requires_document" json:"requires_document"`           // a supporting document must be attached
+	DocumentAfterDays    float64            `bson:"document_after_days" json:"document_after_days"`       // leaves longer than this need a document, 0 = never
+	DocumentDeadlineDays int                `bson:"document_deadline_days" json:"document_deadline_days"` // days after the leave ends to submit it, 0 = when applying
+	MinNoticeDays        int                `bson:"min_notice_days" json:"min_notice_days"`               // days between applying and the start date
+	MaxConsecutiveDays   int                `bson:"max_consecutive_days" json:"max_consecutive_days"`     // 0 = no limit
+	EligibleGenders      []Gender           `bson:"eligible_genders,omitempty" json:"eligible_genders,omitempty"`
+	MinTenureMonths      int                `bson:"min_tenure_months" json:"min_tenure_months"`               // months since date of join
+	ApprovalChain        []ApprovalStepRule `bson:"approval_chain,omitempty" json:"approval_chain,omitempty"` // empty uses DefaultApprovalChain
+	IsActive             bool               `bson:"is_active" json:"is_active"`
+
+	TimeStamp
+}
+
+// DefaultLeaveTypes returns the leave types a company starts with
+func DefaultLeaveTypes(companyID primitive.ObjectID) []CompanyLeaveType {
+	return []CompanyLeaveType{
+		{Company: companyID, Code: "casual", Name: "Casual Leave", Paid: true, MinNoticeDays: 1, MaxConsecutiveDays: 3, IsActive: true},
+		{Company: companyID, Code: "sick", Name: "Sick Leave", Paid: true, DocumentAfterDays: 2, DocumentDeadlineDays: 3, IsActive: true},
+		{Company: companyID, Code: "vacation", Name: "Vacation", Paid: true, MinNoticeDays: 7, IsActive: true},
+		{Company: companyID, Code: "maternity", Name: "Maternity Leave", Paid: true, RequiresDocument: true, MinNoticeDays: 30, MaxConsecutiveDays: 182, EligibleGenders: []Gender{GenderFemale}, MinTenureMonths: 3, IsActive: true},
+		{Company: companyID, Code: "paternity", Name: "Paternity Leave", Paid: true, MaxConsecutiveDays: 15, EligibleGenders: []Gender{GenderMale}, MinTenureMonths: 3, IsActive: true},
+		{Company: companyID, Code: "bereavement", Name: "Bereavement Leave", Paid: true, MaxConsecutiveDays: 5, IsActive: true},
+		{Company: companyID, Code: "unpaid", Name: "Unpaid Leave", Paid: false, MinNoticeDays: 3, IsActive: true},
+		{Company: companyID, Code: "comp_off", Name: "Compensatory Off", Paid: true, IsActive: true},
+	}
+}
+
+// IsEligibleGender reports whether the gender may take this leave type
+func (t *CompanyLeaveType) IsEligibleGender(gender Gender) bool {
+	if len(t.EligibleGenders) == 0 {
+		return true
+	}
+	for _, eligible := range t.EligibleGenders {
+		if eligible == gender {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresDocumentFor reports whether a leave of the given length needs a supporting document
+func (t *CompanyLeaveType) RequiresDocumentFor(days float64) bool {
+	return t.RequiresDocument || (t.DocumentAfterDays > 0 && days > t.DocumentAfterDays)
+}
@@ -0,0 +1,12 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// LoginAttempt counts recent failed logins from one IP address, across all accounts
+type LoginAttempt struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	IPAddress    string             `bson:"ip_address" json:"ip_address"`
+	Failures     int                `bson:"failures" json:"failures"` // within the window since the last failure
+	LastFailedAt primitive.DateTime `bson:"last_failed_at" json:"last_failed_at"`
+	LockedUntil  primitive.DateTime `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+}
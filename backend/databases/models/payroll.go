@@ -26,6 +26,9 @@ type Payroll struct {
 	LeaveTravelAllowance float64 `bson:"leave_travel_allowance" json:"leave_travel_allowance"`
 	FixedAllowance       float64 `bson:"fixed_allowance" json:"fixed_allowance"`
 
+	// One-off earnings picked up from payroll adjustments, e.g. leave encashment
+	AdditionalEarnings []PayrollEarning `bson:"additional_earnings,omitempty" json:"additional_earnings,omitempty"`
+
 	// Totals
 	GrossSalary     float64 `bson:"gross_salary" json:"gross_salary"`
 	TotalDeductions float64 `bson:"total_deductions" json:"total_deductions"`
@@ -35,12 +38,14 @@ type Payroll struct {
 	PFEmployee      float64 `bson:"pf_employee" json:"pf_employee"`
 	PFEmployer      float64 `bson:"pf_employer" json:"pf_employer"`
 	ProfessionalTax float64 `bson:"professional_tax" json:"professional_tax"`
+	LOPDeduction    float64 `bson:"lop_deduction" json:"lop_deduction"` // loss of pay for unpaid leave
 
 	// Attendance Data
-	WorkingDays int `bson:"working_days" json:"working_days"`
-	PresentDays int `bson:"present_days" json:"present_days"`
-	LeaveDays   int `bson:"leave_days" json:"leave_days"`
-	AbsentDays  int `bson:"absent_days" json:"absent_days"`
+	WorkingDays     int     `bson:"working_days" json:"working_days"`
+	PresentDays     int     `bson:"present_days" json:"present_days"`
+	LeaveDays       float64 `bson:"leave_days" json:"leave_days"`               // paid leave, half days and hourly leave as fractions
+	UnpaidLeaveDays float64 `bson:"unpaid_leave_days" json:"unpaid_leave_days"` // deducted as loss of pay
+	AbsentDays      int     `bson:"absent_days" json:"absent_days"`
 
 	// Warnings
 	HasBankAccount bool `bson:"has_bank_account" json:"has_bank_account"`
@@ -54,3 +59,10 @@ type Payroll struct {
 
 	TimeStamp
 }
+
+// PayrollEarning is a one-off earning line of a payroll
+type PayrollEarning struct {
+	Code        string  `bson:"code" json:"code"`
+	Description string  `bson:"description" json:"description"`
+	Amount      float64 `bson:"amount" json:"amount"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type PayrollAdjustmentStatus string
+
+const (
+	AdjustmentPending   PayrollAdjustmentStatus = "pending"   // waiting for the next payrun
+	AdjustmentApplied   PayrollAdjustmentStatus = "applied"   // paid out in a payrun
+	AdjustmentCancelled PayrollAdjustmentStatus = "cancelled" // withdrawn before it was paid
+
+	AdjustmentLeaveEncashment = "leave_encashment"
+)
+
+// PayrollAdjustment is a one-off earning added to an employee's next payroll
+type PayrollAdjustment struct {
+	ID          primitive.ObjectID      `bson:"_id,omitempty" json:"id,omitempty"`
+	Company     primitive.ObjectID      `bson:"company" json:"company"`
+	EmployeeID  primitive.ObjectID      `bson:"employee_id" json:"employee_id"`
+	Code        string                  `bson:"code" json:"code"` // leave_encashment
+	Description string                  `bson:"description" json:"description"`
+	Amount      float64                 `bson:"amount" json:"amount"`
+	SourceID    primitive.ObjectID      `bson:"source_id,omitempty" json:"source_id,omitempty"` // e.g. the leave rollover
+	Status      PayrollAdjustmentStatus `bson:"status" json:"status"`                           // pending | applied | cancelled
+	PayrunID    primitive.ObjectID      `bson:"payrun_id,omitempty" json:"payrun_id,omitempty"`
+	PayrollID   primitive.ObjectID      `bson:"payroll_id,omitempty" json:"payroll_id,omitempty"`
+
+	TimeStamp
+}
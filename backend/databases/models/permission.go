@@ -0,0 +1,134 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Permission names one action a user may take in their company, as "<module>.<action>"
+type Permission string
+
+const (
+	// Users
+	PermUserViewAll Permission = "user.view_all" // list all employees
+	PermUserCreate  Permission = "user.create"
+	PermUserUpdate  Permission = "user.update"
+	PermUserManage  Permission = "user.manage" // activate, deactivate, unlock, delete, reset passwords, end sessions
+	PermBankReveal  Permission = "bank.reveal" // unmasked bank details
+
+	// Company
+	PermCompanySecurity Permission = "company.security" // two-factor and password policies
+	PermRoleManage      Permission = "role.manage"      // custom roles and assigning them
+
+	// Departments
+	PermDepartmentManage Permission = "department.manage" // create and update
+	PermDepartmentDelete Permission = "department.delete"
+
+	// Attendance
+	PermAttendanceMark      Permission = "attendance.mark"     // check in and out
+	PermAttendanceViewAll   Permission = "attendance.view_all" // everyone's attendance, regularizations and reports
+	PermAttendanceApprove   Permission = "attendance.approve"  // regularizations of any employee
+	PermAttendanceManage    Permission = "attendance.manage"   // punches, punch errors and job reruns
+	PermAttendanceConfigure Permission = "attendance.configure"
+
+	// Leave
+	PermLeaveViewAll        Permission = "leave.view_all"        // everyone's leaves, balances, comp-offs and calendar
+	PermLeaveApprove        Permission = "leave.approve"         // the HR approval step, leave changes and comp-offs
+	PermLeaveOverride       Permission = "leave.override"        // any step of any leave
+	PermLeaveManageBalances Permission = "leave.manage_balances" // adjustments, accrual runs and rollovers
+	PermLeaveConfigure      Permission = "leave.configure"       // leave configuration, types and policies
+
+	// Salary & Payroll
+	PermSalaryViewAll    Permission = "salary.view_all"
+	PermSalaryManage     Permission = "salary.manage"
+	PermPayrollViewAll   Permission = "payroll.view_all" // payruns and everyone's payslips
+	PermPayrollRun       Permission = "payroll.run"      // create payruns and mark payslips paid
+	PermPayrollConfigure Permission = "payroll.configure"
+
+	// Documents
+	PermDocumentViewAll Permission = "document.view_all" // everyone's documents, and uploading for others
+	PermDocumentDelete  Permission = "document.delete"
+
+	// Dashboard
+	PermDashboardAdmin Permission = "dashboard.admin"
+)
+
+// AllPermissions lists every permission a role can hold
+var AllPermissions = []Permission{
+	PermUserViewAll, PermUserCreate, PermUserUpdate, PermUserManage, PermBankReveal,
+	PermCompanySecurity, PermRoleManage,
+	PermDepartmentManage, PermDepartmentDelete,
+	PermAttendanceMark, PermAttendanceViewAll, PermAttendanceApprove, PermAttendanceManage, PermAttendanceConfigure,
+	PermLeaveViewAll, PermLeaveApprove, PermLeaveOverride, PermLeaveManageBalances, PermLeaveConfigure,
+	PermSalaryViewAll, PermSalaryManage, PermPayrollViewAll, PermPayrollRun, PermPayrollConfigure,
+	PermDocumentViewAll, PermDocumentDelete,
+	PermDashboardAdmin,
+}
+
+// DefaultRolePermissions are the bundles of the built-in roles, used for users without a custom role.
+// SuperAdmin holds every permission and is not listed.
+var DefaultRolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermUserViewAll, PermUserCreate, PermUserUpdate, PermUserManage, PermBankReveal,
+		PermCompanySecurity, PermRoleManage,
+		PermDepartmentManage, PermDepartmentDelete,
+		PermAttendanceViewAll, PermAttendanceApprove, PermAttendanceManage, PermAttendanceConfigure,
+		PermLeaveViewAll, PermLeaveApprove, PermLeaveOverride, PermLeaveManageBalances, PermLeaveConfigure,
+		PermSalaryViewAll, PermSalaryManage, PermPayrollViewAll, PermPayrollRun, PermPayrollConfigure,
+		PermDocumentViewAll, PermDocumentDelete,
+		PermDashboardAdmin,
+	},
+	RoleHR: {
+		PermUserViewAll, PermUserCreate, PermUserUpdate,
+		PermDepartmentManage,
+		PermAttendanceMark, PermAttendanceViewAll, PermAttendanceApprove, PermAttendanceManage,
+		PermLeaveViewAll, PermLeaveApprove, PermLeaveManageBalances,
+		PermSalaryViewAll, PermSalaryManage,
+		PermDocumentViewAll,
+	},
+	RolePayroll: {
+		PermBankReveal,
+		PermAttendanceMark,
+		PermSalaryViewAll, PermSalaryManage, PermPayrollViewAll, PermPayrollRun,
+	},
+	RoleEmployee: {
+		PermAttendanceMark,
+	},
+}
+
+// PermissionSet holds the permissions a user was resolved to
+type PermissionSet map[Permission]bool
+
+// NewPermissionSet builds a set from a list of permissions
+func NewPermissionSet(permissions []Permission) PermissionSet {
+	set := make(PermissionSet, len(permissions))
+	for _, permission := range permissions {
+		set[permission] = true
+	}
+	return set
+}
+
+// Has reports whether the set holds the permission
+func (s PermissionSet) Has(permission Permission) bool {
+	return s[permission]
+}
+
+// IsValidPermission reports whether permission is one of AllPermissions
+func IsValidPermission(permission Permission) bool {
+	for _, known := range AllPermissions {
+		if known == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomRole is a company-defined bundle of permissions. A user assigned one gets its permissions
+// instead of their built-in role's, while the built-in role still decides which colleagues they see on
+// the dashboard.
+type CustomRole struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Company     primitive.ObjectID `bson:"company" json:"company"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Permissions []Permission       `bson:"permissions" json:"permissions"`
+
+	TimeStamp
+}
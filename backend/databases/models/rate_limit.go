@@ -0,0 +1,12 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// RateLimit counts the requests of one client to one route within a fixed window. Stored in MongoDB so
+// every server process, including prefork children, shares the count.
+type RateLimit struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Key       string             `bson:"key" json:"key"` // method, route, client IP and window start
+	Count     int                `bson:"count" json:"count"`
+	ExpiresAt primitive.DateTime `bson:"expires_at" json:"expires_at"` // end of the window; removed by a TTL index
+}
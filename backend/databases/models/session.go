@@ -0,0 +1,35 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type SessionRevokeReason string
+
+const (
+	SessionLogout         SessionRevokeReason = "logout"          // the user logged out of this device
+	SessionLogoutAll      SessionRevokeReason = "logout_all"      // the user logged out of every device
+	SessionPasswordChange SessionRevokeReason = "password_change" // the password was changed or reset
+	SessionTokenReuse     SessionRevokeReason = "token_reuse"     // a rotated-out refresh token was presented again
+	SessionDeactivated    SessionRevokeReason = "deactivated"     // the user or company was deactivated or deleted
+	SessionRevoked        SessionRevokeReason = "revoked"         // the user signed this device out from another one
+	SessionAdminRevoked   SessionRevokeReason = "admin_revoked"   // a company admin ended the session
+)
+
+// Session is one login of a user. Access tokens carry its ID, so revoking the session revokes them;
+// the refresh token is rotated on every use.
+type Session struct {
+	ID               primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID           primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Company          primitive.ObjectID  `bson:"company,omitempty" json:"company,omitempty"`
+	Device           string              `bson:"device,omitempty" json:"device,omitempty"` // e.g. "Chrome on Windows", from the user agent
+	IPAddress        string              `bson:"ip_address,omitempty" json:"ip_address,omitempty"`
+	UserAgent        string              `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	LastSeenAt       primitive.DateTime  `bson:"last_seen_at,omitempty" json:"last_seen_at,omitempty"`
+	RefreshTokenHash string              `bson:"refresh_token_hash" json:"-"`
+	UsedTokenHashes  []string            `bson:"used_token_hashes,omitempty" json:"-"` // rotated-out refresh tokens, kept to detect reuse
+	ExpiresAt        primitive.DateTime  `bson:"expires_at" json:"expires_at"`         // end of the refresh token's lifetime
+	RefreshedAt      primitive.DateTime  `bson:"refreshed_at,omitempty" json:"refreshed_at,omitempty"`
+	RevokedAt        primitive.DateTime  `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	RevokedReason    SessionRevokeReason `bson:"revoked_reason,omitempty" json:"revoked_reason,omitempty"`
+
+	TimeStamp
+}
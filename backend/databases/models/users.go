@@ -6,6 +6,7 @@ import "go.mongodb.org/mongo-driver/bson/primitive"
 // Role defines different user roles across the WorkZen HRMS system
 type Role string
 type UserStatus string
+type Gender string
 
 const (
 	RoleSuperAdmin Role = "superadmin" // Platform-level access
@@ -16,8 +17,31 @@ const (
 
 	UserActive   UserStatus = "active"
 	UserInactive UserStatus = "inactive"
+
+	GenderMale   Gender = "male"
+	GenderFemale Gender = "female"
+	GenderOther  Gender = "other"
 )
 
+// IsValidGender reports whether gender is one of the supported values
+func IsValidGender(gender Gender) bool {
+	switch gender {
+	case GenderMale, GenderFemale, GenderOther:
+		return true
+	}
+	return false
+}
+
+// IsAssignableRole reports whether role is a built-in role a user can be given; SuperAdmin is only
+// set up on the platform itself
+func IsAssignableRole(role Role) bool {
+	switch role {
+	case RoleAdmin, RoleHR, RolePayroll, RoleEmployee:
+		return true
+	}
+	return false
+}
+
 // User represents a registered person in the HRMS system.
 // Each user belongs to a company (except SuperAdmin).
 type User struct {
@@ -27,13 +51,15 @@ type User struct {
 	Password               string             `bson:"password" json:"password"`
 	FirstName              string             `bson:"first_name" json:"first_name"`
 	LastName               string             `bson:"last_name" json:"last_name"`
-	Role                   Role               `bson:"role" json:"role"` // superadmin | admin | hr | payroll | employee
+	Role                   Role               `bson:"role" json:"role"`                                         // superadmin | admin | hr | payroll | employee
+	CustomRoleID           primitive.ObjectID `bson:"custom_role_id,omitempty" json:"custom_role_id,omitempty"` // replaces the built-in role's permissions
 	IsSuperAdmin           bool               `bson:"is_super_admin,omitempty" json:"is_super_admin,omitempty"`
 	Designation            string             `bson:"designation,omitempty" json:"designation,omitempty"`
 	DepartmentID           primitive.ObjectID `bson:"department_id,omitempty" json:"department_id,omitempty"`
 	ManagerID              primitive.ObjectID `bson:"manager_id,omitempty" json:"manager_id,omitempty"`
 	EmployeeCode           string             `bson:"employee_code,omitempty" json:"employee_code,omitempty"`
 	DateOfJoin             string             `bson:"date_of_join,omitempty" json:"date_of_join,omitempty"` // YYYY-MM-DD
+	Gender                 Gender             `bson:"gender,omitempty" json:"gender,omitempty"`             // male | female | other
 	Status                 UserStatus         `bson:"status" json:"status"`                                 // active | inactive
 	Phone                  string             `bson:"phone,omitempty" json:"phone,omitempty"`
 	Address                Address            `bson:"address,omitempty" json:"address,omitempty"`
@@ -42,10 +68,28 @@ type User struct {
 	Company                primitive.ObjectID `bson:"company,omitempty" json:"company,omitempty"`
 	BankDetails            *BankDetails       `bson:"bank_details,omitempty" json:"bank_details,omitempty"`
 	LastLogin              primitive.DateTime `bson:"last_login,omitempty" json:"last_login,omitempty"`
+	FailedLogins           int                `bson:"failed_logins,omitempty" json:"-"`                     // wrong passwords since the last success, within the window
+	LastFailedLogin        primitive.DateTime `bson:"last_failed_login,omitempty" json:"-"`                 // time of the latest wrong password
+	LockedUntil            primitive.DateTime `bson:"locked_until,omitempty" json:"locked_until,omitempty"` // temporary lockout after too many wrong passwords
 	EmailVerified          bool               `bson:"email_verified" json:"email_verified"`
 	EmailVerificationToken string             `bson:"email_verification_token,omitempty" json:"-"`
 	TokenExpiry            primitive.DateTime `bson:"token_expiry,omitempty" json:"-"`
+	PasswordResetToken     string             `bson:"password_reset_token,omitempty" json:"-"`  // hash of the emailed forgot-password token
+	PasswordResetExpiry    primitive.DateTime `bson:"password_reset_expiry,omitempty" json:"-"` // when the reset token stops being accepted
+	PasswordResetSentAt    primitive.DateTime `bson:"password_reset_sent_at,omitempty" json:"-"`
+	PasswordChangedAt      primitive.DateTime `bson:"password_changed_at,omitempty" json:"-"` // unset: since the account was created
+	PasswordHistory        []string           `bson:"password_history,omitempty" json:"-"`    // hashes of earlier passwords, newest first
+	CalendarTokenHash      string             `bson:"calendar_token_hash,omitempty" json:"-"` // hash of the secret in the user's leave calendar feed URL
 	TwoFactorEnabled       bool               `bson:"two_factor_enabled" json:"two_factor_enabled"`
+	TwoFactorSecret        string             `bson:"two_factor_secret,omitempty" json:"-"`         // encrypted TOTP secret, set when enrolling
+	TwoFactorLastStep      int64              `bson:"two_factor_last_step,omitempty" json:"-"`      // last accepted TOTP time step, so a code works once
+	TwoFactorRecoveryCodes []string           `bson:"two_factor_recovery_codes,omitempty" json:"-"` // hashes of the unused recovery codes
+	TwoFactorChallenge     string             `bson:"two_factor_challenge,omitempty" json:"-"`      // hash of the token of a login waiting for its second step
+	TwoFactorExpiry        primitive.DateTime `bson:"two_factor_expiry,omitempty" json:"-"`         // when the challenge stops being accepted
+	TwoFactorAttempts      int                `bson:"two_factor_attempts,omitempty" json:"-"`       // wrong codes entered against the challenge
+	PasswordChallenge      bool               `bson:"password_challenge,omitempty" json:"-"`        // the challenge asks for a new password instead of a code
+	WorkFromHomeAllowed    bool               `bson:"work_from_home_allowed" json:"work_from_home_allowed"`
+	Permissions            PermissionSet      `bson:"-" json:"-"` // resolved once per request by AuthMiddleware; nil until then
 	TimeStamp
 }
 
@@ -0,0 +1,149 @@
+package encryptions
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrInvalidCiphertext is returned for values that were tampered with, are truncated, or were
+// encrypted with a key that is not (or no longer) configured
+var ErrInvalidCiphertext = errors.New("invalid or tampered ciphertext")
+
+var aeadEncoding = base64.RawURLEncoding
+
+// Keyring encrypts with AES-GCM under versioned keys. Every value starts with the version of its key,
+// so after a rotation values sealed with older keys still open while those keys stay configured.
+type Keyring struct {
+	current byte
+	keys    map[byte]keyringKey
+}
+
+type keyringKey struct {
+	aead     cipher.AEAD
+	nonceKey []byte // derives the synthetic nonce of deterministic values
+}
+
+// NewKeyring builds a keyring from keys by version ("1", "2", ...) and the version new values use.
+// Keys must be 16, 24 or 32 bytes.
+func NewKeyring(keys map[string]string, current int) (*Keyring, error) {
+	keyring := &Keyring{keys: map[byte]keyringKey{}}
+
+	for name, secret := range keys {
+		version, err := strconv.Atoi(name)
+		if err != nil || version < 1 || version > 255 {
+			return nil, fmt.Errorf("invalid key version %q: must be 1-255", name)
+		}
+		switch len(secret) {
+		case 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("key version %d must be 16, 24 or 32 bytes", version)
+		}
+
+		// Separate subkeys for encryption and nonce derivation
+		encKey := deriveKey([]byte(secret), "encrypt")[:len(secret)]
+		block, err := aes.NewCipher(encKey)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		keyring.keys[byte(version)] = keyringKey{aead: aead, nonceKey: deriveKey([]byte(secret), "nonce")}
+	}
+
+	if _, ok := keyring.keys[byte(current)]; !ok || current < 1 || current > 255 {
+		return nil, fmt.Errorf("current key version %d is not configured", current)
+	}
+	keyring.current = byte(current)
+
+	return keyring, nil
+}
+
+// mustKeyring builds a keyring from the config section name, panicking on an invalid config so the
+// server does not start with it. Without versioned keys, fallback is used as version 1; an empty
+// fallback makes the keys required.
+func mustKeyring(name string, keys map[string]string, current int, fallback string) *Keyring {
+	if len(keys) == 0 {
+		if fallback == "" {
+			panic(fmt.Errorf("invalid %s config: no keys configured", name))
+		}
+		keys, current = map[string]string{"1": fallback}, 1
+	}
+
+	keyring, err := NewKeyring(keys, current)
+	if err != nil {
+		panic(fmt.Errorf("invalid %s config: %w", name, err))
+	}
+	return keyring
+}
+
+// Seal encrypts text under the current key with a random nonce
+func (k *Keyring) Seal(text string) (string, error) {
+	key := k.keys[k.current]
+
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return k.seal(key, nonce, text), nil
+}
+
+// SealDeterministic encrypts text under the current key so equal texts give equal values, as needed
+// for IDs that clients compare. The nonce is derived from the text (synthetic IV), so it only repeats
+// for the same text.
+func (k *Keyring) SealDeterministic(text string) (string, error) {
+	key := k.keys[k.current]
+
+	mac := hmac.New(sha256.New, key.nonceKey)
+	mac.Write([]byte{k.current})
+	mac.Write([]byte(text))
+	nonce := mac.Sum(nil)[:key.aead.NonceSize()]
+
+	return k.seal(key, nonce, text), nil
+}
+
+// Open decrypts a value from Seal or SealDeterministic, failing with ErrInvalidCiphertext when it was
+// changed or its key version is unknown
+func (k *Keyring) Open(value string) (string, error) {
+	data, err := aeadEncoding.DecodeString(value)
+	if err != nil || len(data) < 1 {
+		return "", ErrInvalidCiphertext
+	}
+
+	key, ok := k.keys[data[0]]
+	if !ok || len(data) < 1+key.aead.NonceSize()+key.aead.Overhead() {
+		return "", ErrInvalidCiphertext
+	}
+
+	nonce := data[1 : 1+key.aead.NonceSize()]
+	plaintext, err := key.aead.Open(nil, nonce, data[1+key.aead.NonceSize():], data[:1])
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+
+	return string(plaintext), nil
+}
+
+// seal lays a value out as version | nonce | ciphertext and tag; the version is authenticated too
+func (k *Keyring) seal(key keyringKey, nonce []byte, text string) string {
+	header := []byte{k.current}
+	data := append(append(header, nonce...), key.aead.Seal(nil, nonce, []byte(text), header)...)
+	return aeadEncoding.EncodeToString(data)
+}
+
+// deriveKey derives a purpose-specific subkey from a configured key
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("workzen-" + purpose))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,194 @@
+package encryptions
+
+import (
+	"errors"
+	"testing"
+
+	"api.workzen.odoo/constants"
+)
+
+const (
+	testKeyV1 = "0123456789abcdef0123456789abcdef"
+	testKeyV2 = "fedcba9876543210fedcba9876543210"
+)
+
+func mustTestKeyring(t *testing.T, keys map[string]string, current int) *Keyring {
+	t.Helper()
+	keyring, err := NewKeyring(keys, current)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return keyring
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    map[string]string
+		current int
+		wantErr bool
+	}{
+		{"16 byte key", map[string]string{"1": testKeyV1[:16]}, 1, false},
+		{"24 byte key", map[string]string{"1": testKeyV1[:24]}, 1, false},
+		{"32 byte key", map[string]string{"1": testKeyV1}, 1, false},
+		{"two versions", map[string]string{"1": testKeyV1, "2": testKeyV2}, 2, false},
+		{"short key", map[string]string{"1": "too short"}, 1, true},
+		{"non-numeric version", map[string]string{"one": testKeyV1}, 1, true},
+		{"version zero", map[string]string{"0": testKeyV1}, 0, true},
+		{"version above 255", map[string]string{"256": testKeyV1}, 256, true},
+		{"current not configured", map[string]string{"1": testKeyV1}, 2, true},
+		{"no keys", map[string]string{}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyring(tt.keys, tt.current)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewKeyring() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyringSealOpen(t *testing.T) {
+	keyring := mustTestKeyring(t, map[string]string{"1": testKeyV1}, 1)
+
+	for _, text := range []string{"", "a", "507f1f77bcf86cd799439011", "ünïcödé text"} {
+		sealed, err := keyring.Seal(text)
+		if err != nil {
+			t.Fatalf("Seal(%q): %v", text, err)
+		}
+		opened, err := keyring.Open(sealed)
+		if err != nil || opened != text {
+			t.Errorf("Open(Seal(%q)) = %q, %v", text, opened, err)
+		}
+	}
+
+	first, _ := keyring.Seal("same")
+	second, _ := keyring.Seal("same")
+	if first == second {
+		t.Error("Seal gave equal values for equal texts; the nonce is not random")
+	}
+}
+
+func TestKeyringSealDeterministic(t *testing.T) {
+	keyring := mustTestKeyring(t, map[string]string{"1": testKeyV1}, 1)
+
+	first, _ := keyring.SealDeterministic("507f1f77bcf86cd799439011")
+	second, _ := keyring.SealDeterministic("507f1f77bcf86cd799439011")
+	other, _ := keyring.SealDeterministic("507f1f77bcf86cd799439012")
+	if first != second {
+		t.Error("SealDeterministic gave different values for equal texts")
+	}
+	if first == other {
+		t.Error("SealDeterministic gave equal values for different texts")
+	}
+
+	opened, err := keyring.Open(first)
+	if err != nil || opened != "507f1f77bcf86cd799439011" {
+		t.Errorf("Open() = %q, %v", opened, err)
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	before := mustTestKeyring(t, map[string]string{"1": testKeyV1}, 1)
+	during := mustTestKeyring(t, map[string]string{"1": testKeyV1, "2": testKeyV2}, 2)
+	after := mustTestKeyring(t, map[string]string{"2": testKeyV2}, 2)
+
+	old, _ := before.Seal("payload")
+	oldID, _ := before.SealDeterministic("payload")
+	fresh, _ := during.Seal("payload")
+	freshID, _ := during.SealDeterministic("payload")
+
+	if oldID == freshID {
+		t.Error("deterministic values did not change with the current key")
+	}
+
+	tests := []struct {
+		name    string
+		keyring *Keyring
+		value   string
+		wantErr bool
+	}{
+		{"old value during rotation", during, old, false},
+		{"old ID during rotation", during, oldID, false},
+		{"new value during rotation", during, fresh, false},
+		{"new value after rotation", after, fresh, false},
+		{"new ID after rotation", after, freshID, false},
+		{"old value after its key is removed", after, old, true},
+		{"new value before rotation", before, fresh, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opened, err := tt.keyring.Open(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCiphertext) {
+					t.Errorf("Open() error = %v, want ErrInvalidCiphertext", err)
+				}
+				return
+			}
+			if err != nil || opened != "payload" {
+				t.Errorf("Open() = %q, %v", opened, err)
+			}
+		})
+	}
+}
+
+func TestKeyringOpenTampered(t *testing.T) {
+	keyring := mustTestKeyring(t, map[string]string{"1": testKeyV1, "2": testKeyV2}, 1)
+
+	sealed, _ := keyring.Seal("payload")
+	data, _ := aeadEncoding.DecodeString(sealed)
+
+	flip := func(i int) string {
+		changed := append([]byte(nil), data...)
+		changed[i] ^= 0x01
+		return aeadEncoding.EncodeToString(changed)
+	}
+	relabelled := append([]byte{2}, data[1:]...)
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"empty", ""},
+		{"not base64", "not base64!"},
+		{"version only", aeadEncoding.EncodeToString(data[:1])},
+		{"truncated", aeadEncoding.EncodeToString(data[:len(data)-1])},
+		{"unknown version", aeadEncoding.EncodeToString(append([]byte{9}, data[1:]...))},
+		{"version swapped to another key", aeadEncoding.EncodeToString(relabelled)},
+		{"nonce changed", flip(1)},
+		{"ciphertext changed", flip(len(data) - 17)},
+		{"tag changed", flip(len(data) - 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := keyring.Open(tt.value); !errors.Is(err, ErrInvalidCiphertext) {
+				t.Errorf("Open() error = %v, want ErrInvalidCiphertext", err)
+			}
+		})
+	}
+}
+
+func TestDecryptIDRejectsLegacy(t *testing.T) {
+	id := "507f1f77bcf86cd799439011"
+
+	encrypted, err := EncryptID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := DecryptID(encrypted); err != nil || got != id {
+		t.Errorf("DecryptID(EncryptID()) = %q, %v", got, err)
+	}
+
+	// config.example.yml ships with accept_legacy off
+	legacy, err := EncryptAES(id, constants.EncryptionAESIDKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptID(legacy); err == nil {
+		t.Error("DecryptID accepted a legacy AES-CFB ID with accept_legacy off")
+	}
+}
@@ -0,0 +1,43 @@
+package encryptions
+
+import (
+	"strings"
+
+	"api.workzen.odoo/constants"
+)
+
+// fieldPrefix marks encrypted field values, so values stored before encryption can still be read
+const fieldPrefix = "enc:"
+
+// fieldKeyring encrypts sensitive fields at rest with its own key; there is no fallback to another
+// key, so the server does not start without encryption.aes.fields
+var fieldKeyring = mustKeyring("encryption.aes.fields", constants.EncryptionAESFieldKeys, constants.EncryptionAESFieldCurrentKey, "")
+
+// EncryptField encrypts a sensitive value for storage; only empty values are returned unchanged.
+// Values that look encrypted are encrypted again, since they may come from a client: callers
+// keep a value read from the database by checking IsEncryptedField themselves.
+func EncryptField(text string) (string, error) {
+	if text == "" {
+		return text, nil
+	}
+
+	sealed, err := fieldKeyring.Seal(text)
+	if err != nil {
+		return "", err
+	}
+	return fieldPrefix + sealed, nil
+}
+
+// DecryptField decrypts a value from EncryptField; values stored before encryption are returned as
+// they are
+func DecryptField(value string) (string, error) {
+	if !IsEncryptedField(value) {
+		return value, nil
+	}
+	return fieldKeyring.Open(strings.TrimPrefix(value, fieldPrefix))
+}
+
+// IsEncryptedField reports whether a stored value is encrypted
+func IsEncryptedField(value string) bool {
+	return strings.HasPrefix(value, fieldPrefix)
+}
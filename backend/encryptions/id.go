@@ -1,14 +1,58 @@
 package encryptions
 
-import "api.workzen.odoo/constants"
+import (
+	"fmt"
+	"regexp"
+	"sync/atomic"
+	"time"
 
+	"api.workzen.odoo/constants"
+)
+
+// idKeyring encrypts the IDs handed to clients; configs without versioned keys use the legacy ID key
+// as version 1
+var idKeyring = mustKeyring("encryption.aes.ids", constants.EncryptionAESIDKeys, constants.EncryptionAESIDCurrentKey, constants.EncryptionAESIDKey)
+
+// EncryptID encrypts an ID with AES-GCM. The same ID always gives the same value, so clients can
+// compare encrypted IDs.
 func EncryptID(text string) (string, error) {
-	return EncryptAES(text, constants.EncryptionAESIDKey)
+	return idKeyring.SealDeterministic(text)
 }
 
-// DecryptID decrypts an encrypted ID string using AES encryption
+// legacyIDPattern is the only plaintext a legacy ID may decrypt to: a hex ObjectID
+var legacyIDPattern = regexp.MustCompile(`^[0-9a-f]{24}$`)
+
+// legacyIDHits counts legacy IDs accepted since start; legacyIDLoggedAt throttles the log line about them
+var legacyIDHits, legacyIDLoggedAt atomic.Int64
+
+// DecryptID decrypts an encrypted ID, rejecting values that were tampered with or encrypted under an
+// unknown key. IDs from before AES-GCM, which carry no integrity check, are accepted only while
+// encryption.aes.ids.accept_legacy is on (off by default) and are logged so operators know when it is
+// safe to turn off.
 func DecryptID(text string) (string, error) {
-	return DecryptAES(text, constants.EncryptionAESIDKey)
+	id, err := idKeyring.Open(text)
+	if err == nil {
+		return id, nil
+	}
+
+	if constants.EncryptionAESIDAcceptLegacy {
+		if id, legacyErr := DecryptAES(text, constants.EncryptionAESIDKey); legacyErr == nil && legacyIDPattern.MatchString(id) {
+			logLegacyID()
+			return id, nil
+		}
+	}
+
+	return "", err
+}
+
+// logLegacyID records an accepted legacy ID, logging at most once a minute
+func logLegacyID() {
+	hits := legacyIDHits.Add(1)
+	now := time.Now().Unix()
+	last := legacyIDLoggedAt.Load()
+	if now-last >= 60 && legacyIDLoggedAt.CompareAndSwap(last, now) {
+		fmt.Printf("Accepted a legacy AES-CFB ID (%d since start); turn off encryption.aes.ids.accept_legacy once these stop\n", hits)
+	}
 }
 
 func Encrypt(text string) (string, error) {
@@ -1,9 +1,131 @@
 // Package encryptions provides cryptographic functions for encryption, decryption, and hashing operations.
 package encryptions
 
-import "api.workzen.odoo/constants"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
 
+	"api.workzen.odoo/constants"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+
+	// Defaults when the config leaves the cost unset (OWASP recommendation for argon2id)
+	argon2DefaultMemory      = 64 * 1024 // KiB
+	argon2DefaultIterations  = 3
+	argon2DefaultParallelism = 2
+)
+
+var argon2Encoding = base64.RawStdEncoding
+
+// argon2Params is the cost of an argon2id hash
+type argon2Params struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// currentArgon2Params returns the configured cost for new hashes
+func currentArgon2Params() argon2Params {
+	params := argon2Params{
+		Memory:      argon2DefaultMemory,
+		Iterations:  argon2DefaultIterations,
+		Parallelism: argon2DefaultParallelism,
+	}
+	if constants.EncryptionPasswordArgon2Memory > 0 {
+		params.Memory = uint32(constants.EncryptionPasswordArgon2Memory)
+	}
+	if constants.EncryptionPasswordArgon2Iterations > 0 {
+		params.Iterations = uint32(constants.EncryptionPasswordArgon2Iterations)
+	}
+	if constants.EncryptionPasswordArgon2Parallelism > 0 {
+		params.Parallelism = uint8(constants.EncryptionPasswordArgon2Parallelism)
+	}
+	return params
+}
+
+// HashPassword hashes a password with argon2id and a random per-password salt. The result carries
+// the algorithm and cost: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
 func HashPassword(text string) string {
+	params := currentArgon2Params()
+
+	salt := make([]byte, argon2SaltLength)
+	rand.Read(salt)
+
+	key := argon2.IDKey([]byte(text), salt, params.Iterations, params.Memory, params.Parallelism, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		argon2Encoding.EncodeToString(salt), argon2Encoding.EncodeToString(key))
+}
+
+// ComparePassword reports whether a password matches a hash, in constant time. Hashes created
+// before argon2id are still accepted.
+func ComparePassword(text, hash string) bool {
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(hashLegacyPassword(text))) == 1
+	}
+
+	params, salt, key, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return false
+	}
+
+	candidate := argon2.IDKey([]byte(text), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(candidate, key) == 1
+}
+
+// PasswordNeedsRehash reports whether a hash uses an older algorithm or cost than new hashes do, so
+// it should be replaced the next time the password is known
+func PasswordNeedsRehash(hash string) bool {
+	params, _, _, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return true
+	}
+	return params != currentArgon2Params()
+}
+
+// decodeArgon2Hash splits an encoded argon2id hash into its cost, salt and key
+func decodeArgon2Hash(hash string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, fmt.Errorf("not an argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2 parameters: %w", err)
+	}
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, fmt.Errorf("invalid argon2 parameters")
+	}
+
+	salt, err := argon2Encoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2 salt: %w", err)
+	}
+	key, err := argon2Encoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, fmt.Errorf("invalid argon2 hash")
+	}
+
+	return params, salt, key, nil
+}
+
+// hashLegacyPassword is the salted SHA chain passwords were hashed with before argon2id. It is only
+// used to verify those hashes until their users sign in and they are replaced.
+func hashLegacyPassword(text string) string {
 	// Add initial salt mixing
 	text, _ = Hash256WithSalt(text, constants.EncryptionPasswordSecret)
 
@@ -46,7 +168,3 @@ func min(a, b int) int {
 	}
 	return b
 }
-
-func ComparePassword(text, hash string) bool {
-	return hash == HashPassword(text)
-}
@@ -0,0 +1,95 @@
+package encryptions
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2Hash encodes a hash of password with the given cost, as HashPassword does
+func argon2Hash(password string, params argon2Params) string {
+	salt := []byte("0123456789abcdef")
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		argon2Encoding.EncodeToString(salt), argon2Encoding.EncodeToString(key))
+}
+
+func TestHashPassword(t *testing.T) {
+	hash := HashPassword("correct horse")
+
+	params := currentArgon2Params()
+	prefix := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$", argon2.Version, params.Memory, params.Iterations, params.Parallelism)
+	if !strings.HasPrefix(hash, prefix) {
+		t.Fatalf("hash %q does not start with %q", hash, prefix)
+	}
+	if strings.Contains(hash, "correct horse") {
+		t.Fatal("hash contains the password")
+	}
+	if HashPassword("correct horse") == hash {
+		t.Error("two hashes of the same password are equal; the salt is not random")
+	}
+}
+
+func TestComparePassword(t *testing.T) {
+	hash := HashPassword("correct horse")
+	cheap := argon2Hash("correct horse", argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1})
+	legacy := hashLegacyPassword("correct horse")
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+	}{
+		{"argon2id match", "correct horse", hash, true},
+		{"argon2id mismatch", "correct horse!", hash, false},
+		{"argon2id empty password", "", hash, false},
+		{"argon2id with another cost", "correct horse", cheap, true},
+		{"legacy match", "correct horse", legacy, true},
+		{"legacy mismatch", "wrong horse", legacy, false},
+		{"truncated hash", "correct horse", hash[:len(hash)-10], false},
+		{"missing parts", "correct horse", "$argon2id$v=19$m=1024,t=1,p=1$", false},
+		{"zero cost", "correct horse", strings.Replace(cheap, "t=1", "t=0", 1), false},
+		{"unknown version", "correct horse", strings.Replace(cheap, "v=19", "v=16", 1), false},
+		{"empty hash", "correct horse", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComparePassword(tt.password, tt.hash); got != tt.want {
+				t.Errorf("ComparePassword() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	current := currentArgon2Params()
+	weaker := current
+	weaker.Memory /= 2
+	stronger := current
+	stronger.Iterations++
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{"current cost", HashPassword("secret"), false},
+		{"lower cost", argon2Hash("secret", weaker), true},
+		{"higher cost", argon2Hash("secret", stronger), true},
+		{"legacy hash", hashLegacyPassword("secret"), true},
+		{"malformed", "$argon2id$garbage", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PasswordNeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("PasswordNeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package helpers
 
 import (
 	"fmt"
+	"html"
 	"net/smtp"
+	"time"
 
 	"api.workzen.odoo/constants"
 )
@@ -295,3 +297,164 @@ func SendEmployeeInvitationEmail(to, firstName, companyName, username, tempPassw
 
 	return SendEmail(to, subject, body)
 }
+
+// SendNewDeviceLoginEmail warns a user that their account was signed in to from a device it had not
+// been used on before
+func SendNewDeviceLoginEmail(to, firstName, device, ipAddress string, at time.Time) error {
+	frontendURL := constants.FrontendURL
+	loginLink := fmt.Sprintf("%s/login", frontendURL)
+
+	subject := "New Sign-in to Your WorkZen Account"
+	body := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #FF9800; color: white; padding: 20px; text-align: center; }
+        .content { background-color: #f9f9f9; padding: 30px; border-radius: 5px; margin-top: 20px; }
+        .info-box { 
+            background-color: #fff3e0; 
+            padding: 15px; 
+            border-radius: 5px; 
+            margin: 20px 0; 
+        }
+        .footer { text-align: center; margin-top: 30px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>New Sign-in Detected</h1>
+        </div>
+        <div class="content">
+            <p>Hello %s,</p>
+            <p>Your WorkZen HRMS account was just signed in to from a new device.</p>
+            <div class="info-box">
+                <strong>Device:</strong> %s<br>
+                <strong>IP address:</strong> %s<br>
+                <strong>Time:</strong> %s
+            </div>
+            <p>If this was you, you can ignore this email.</p>
+            <p>If it wasn't, <a href="%s">sign in</a>, end the session from your list of active sessions
+            and change your password right away.</p>
+        </div>
+        <div class="footer">
+            <p>© 2025 WorkZen HRMS. All rights reserved.</p>
+            <p>This is a security notification sent for every sign-in from a new device.</p>
+        </div>
+    </div>
+</body>
+</html>
+`, html.EscapeString(firstName), html.EscapeString(device), html.EscapeString(ipAddress), at.UTC().Format("02 Jan 2006 15:04 MST"), loginLink)
+
+	return SendEmail(to, subject, body)
+}
+
+// SendPasswordResetEmail sends a forgot-password link to the user
+func SendPasswordResetEmail(to, firstName, username, token string, validFor time.Duration) error {
+	frontendURL := constants.FrontendURL
+	resetLink := fmt.Sprintf("%s/reset-password?token=%s", frontendURL, token)
+
+	subject := "Reset Your Password - WorkZen HRMS"
+	body := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #4CAF50; color: white; padding: 20px; text-align: center; }
+        .content { background-color: #f9f9f9; padding: 30px; border-radius: 5px; margin-top: 20px; }
+        .button { 
+            display: inline-block; 
+            padding: 12px 30px; 
+            background-color: #4CAF50; 
+            color: white; 
+            text-decoration: none; 
+            border-radius: 5px; 
+            margin: 20px 0;
+        }
+        .footer { text-align: center; margin-top: 30px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Password Reset</h1>
+        </div>
+        <div class="content">
+            <p>Hello %s,</p>
+            <p>We received a request to reset the password of your WorkZen HRMS account <strong>%s</strong>.</p>
+            <div style="text-align: center;">
+                <a href="%s" class="button">Reset Password</a>
+            </div>
+            <p>Or copy and paste this link into your browser:</p>
+            <p style="word-break: break-all; color: #666;">%s</p>
+            <p><strong>Note:</strong> This link can be used once and expires in %d minutes. Resetting your
+            password signs you out of every device.</p>
+            <p>If you didn't ask to reset your password, you can ignore this email; your password stays the same.</p>
+        </div>
+        <div class="footer">
+            <p>© 2025 WorkZen HRMS. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+`, html.EscapeString(firstName), html.EscapeString(username), resetLink, resetLink, int(validFor.Minutes()))
+
+	return SendEmail(to, subject, body)
+}
+
+// SendAccountLockedEmail tells a user that their account was locked after repeated failed logins
+func SendAccountLockedEmail(to, firstName, ipAddress string, until time.Time) error {
+	frontendURL := constants.FrontendURL
+	resetLink := fmt.Sprintf("%s/forgot-password", frontendURL)
+
+	subject := "Your WorkZen Account Was Temporarily Locked"
+	body := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #f44336; color: white; padding: 20px; text-align: center; }
+        .content { background-color: #f9f9f9; padding: 30px; border-radius: 5px; margin-top: 20px; }
+        .info-box { 
+            background-color: #ffebee; 
+            padding: 15px; 
+            border-radius: 5px; 
+            margin: 20px 0; 
+        }
+        .footer { text-align: center; margin-top: 30px; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Account Temporarily Locked</h1>
+        </div>
+        <div class="content">
+            <p>Hello %s,</p>
+            <p>There were too many failed sign-in attempts on your WorkZen HRMS account, so it has been locked for a while.</p>
+            <div class="info-box">
+                <strong>Last attempt from IP address:</strong> %s<br>
+                <strong>Locked until:</strong> %s
+            </div>
+            <p>If this was you, wait until then and try again, or ask your administrator to unlock your account.</p>
+            <p>If it wasn't, someone may be trying to guess your password. You can <a href="%s">reset your password</a>
+            once the lock ends.</p>
+        </div>
+        <div class="footer">
+            <p>© 2025 WorkZen HRMS. All rights reserved.</p>
+            <p>This is a security notification sent when an account is locked.</p>
+        </div>
+    </div>
+</body>
+</html>
+`, html.EscapeString(firstName), html.EscapeString(ipAddress), until.UTC().Format("02 Jan 2006 15:04 MST"), resetLink)
+
+	return SendEmail(to, subject, body)
+}
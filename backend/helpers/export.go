@@ -0,0 +1,139 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+// ExportCSV renders a header row and data rows as CSV
+func ExportCSV(headers []string, rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(headers); err != nil {
+		return nil, err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ExportXLSX renders a header row and data rows as a single-sheet Excel workbook
+func ExportXLSX(sheet string, headers []string, rows [][]string) ([]byte, error) {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	if err := file.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, err
+	}
+
+	headerStyle, err := file.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#E0E7FF"}, Pattern: 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	writeRow := func(rowIndex int, values []string) error {
+		cell, err := excelize.CoordinatesToCellName(1, rowIndex)
+		if err != nil {
+			return err
+		}
+		row := make([]interface{}, len(values))
+		for i, value := range values {
+			row[i] = value
+		}
+		return file.SetSheetRow(sheet, cell, &row)
+	}
+
+	if err := writeRow(1, headers); err != nil {
+		return nil, err
+	}
+	lastHeader, _ := excelize.CoordinatesToCellName(len(headers), 1)
+	if err := file.SetCellStyle(sheet, "A1", lastHeader, headerStyle); err != nil {
+		return nil, err
+	}
+	if err := file.SetPanes(sheet, &excelize.Panes{Freeze: true, Split: false, XSplit: 0, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return nil, err
+	}
+
+	for i, values := range rows {
+		if err := writeRow(i+2, values); err != nil {
+			return nil, err
+		}
+	}
+
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ExportPDF renders a header row and data rows as a landscape A3 table.
+// Column widths are given in millimetres; the first widths apply to the leading columns and
+// the remaining columns share the last width.
+func ExportPDF(title string, headers []string, rows [][]string, widths []float64) ([]byte, error) {
+	pdf := fpdf.New("L", "mm", "A3", "")
+	pdf.SetMargins(8, 10, 8)
+	pdf.SetAutoPageBreak(true, 10)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	columnWidth := func(i int) float64 {
+		if len(widths) == 0 {
+			return 20
+		}
+		if i < len(widths) {
+			return widths[i]
+		}
+		return widths[len(widths)-1]
+	}
+
+	printHeader := func() {
+		pdf.SetFont("Helvetica", "B", 7)
+		pdf.SetFillColor(224, 231, 255)
+		for i, header := range headers {
+			pdf.CellFormat(columnWidth(i), 6, tr(header), "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 7)
+	}
+
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 8, tr(title), "", 1, "L", false, 0, "")
+		printHeader()
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-8)
+		pdf.SetFont("Helvetica", "", 7)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	pdf.AddPage()
+	for _, row := range rows {
+		for i, value := range row {
+			align := "C"
+			if i < 2 {
+				align = "L"
+			}
+			pdf.CellFormat(columnWidth(i), 5, tr(value), "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,23 @@
+package helpers
+
+import "math"
+
+const earthRadiusMeters = 6371000.0
+
+// DistanceMeters returns the great-circle distance between two coordinates using the haversine formula
+func DistanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	toRadians := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return earthRadiusMeters * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// ValidCoordinates reports whether latitude and longitude are within range
+func ValidCoordinates(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
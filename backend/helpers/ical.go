@@ -0,0 +1,92 @@
+package helpers
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+// ICalEvent is an all-day event of an iCalendar feed
+type ICalEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	StartDate   string // YYYY-MM-DD
+	EndDate     string // YYYY-MM-DD, inclusive
+	Tentative   bool   // shown as tentative, e.g. while awaiting approval
+	Updated     time.Time
+}
+
+// ExportICal renders events as an iCalendar (RFC 5545) document
+func ExportICal(name string, events []ICalEvent) []byte {
+	var buf bytes.Buffer
+
+	writeLine := func(line string) {
+		// Lines longer than 75 octets are folded onto continuation lines starting with a space
+		for len(line) > 75 {
+			cut := 75
+			for cut > 0 && !isUTF8Boundary(line, cut) {
+				cut--
+			}
+			buf.WriteString(line[:cut] + "\r\n")
+			line = " " + line[cut:]
+		}
+		buf.WriteString(line + "\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//WorkZen//HRMS//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:" + escapeICalText(name))
+
+	for _, event := range events {
+		start, err := ParseDate(event.StartDate)
+		if err != nil {
+			continue
+		}
+		end, err := ParseDate(event.EndDate)
+		if err != nil {
+			continue
+		}
+
+		status := "CONFIRMED"
+		if event.Tentative {
+			status = "TENTATIVE"
+		}
+
+		writeLine("BEGIN:VEVENT")
+		writeLine("UID:" + event.UID)
+		writeLine("DTSTAMP:" + event.Updated.UTC().Format("20060102T150405Z"))
+		writeLine("DTSTART;VALUE=DATE:" + start.Format("20060102"))
+		// The end of an all-day event is exclusive
+		writeLine("DTEND;VALUE=DATE:" + end.AddDate(0, 0, 1).Format("20060102"))
+		writeLine("SUMMARY:" + escapeICalText(event.Summary))
+		if event.Description != "" {
+			writeLine("DESCRIPTION:" + escapeICalText(event.Description))
+		}
+		writeLine("STATUS:" + status)
+		writeLine("TRANSP:TRANSPARENT")
+		writeLine("END:VEVENT")
+	}
+
+	writeLine("END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// escapeICalText escapes the characters with a meaning in iCalendar text values
+func escapeICalText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
+}
+
+// isUTF8Boundary reports whether index does not split a multi-byte character
+func isUTF8Boundary(s string, index int) bool {
+	return index >= len(s) || s[index]&0xC0 != 0x80
+}
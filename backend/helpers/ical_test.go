@@ -0,0 +1,120 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestExportICal(t *testing.T) {
+	updated := time.Date(2025, time.March, 1, 10, 30, 0, 0, time.FixedZone("IST", 5*3600+1800))
+
+	got := string(ExportICal("Team leave", []ICalEvent{
+		{
+			UID:         "leave-1@workzen",
+			Summary:     "Jane Doe: sick leave",
+			Description: "Flu",
+			StartDate:   "2025-03-10",
+			EndDate:     "2025-03-11",
+			Updated:     updated,
+		},
+		{
+			UID:       "leave-2@workzen",
+			Summary:   "John Roe: casual leave",
+			StartDate: "2025-12-31",
+			EndDate:   "2025-12-31",
+			Tentative: true,
+			Updated:   updated,
+		},
+		{UID: "broken@workzen", Summary: "Skipped", StartDate: "10/03/2025", EndDate: "2025-03-10"},
+	}))
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//WorkZen//HRMS//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Team leave",
+		"BEGIN:VEVENT",
+		"UID:leave-1@workzen",
+		"DTSTAMP:20250301T050000Z",
+		"DTSTART;VALUE=DATE:20250310",
+		"DTEND;VALUE=DATE:20250312",
+		"SUMMARY:Jane Doe: sick leave",
+		"DESCRIPTION:Flu",
+		"STATUS:CONFIRMED",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:leave-2@workzen",
+		"DTSTAMP:20250301T050000Z",
+		"DTSTART;VALUE=DATE:20251231",
+		"DTEND;VALUE=DATE:20260101",
+		"SUMMARY:John Roe: casual leave",
+		"STATUS:TENTATIVE",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	if got != want {
+		t.Errorf("ExportICal() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEscapeICalText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{"a, b; c", `a\, b\; c`},
+		{`back\slash`, `back\\slash`},
+		{"line one\nline two", `line one\nline two`},
+		{"windows\r\nbreak", `windows\nbreak`},
+		{`\,`, `\\\,`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeICalText(tt.in); got != tt.want {
+			t.Errorf("escapeICalText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExportICalFolding(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary string
+	}{
+		{"ascii", strings.Repeat("Annual leave ", 20)},
+		{"multi-byte", strings.Repeat("Congé annuel – ", 15)},
+		{"multi-byte at the fold", strings.Repeat("a", 65) + strings.Repeat("é", 20)},
+		{"exactly one line", strings.Repeat("x", 75-len("SUMMARY:"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := string(ExportICal("Leave", []ICalEvent{
+				{UID: "leave@workzen", Summary: tt.summary, StartDate: "2025-03-10", EndDate: "2025-03-10"},
+			}))
+
+			for _, line := range strings.Split(strings.TrimSuffix(doc, "\r\n"), "\r\n") {
+				if len(line) > 75 {
+					t.Errorf("line of %d octets: %q", len(line), line)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line splits a character: %q", line)
+				}
+			}
+
+			// Unfolding removes each CRLF followed by a space
+			unfolded := strings.ReplaceAll(doc, "\r\n ", "")
+			if !strings.Contains(unfolded, "\r\nSUMMARY:"+escapeICalText(tt.summary)+"\r\n") {
+				t.Errorf("unfolded summary does not match %q", tt.summary)
+			}
+		})
+	}
+}
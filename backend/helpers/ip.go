@@ -0,0 +1,46 @@
+package helpers
+
+import (
+	"net"
+	"strings"
+)
+
+// ParseIPRange parses a CIDR block or a single IP address into a network
+func ParseIPRange(value string) (*net.IPNet, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, &net.ParseError{Type: "IP address", Text: value}
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(value)
+	return network, err
+}
+
+// IPInRanges reports whether ip falls inside any of the given CIDR blocks or addresses
+func IPInRanges(ip string, ranges []string) bool {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return false
+	}
+
+	for _, value := range ranges {
+		network, err := ParseIPRange(value)
+		if err != nil {
+			continue
+		}
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
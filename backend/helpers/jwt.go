@@ -16,10 +16,19 @@ var (
 	ErrTokenBeforeIssue = fmt.Errorf("token used before issue time")
 	ErrInvalidUserID    = fmt.Errorf("invalid user ID in token")
 
-	JWTExpireDuration = time.Duration(constants.EncryptionJWTExpire) * time.Hour * 24
-	JWTKey            = []byte(constants.EncryptionJWTSecret)
+	JWTExpireDuration       = time.Duration(constants.EncryptionJWTExpire) * time.Hour * 24 // refresh token lifetime
+	JWTAccessExpireDuration = accessExpireDuration()
+	JWTKey                  = []byte(constants.EncryptionJWTSecret)
 )
 
+// accessExpireDuration returns the configured access token lifetime, 15 minutes by default
+func accessExpireDuration() time.Duration {
+	if constants.EncryptionJWTAccessExpire <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(constants.EncryptionJWTAccessExpire) * time.Minute
+}
+
 func GenerateJWT(payload map[string]any, expire time.Time) (string, error) {
 	if expire.Before(time.Now()) {
 		return "", fmt.Errorf("expiration time must be in the future")
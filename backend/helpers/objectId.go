@@ -1,10 +1,15 @@
 package helpers
 
 import (
+	"errors"
+
 	"api.workzen.odoo/encryptions"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ErrInvalidEncryptedID is returned for encrypted IDs that do not decrypt to an ObjectID
+var ErrInvalidEncryptedID = errors.New("invalid ID")
+
 // ObjectID is a helper function that converts a string to a primitive.ObjectID
 func ObjectID(id string) (primitive.ObjectID, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -14,18 +19,19 @@ func ObjectID(id string) (primitive.ObjectID, error) {
 	return objectID, nil
 }
 
-// DecryptObjectID decrypts an encrypted ID and converts it to primitive.ObjectID
+// DecryptObjectID decrypts an encrypted ID and converts it to primitive.ObjectID. Tampered IDs and IDs
+// encrypted under another key fail with ErrInvalidEncryptedID.
 func DecryptObjectID(encryptedID string) (primitive.ObjectID, error) {
 	// Decrypt the ID
 	decryptedID, err := encryptions.DecryptID(encryptedID)
 	if err != nil {
-		return primitive.ObjectID{}, err
+		return primitive.ObjectID{}, ErrInvalidEncryptedID
 	}
 
 	// Convert to ObjectID
 	objectID, err := primitive.ObjectIDFromHex(decryptedID)
 	if err != nil {
-		return primitive.ObjectID{}, err
+		return primitive.ObjectID{}, ErrInvalidEncryptedID
 	}
 
 	return objectID, nil
@@ -0,0 +1,28 @@
+package helpers
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// commonPasswords is the bundled list of frequently used passwords, lowercased
+var commonPasswords = func() map[string]bool {
+	passwords := map[string]bool{}
+	for _, line := range strings.Split(commonPasswordList, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = true
+	}
+	return passwords
+}()
+
+// IsCommonPassword reports whether a password is on the bundled list of frequently used passwords,
+// ignoring case
+func IsCommonPassword(password string) bool {
+	return commonPasswords[strings.ToLower(password)]
+}
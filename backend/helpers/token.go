@@ -1,6 +1,10 @@
 package helpers
 
-import "time"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
 
 // GenerateVerificationToken generates a UUID-based verification token
 func GenerateVerificationToken() (string, error) {
@@ -11,3 +15,12 @@ func GenerateVerificationToken() (string, error) {
 func VerificationTokenExpiry() time.Time {
 	return time.Now().Add(24 * time.Hour)
 }
+
+// GenerateSecretToken generates a random 256-bit token for URLs that act as credentials
+func GenerateSecretToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
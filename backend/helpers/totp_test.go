@@ -0,0 +1,143 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the RFC 4226 / RFC 6238 SHA-1 test key "12345678901234567890", base32 encoded
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC4226(t *testing.T) {
+	// RFC 4226 appendix D, HOTP values for counters 0-9
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range want {
+		got, err := TOTPCode(rfcSecret, int64(counter))
+		if err != nil {
+			t.Fatalf("counter %d: %v", counter, err)
+		}
+		if got != code {
+			t.Errorf("counter %d: got %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1 column; the 6-digit code is the last 6 of the 8-digit value
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("T=%d: %v", tt.unix, err)
+		}
+		if got != tt.code {
+			t.Errorf("T=%d: got %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestTOTPCodeSecretFormats(t *testing.T) {
+	want, _ := TOTPCode(rfcSecret, 1)
+
+	for _, secret := range []string{strings.ToLower(rfcSecret), rfcSecret + "===="} {
+		got, err := TOTPCode(secret, 1)
+		if err != nil || got != want {
+			t.Errorf("secret %q: got %q, %v; want %q", secret, got, err, want)
+		}
+	}
+
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("invalid secret: expected an error")
+	}
+}
+
+func TestVerifyTOTPSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := TOTPStep(now)
+	code := func(step int64) string {
+		c, err := TOTPCode(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", code(current), 0, current, true},
+		{"one step behind", code(current - 1), 0, current - 1, true},
+		{"one step ahead", code(current + 1), 0, current + 1, true},
+		{"two steps behind", code(current - 2), 0, 0, false},
+		{"two steps ahead", code(current + 2), 0, 0, false},
+		{"spaces around and inside", " " + code(current)[:3] + " " + code(current)[3:] + " ", 0, current, true},
+		{"already used step", code(current), current, 0, false},
+		{"earlier step after a later one was used", code(current - 1), current, 0, false},
+		{"later step after an earlier one was used", code(current + 1), current, current + 1, true},
+		{"too short", code(current)[:5], 0, 0, false},
+		{"too long", code(current) + "0", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := VerifyTOTP(rfcSecret, tt.code, now, tt.lastStep)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("got (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"abcde-fghij", "abcdefghij"},
+		{" ABCDE-FGHIJ ", "abcdefghij"},
+		{"abcde fghij", "abcdefghij"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.in); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes, want 10", len(codes))
+	}
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' || code != strings.ToLower(code) {
+			t.Errorf("code %q is not formatted as xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q repeated", code)
+		}
+		seen[code] = true
+	}
+}
@@ -0,0 +1,64 @@
+package helpers
+
+import "strings"
+
+// userAgentBrowsers are matched in order: Chromium-based browsers also send "Chrome" and "Safari",
+// and Chrome also sends "Safari"
+var userAgentBrowsers = []struct{ token, name string }{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"PostmanRuntime/", "Postman"},
+	{"curl/", "curl"},
+	{"okhttp/", "Android app"},
+	{"Dart/", "Mobile app"},
+}
+
+var userAgentSystems = []struct{ token, name string }{
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// DescribeUserAgent returns a short device label such as "Chrome on Windows" for a User-Agent header
+func DescribeUserAgent(userAgent string) string {
+	if strings.TrimSpace(userAgent) == "" {
+		return "Unknown device"
+	}
+
+	browser := ""
+	for _, candidate := range userAgentBrowsers {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+
+	system := ""
+	for _, candidate := range userAgentSystems {
+		if strings.Contains(userAgent, candidate.token) {
+			system = candidate.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system + " device"
+	}
+	return "Unknown device"
+}
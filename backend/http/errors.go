@@ -14,6 +14,7 @@ type Error interface {
 	Unauthorized(c *fiber.Ctx, msg string) error
 	Forbidden(c *fiber.Ctx, msg string) error
 	Conflict(c *fiber.Ctx, msg string) error
+	TooManyRequests(c *fiber.Ctx, msg string) error
 
 	// 5xx errors
 	InternalServerError(c *fiber.Ctx, msg string) error
@@ -79,6 +80,10 @@ func (e *httpErrors) Conflict(c *fiber.Ctx, msg string) error {
 	return createErrorResponse(c, fiber.StatusConflict, msg, "Conflict")
 }
 
+func (e *httpErrors) TooManyRequests(c *fiber.Ctx, msg string) error {
+	return createErrorResponse(c, fiber.StatusTooManyRequests, msg, "Too Many Requests")
+}
+
 // 5xx errors
 func (e *httpErrors) InternalServerError(c *fiber.Ctx, msg string) error {
 	return createErrorResponse(c, fiber.StatusInternalServerError, msg, "Internal Server Error")
@@ -0,0 +1,122 @@
+package jobs
+
+import (
+	"time"
+
+	"api.workzen.odoo/constants"
+	"api.workzen.odoo/helpers"
+	"api.workzen.odoo/services"
+)
+
+// StartAll schedules the server's background jobs when they are enabled in config
+func StartAll() {
+	if !constants.JobsEnabled {
+		return
+	}
+
+	Start(
+		attendanceDailyJob(),
+		leaveAccrualJob(),
+		leaveEscalationJob(),
+		compOffExpiryJob(),
+		leaveDocumentOverdueJob(),
+		bankDetailsEncryptionJob(),
+		twoFactorSecretEncryptionJob(),
+		loginAttemptCleanupJob(),
+	)
+}
+
+// attendanceDailyJob closes open check-ins and marks absences for the previous day
+func attendanceDailyJob() Job {
+	at := constants.JobsAttendanceTime
+	if at == "" {
+		at = "00:30"
+	}
+
+	attendanceService := services.NewAttendanceService()
+
+	return Job{
+		Name: "attendance_daily",
+		At:   at,
+		Run: func(now time.Time) error {
+			return attendanceService.RunDailyJob(helpers.FormatDate(now.AddDate(0, 0, -1)))
+		},
+	}
+}
+
+// leaveAccrualJob credits due leave accruals every night; already credited periods are skipped
+func leaveAccrualJob() Job {
+	leaveService := services.NewLeaveService()
+
+	return Job{
+		Name: "leave_accrual",
+		At:   "01:00",
+		Run:  leaveService.RunAccrualForAllCompanies,
+	}
+}
+
+// leaveEscalationJob hourly passes leave approvals left pending too long to the next approver
+func leaveEscalationJob() Job {
+	leaveService := services.NewLeaveService()
+
+	return Job{
+		Name:     "leave_escalation",
+		Interval: time.Hour,
+		Run:      leaveService.RunEscalationForAllCompanies,
+	}
+}
+
+// compOffExpiryJob lapses the unused days of comp-off credits whose validity ended
+func compOffExpiryJob() Job {
+	leaveService := services.NewLeaveService()
+
+	return Job{
+		Name: "comp_off_expiry",
+		At:   "01:30",
+		Run:  leaveService.RunCompOffExpiryForAllCompanies,
+	}
+}
+
+// leaveDocumentOverdueJob flags leaves whose supporting document is past its due date
+func leaveDocumentOverdueJob() Job {
+	leaveService := services.NewLeaveService()
+
+	return Job{
+		Name: "leave_document_overdue",
+		At:   "01:45",
+		Run:  leaveService.RunLeaveDocumentOverdueForAllCompanies,
+	}
+}
+
+// bankDetailsEncryptionJob encrypts bank details still stored in plaintext
+func bankDetailsEncryptionJob() Job {
+	userService := services.NewUserService()
+
+	return Job{
+		Name: "bank_details_encryption",
+		At:   "02:00",
+		Run:  userService.RunBankDetailsEncryption,
+	}
+}
+
+// twoFactorSecretEncryptionJob re-encrypts TOTP secrets still stored with the legacy cipher
+func twoFactorSecretEncryptionJob() Job {
+	authService := services.NewAuthService()
+
+	return Job{
+		Name: "two_factor_secret_encryption",
+		At:   "02:15",
+		Run:  authService.RunTwoFactorSecretEncryption,
+	}
+}
+
+// loginAttemptCleanupJob hourly drops the failed-login counters of IP addresses that went quiet
+func loginAttemptCleanupJob() Job {
+	authService := services.NewAuthService()
+
+	return Job{
+		Name:     "login_attempt_cleanup",
+		Interval: time.Hour,
+		Run:      authService.RunLoginAttemptCleanup,
+	}
+}
@@ -0,0 +1,104 @@
+// Package jobs runs scheduled background work inside the API server process.
+package jobs
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is a unit of background work run either daily at a fixed time or at a fixed interval
+type Job struct {
+	Name     string
+	At       string        // HH:MM daily run time (server local time)
+	Interval time.Duration // used when At is empty
+	Run      func(now time.Time) error
+}
+
+var (
+	mu      sync.Mutex
+	stop    chan struct{}
+	running sync.WaitGroup
+)
+
+// Start launches every registered job in its own goroutine
+func Start(jobs ...Job) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if stop != nil {
+		return
+	}
+	stop = make(chan struct{})
+
+	for _, job := range jobs {
+		if job.At == "" && job.Interval <= 0 {
+			log.Printf("⚠️  Job %s has no schedule, skipping\n", job.Name)
+			continue
+		}
+
+		running.Add(1)
+		go loop(job, stop)
+		log.Printf("⏱️  Job %s scheduled\n", job.Name)
+	}
+}
+
+// Stop signals all jobs to exit and waits for in-flight runs to finish
+func Stop() {
+	mu.Lock()
+	if stop == nil {
+		mu.Unlock()
+		return
+	}
+	close(stop)
+	stop = nil
+	mu.Unlock()
+
+	running.Wait()
+}
+
+func loop(job Job, stop <-chan struct{}) {
+	defer running.Done()
+
+	for {
+		timer := time.NewTimer(time.Until(nextRun(job, time.Now())))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case now := <-timer.C:
+			execute(job, now)
+		}
+	}
+}
+
+func execute(job Job, now time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ Job %s panicked: %v\n", job.Name, r)
+		}
+	}()
+
+	if err := job.Run(now); err != nil {
+		log.Printf("⚠️  Job %s failed: %v\n", job.Name, err)
+	}
+}
+
+// nextRun returns the next time the job is due after now
+func nextRun(job Job, now time.Time) time.Time {
+	if job.At == "" {
+		return now.Add(job.Interval)
+	}
+
+	at, err := time.Parse("15:04", job.At)
+	if err != nil {
+		at, _ = time.Parse("15:04", "00:30")
+	}
+
+	next := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next
+}
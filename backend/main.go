@@ -8,8 +8,10 @@ import (
 	"api.workzen.odoo/constants"
 	"api.workzen.odoo/databases"
 	"api.workzen.odoo/databases/seed"
+	"api.workzen.odoo/jobs"
 	"api.workzen.odoo/routers"
 	"github.com/Delta456/box-cli-maker/v2"
+	"github.com/gofiber/fiber/v2"
 )
 
 func init() {
@@ -53,6 +55,12 @@ func main() {
 		}
 	}()
 
+	// Background jobs run once, in the master process when prefork is enabled
+	if !fiber.IsChild() {
+		jobs.StartAll()
+		defer jobs.Stop()
+	}
+
 	router := routers.Init()
 	router.Listen(constants.ServerPort)
 }
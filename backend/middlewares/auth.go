@@ -13,11 +13,15 @@ import (
 	"api.workzen.odoo/databases/models"
 	"api.workzen.odoo/encryptions"
 	"api.workzen.odoo/helpers"
+	"api.workzen.odoo/services"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// sessionLastSeenInterval throttles how often a session's last-seen time is written
+const sessionLastSeenInterval = time.Minute
+
 // AuthMiddleware verifies JWT token and extracts user information
 func AuthMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -74,12 +78,49 @@ func AuthMiddleware() fiber.Handler {
 			return constants.HTTPErrors.Forbidden(c, "User account is inactive")
 		}
 
+		// The token must belong to a session that has not been revoked (logout, password change, ...)
+		encryptedSessionID, ok := claims["sid"].(string)
+		if !ok {
+			return constants.HTTPErrors.Unauthorized(c, "Invalid token claims")
+		}
+		sessionID, err := helpers.DecryptObjectID(encryptedSessionID)
+		if err != nil {
+			return constants.HTTPErrors.Unauthorized(c, "Invalid session in token")
+		}
+
+		sessionCollection := databases.GetMongoDBCollection(collections.Sessions)
+		var session models.Session
+		err = sessionCollection.FindOne(ctx, bson.M{
+			"_id":        sessionID,
+			"user_id":    user.ID,
+			"revoked_at": bson.M{"$exists": false},
+			"expires_at": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+		}).Decode(&session)
+		if err != nil {
+			return constants.HTTPErrors.Unauthorized(c, "Session has ended, please log in again")
+		}
+
+		// Record activity on the session, at most once per interval to spare the database
+		now := time.Now()
+		if now.Sub(session.LastSeenAt.Time()) >= sessionLastSeenInterval {
+			sessionCollection.UpdateOne(ctx, bson.M{"_id": session.ID}, bson.M{"$set": bson.M{
+				"last_seen_at": primitive.NewDateTimeFromTime(now),
+				"ip_address":   c.IP(),
+			}})
+		}
+
+		// Resolve permissions once; every check during the request reads them from the user
+		if err := services.LoadPermissions(&user); err != nil {
+			return constants.HTTPErrors.InternalServerError(c, "Failed to load permissions")
+		}
+
 		// Store user in context
 		c.Locals("user", user)
 		c.Locals("userID", user.ID)
 		c.Locals("companyID", user.Company)
 		c.Locals("role", user.Role)
 		c.Locals("isSuperAdmin", user.IsSuperAdmin)
+		c.Locals("sessionID", session.ID)
 
 		return c.Next()
 	}
@@ -103,6 +144,15 @@ func GetAuthUserID(c *fiber.Ctx) (primitive.ObjectID, error) {
 	return userID, nil
 }
 
+// GetAuthSessionID retrieves the session the request's token belongs to
+func GetAuthSessionID(c *fiber.Ctx) (primitive.ObjectID, error) {
+	sessionID, ok := c.Locals("sessionID").(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, fiber.NewError(fiber.StatusUnauthorized, "Session ID not found in context")
+	}
+	return sessionID, nil
+}
+
 // GetAuthCompanyID retrieves the authenticated user's company ID from context
 func GetAuthCompanyID(c *fiber.Ctx) (primitive.ObjectID, error) {
 	companyID, ok := c.Locals("companyID").(primitive.ObjectID)
@@ -0,0 +1,57 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"api.workzen.odoo/constants"
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RateLimitByIP allows at most max requests per client IP and route within a fixed window. Counters are
+// kept in MongoDB, so the limit holds across prefork processes and server instances.
+func RateLimitByIP(max int, window time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		rateLimitsCollection := databases.MongoDBDatabase.Collection(collections.RateLimits)
+
+		now := time.Now()
+		start := now.Truncate(window)
+		end := start.Add(window)
+		key := fmt.Sprintf("%s %s|%s|%d", c.Method(), c.Route().Path, c.IP(), start.Unix())
+
+		var limit models.RateLimit
+		err := rateLimitsCollection.FindOneAndUpdate(ctx,
+			bson.M{"key": key},
+			bson.M{
+				"$inc":         bson.M{"count": 1},
+				"$setOnInsert": bson.M{"expires_at": primitive.NewDateTimeFromTime(end)},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&limit)
+		if err != nil {
+			// Failing open keeps the API up; logins still have their own per-account and per-IP throttle
+			log.Printf("⚠️  Rate limit check failed for %s: %v\n", key, err)
+			return c.Next()
+		}
+
+		if limit.Count > max {
+			retryAfter := int(end.Sub(now).Seconds()) + 1
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			return constants.HTTPErrors.TooManyRequests(c, "Too many requests, please try again later")
+		}
+
+		return c.Next()
+	}
+}
@@ -3,21 +3,22 @@ package middlewares
 import (
 	"api.workzen.odoo/constants"
 	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/services"
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// RequireRole creates middleware that checks if user has one of the specified roles
-func RequireRole(roles ...models.Role) fiber.Handler {
+// RequirePermission creates middleware that checks if user holds one of the specified permissions in
+// their company
+func RequirePermission(permissions ...models.Permission) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		user, err := GetAuthUser(c)
 		if err != nil {
 			return constants.HTTPErrors.Unauthorized(c, "Authentication required")
 		}
 
-		// Check if user has any of the required roles
-		for _, role := range roles {
-			if user.Role == role {
+		// Check if user has any of the required permissions
+		for _, permission := range permissions {
+			if services.HasPermission(user, permission, user.Company) {
 				return c.Next()
 			}
 		}
@@ -42,54 +43,6 @@ func RequireSuperAdmin() fiber.Handler {
 	}
 }
 
-// RequireCompanyAdmin checks if user is Admin or SuperAdmin
-func RequireCompanyAdmin() fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		user, err := GetAuthUser(c)
-		if err != nil {
-			return constants.HTTPErrors.Unauthorized(c, "Authentication required")
-		}
-
-		if !user.IsSuperAdmin && user.Role != models.RoleAdmin {
-			return constants.HTTPErrors.Forbidden(c, "Admin access required")
-		}
-
-		return c.Next()
-	}
-}
-
-// RequireHROrAdmin checks if user is HR, Admin, or SuperAdmin
-func RequireHROrAdmin() fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		user, err := GetAuthUser(c)
-		if err != nil {
-			return constants.HTTPErrors.Unauthorized(c, "Authentication required")
-		}
-
-		if !user.IsSuperAdmin && user.Role != models.RoleAdmin && user.Role != models.RoleHR {
-			return constants.HTTPErrors.Forbidden(c, "HR or Admin access required")
-		}
-
-		return c.Next()
-	}
-}
-
-// RequirePayrollOrAdmin checks if user is Payroll Officer, Admin, or SuperAdmin
-func RequirePayrollOrAdmin() fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		user, err := GetAuthUser(c)
-		if err != nil {
-			return constants.HTTPErrors.Unauthorized(c, "Authentication required")
-		}
-
-		if !user.IsSuperAdmin && user.Role != models.RoleAdmin && user.Role != models.RolePayroll {
-			return constants.HTTPErrors.Forbidden(c, "Payroll Officer or Admin access required")
-		}
-
-		return c.Next()
-	}
-}
-
 // CompanyScopeMiddleware ensures user can only access resources from their own company
 func CompanyScopeMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -109,52 +62,3 @@ func CompanyScopeMiddleware() fiber.Handler {
 		return c.Next()
 	}
 }
-
-// CanAccessEmployee checks if the authenticated user can access a specific employee's data
-func CanAccessEmployee(targetEmployeeID primitive.ObjectID) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		user, err := GetAuthUser(c)
-		if err != nil {
-			return constants.HTTPErrors.Unauthorized(c, "Authentication required")
-		}
-
-		// SuperAdmin and Admin can access all employees in their scope
-		if user.IsSuperAdmin || user.Role == models.RoleAdmin {
-			return c.Next()
-		}
-
-		// HR can access all employees in the company
-		if user.Role == models.RoleHR {
-			return c.Next()
-		}
-
-		// Payroll can access all employees for payroll purposes
-		if user.Role == models.RolePayroll {
-			return c.Next()
-		}
-
-		// Employee can only access their own data
-		if user.ID == targetEmployeeID {
-			return c.Next()
-		}
-
-		return constants.HTTPErrors.Forbidden(c, "You can only access your own data")
-	}
-}
-
-// CanModifySalaryInfo checks if user can view/edit salary information
-func CanModifySalaryInfo() fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		user, err := GetAuthUser(c)
-		if err != nil {
-			return constants.HTTPErrors.Unauthorized(c, "Authentication required")
-		}
-
-		// Only Admin, HR, and Payroll can modify salary info
-		if !user.IsSuperAdmin && user.Role != models.RoleAdmin && user.Role != models.RoleHR && user.Role != models.RolePayroll {
-			return constants.HTTPErrors.Forbidden(c, "Only Admin, HR, or Payroll Officer can modify salary information")
-		}
-
-		return c.Next()
-	}
-}
@@ -1,7 +1,10 @@
 package routers
 
 import (
+	"time"
+
 	"api.workzen.odoo/controllers"
+	"api.workzen.odoo/databases/models"
 	"api.workzen.odoo/middlewares"
 	"github.com/gofiber/fiber/v2"
 )
@@ -19,6 +22,7 @@ func RegisterRoutes(app *fiber.App) {
 	payrollController := controllers.NewPayrollController()
 	documentController := controllers.NewDocumentController()
 	dashboardController := controllers.NewDashboardController()
+	roleController := controllers.NewRoleController()
 
 	// API v1 Routes
 	api := app.Group("/api/v1")
@@ -33,18 +37,37 @@ func RegisterRoutes(app *fiber.App) {
 
 	// ==================== AUTH ROUTES ====================
 	auth := api.Group("/auth")
-	auth.Post("/signup", authController.Signup)
-	auth.Post("/login", authController.Login)
+	auth.Post("/signup", middlewares.RateLimitByIP(5, time.Hour), authController.Signup)
+	auth.Post("/login", middlewares.RateLimitByIP(20, time.Minute), authController.Login)
+	auth.Post("/login/2fa", middlewares.RateLimitByIP(20, time.Minute), authController.VerifyTwoFactorLogin)
+	auth.Post("/login/password", middlewares.RateLimitByIP(10, time.Minute), authController.ChangeExpiredPassword)
+	auth.Post("/login/2fa/setup", middlewares.RateLimitByIP(10, time.Minute), authController.SetupTwoFactorWithChallenge)
+	auth.Post("/login/2fa/enable", middlewares.RateLimitByIP(20, time.Minute), authController.EnableTwoFactorWithChallenge)
+	auth.Post("/refresh", middlewares.RateLimitByIP(60, time.Minute), authController.RefreshToken)
+	auth.Post("/logout", middlewares.AuthMiddleware(), authController.Logout)
+	auth.Post("/logout-all", middlewares.AuthMiddleware(), authController.LogoutAll)
+	auth.Get("/sessions", middlewares.AuthMiddleware(), authController.ListSessions)
+	auth.Delete("/sessions/:id", middlewares.AuthMiddleware(), authController.RevokeSession)
 	auth.Get("/verify-email", authController.VerifyEmail)
-	auth.Post("/resend-verification", authController.ResendVerificationEmail)
+	auth.Post("/resend-verification", middlewares.RateLimitByIP(5, 15*time.Minute), authController.ResendVerificationEmail)
+	auth.Post("/forgot-password", middlewares.RateLimitByIP(5, 15*time.Minute), authController.ForgotPassword)
+	auth.Post("/reset-password", middlewares.RateLimitByIP(10, 15*time.Minute), authController.ResetPassword)
 	auth.Get("/me", middlewares.AuthMiddleware(), authController.GetMe)
 	auth.Post("/change-password", middlewares.AuthMiddleware(), authController.ChangePassword)
+	auth.Post("/2fa/setup", middlewares.AuthMiddleware(), authController.SetupTwoFactor)
+	auth.Post("/2fa/enable", middlewares.AuthMiddleware(), authController.EnableTwoFactor)
+	auth.Post("/2fa/disable", middlewares.AuthMiddleware(), authController.DisableTwoFactor)
+	auth.Post("/2fa/recovery-codes", middlewares.AuthMiddleware(), authController.RegenerateRecoveryCodes)
 
 	// ==================== COMPANY ROUTES ====================
 	companies := api.Group("/companies")
 	companies.Use(middlewares.AuthMiddleware())
 	companies.Post("/", middlewares.RequireSuperAdmin(), companyController.CreateCompany)
 	companies.Get("/", middlewares.RequireSuperAdmin(), companyController.ListCompanies)
+	companies.Get("/two-factor", companyController.GetTwoFactorPolicy)
+	companies.Put("/two-factor", middlewares.RequirePermission(models.PermCompanySecurity), companyController.SaveTwoFactorPolicy)
+	companies.Get("/password-policy", companyController.GetPasswordPolicy)
+	companies.Put("/password-policy", middlewares.RequirePermission(models.PermCompanySecurity), companyController.SavePasswordPolicy)
 	companies.Get("/:id", companyController.GetCompanyByID)
 	companies.Patch("/:id/approve", middlewares.RequireSuperAdmin(), companyController.ApproveCompany)
 	companies.Patch("/:id/deactivate", middlewares.RequireSuperAdmin(), companyController.DeactivateCompany)
@@ -52,22 +75,38 @@ func RegisterRoutes(app *fiber.App) {
 	// ==================== USER ROUTES ====================
 	users := api.Group("/users")
 	users.Use(middlewares.AuthMiddleware())
-	users.Post("/", middlewares.RequireHROrAdmin(), userController.CreateUser)
-	users.Get("/", middlewares.RequireHROrAdmin(), userController.ListUsers)
+	users.Post("/", middlewares.RequirePermission(models.PermUserCreate), userController.CreateUser)
+	users.Get("/", middlewares.RequirePermission(models.PermUserViewAll), userController.ListUsers)
 	users.Get("/:id", userController.GetUserByID)
-	users.Put("/:id", middlewares.RequireHROrAdmin(), userController.UpdateUser)
-	users.Patch("/:id/status", middlewares.RequireCompanyAdmin(), userController.UpdateUserStatus)
+	users.Put("/:id", middlewares.RequirePermission(models.PermUserUpdate), userController.UpdateUser)
+	users.Patch("/:id/status", middlewares.RequirePermission(models.PermUserManage), userController.UpdateUserStatus)
+	// Users update their own bank details; anyone else's need salary.manage, checked in the service
 	users.Patch("/:id/bank", userController.UpdateBankDetails)
-	users.Delete("/:id", middlewares.RequireCompanyAdmin(), userController.DeleteUser)
+	users.Post("/:id/bank/reveal", middlewares.RequirePermission(models.PermBankReveal), userController.RevealBankDetails)
+	users.Post("/:id/unlock", middlewares.RequirePermission(models.PermUserManage), userController.UnlockUser)
+	users.Delete("/:id", middlewares.RequirePermission(models.PermUserManage), userController.DeleteUser)
+	users.Get("/:id/sessions", middlewares.RequirePermission(models.PermUserManage), userController.ListUserSessions)
+	users.Delete("/:id/sessions", middlewares.RequirePermission(models.PermUserManage), userController.RevokeUserSessions)
+	users.Delete("/:id/sessions/:sessionId", middlewares.RequirePermission(models.PermUserManage), userController.RevokeUserSession)
+	users.Put("/:id/role", middlewares.RequirePermission(models.PermRoleManage), roleController.AssignRole)
+
+	// ==================== ROLE ROUTES ====================
+	roles := api.Group("/roles")
+	roles.Use(middlewares.AuthMiddleware())
+	roles.Get("/permissions", roleController.ListPermissions)
+	roles.Get("/", roleController.ListRoles)
+	roles.Post("/", middlewares.RequirePermission(models.PermRoleManage), roleController.CreateRole)
+	roles.Put("/:id", middlewares.RequirePermission(models.PermRoleManage), roleController.UpdateRole)
+	roles.Delete("/:id", middlewares.RequirePermission(models.PermRoleManage), roleController.DeleteRole)
 
 	// ==================== DEPARTMENT ROUTES ====================
 	departments := api.Group("/departments")
 	departments.Use(middlewares.AuthMiddleware())
-	departments.Post("/", middlewares.RequireHROrAdmin(), departmentController.CreateDepartment)
+	departments.Post("/", middlewares.RequirePermission(models.PermDepartmentManage), departmentController.CreateDepartment)
 	departments.Get("/", departmentController.ListDepartments)
 	departments.Get("/:id", departmentController.GetDepartmentByID)
-	departments.Patch("/:id", middlewares.RequireHROrAdmin(), departmentController.UpdateDepartment)
-	departments.Delete("/:id", middlewares.RequireCompanyAdmin(), departmentController.DeleteDepartment)
+	departments.Patch("/:id", middlewares.RequirePermission(models.PermDepartmentManage), departmentController.UpdateDepartment)
+	departments.Delete("/:id", middlewares.RequirePermission(models.PermDepartmentDelete), departmentController.DeleteDepartment)
 
 	// ==================== ATTENDANCE ROUTES ====================
 	attendance := api.Group("/attendance")
@@ -76,40 +115,91 @@ func RegisterRoutes(app *fiber.App) {
 	attendance.Post("/check-out", attendanceController.CheckOut)
 	attendance.Delete("/reset", attendanceController.ResetAttendance)
 	attendance.Get("/me", attendanceController.GetMyAttendance)
-	attendance.Get("/", middlewares.RequireHROrAdmin(), attendanceController.ListAttendance)
-	attendance.Get("/summary", middlewares.RequireHROrAdmin(), attendanceController.GetAttendanceSummary)
+	attendance.Post("/regularizations", attendanceController.RequestRegularization)
+	attendance.Get("/regularizations", attendanceController.ListRegularizations)
+	attendance.Patch("/regularizations/:id/approve", attendanceController.ApproveRegularization)
+	attendance.Patch("/regularizations/:id/reject", attendanceController.RejectRegularization)
+	attendance.Get("/", middlewares.RequirePermission(models.PermAttendanceViewAll), attendanceController.ListAttendance)
+	attendance.Get("/summary", middlewares.RequirePermission(models.PermAttendanceViewAll), attendanceController.GetAttendanceSummary)
+	attendance.Get("/muster-roll", middlewares.RequirePermission(models.PermAttendanceViewAll), attendanceController.GetMusterRoll)
+	attendance.Get("/configuration", middlewares.RequirePermission(models.PermAttendanceViewAll), attendanceController.GetConfiguration)
+	attendance.Post("/configuration", middlewares.RequirePermission(models.PermAttendanceConfigure), attendanceController.SaveConfiguration)
+	attendance.Get("/jobs/runs", middlewares.RequirePermission(models.PermAttendanceViewAll), attendanceController.ListJobRuns)
+	attendance.Post("/jobs/rerun", middlewares.RequirePermission(models.PermAttendanceManage), attendanceController.RerunJob)
+	attendance.Post("/punches", middlewares.RequirePermission(models.PermAttendanceManage), attendanceController.IngestPunches)
+	attendance.Post("/punches/import", middlewares.RequirePermission(models.PermAttendanceManage), attendanceController.ImportPunches)
+	attendance.Get("/punches/errors", middlewares.RequirePermission(models.PermAttendanceManage), attendanceController.ListPunchErrors)
+	attendance.Patch("/punches/errors/:id/resolve", middlewares.RequirePermission(models.PermAttendanceManage), attendanceController.ResolvePunchError)
+	attendance.Patch("/punches/errors/:id/dismiss", middlewares.RequirePermission(models.PermAttendanceManage), attendanceController.DismissPunchError)
 
 	// ==================== LEAVE ROUTES ====================
 	leaves := api.Group("/leaves")
 	leaves.Use(middlewares.AuthMiddleware())
 	leaves.Post("/", leaveController.ApplyLeave)
 	leaves.Get("/", leaveController.ListLeaves) // All users can list (filtered by role in controller)
-	leaves.Patch("/:id/approve", middlewares.RequireHROrAdmin(), leaveController.ApproveLeave)
-	leaves.Patch("/:id/reject", middlewares.RequireHROrAdmin(), leaveController.RejectLeave)
+	leaves.Get("/balance", leaveController.GetBalances)
+	leaves.Get("/ledger", leaveController.ListLedger)
+	leaves.Post("/ledger/adjustments", middlewares.RequirePermission(models.PermLeaveManageBalances), leaveController.AdjustBalance)
+	leaves.Get("/configuration", leaveController.GetConfiguration)
+	leaves.Post("/configuration", middlewares.RequirePermission(models.PermLeaveConfigure), leaveController.SaveConfiguration)
+	leaves.Get("/types", leaveController.ListLeaveTypes)
+	leaves.Post("/types", middlewares.RequirePermission(models.PermLeaveConfigure), leaveController.CreateLeaveType)
+	leaves.Put("/types/:code", middlewares.RequirePermission(models.PermLeaveConfigure), leaveController.UpdateLeaveType)
+	leaves.Get("/policies", leaveController.ListPolicies)
+	leaves.Post("/policies", middlewares.RequirePermission(models.PermLeaveConfigure), leaveController.SavePolicy)
+	leaves.Post("/accrual/run", middlewares.RequirePermission(models.PermLeaveManageBalances), leaveController.RunAccrual)
+	leaves.Post("/rollover", middlewares.RequirePermission(models.PermLeaveManageBalances), leaveController.RunRollover)
+	leaves.Get("/rollovers", middlewares.RequirePermission(models.PermLeaveManageBalances), leaveController.ListRollovers)
+	leaves.Post("/rollovers/:id/reverse", middlewares.RequirePermission(models.PermLeaveManageBalances), leaveController.ReverseRollover)
+	leaves.Get("/calendar", leaveController.GetLeaveCalendar)
+	leaves.Post("/calendar/feed", leaveController.CreateCalendarFeed)
+	leaves.Delete("/calendar/feed", leaveController.RevokeCalendarFeed)
+	leaves.Get("/approvals", leaveController.ListPendingApprovals)
+	leaves.Get("/delegations", leaveController.ListDelegations)
+	leaves.Post("/delegations", leaveController.CreateDelegation)
+	leaves.Delete("/delegations/:id", leaveController.RevokeDelegation)
+	// Comp-offs are reviewed by the employee's reporting manager or HR, checked in the service
+	leaves.Get("/comp-offs", leaveController.ListCompOffs)
+	leaves.Post("/comp-offs", leaveController.RequestCompOff)
+	leaves.Patch("/comp-offs/:id/approve", leaveController.ApproveCompOff)
+	leaves.Patch("/comp-offs/:id/reject", leaveController.RejectCompOff)
+	// Approvers are checked against the leave's approval chain in the service
+	leaves.Get("/:id/conflicts", leaveController.GetLeaveConflicts)
+	leaves.Patch("/:id/approve", leaveController.ApproveLeave)
+	leaves.Patch("/:id/reject", leaveController.RejectLeave)
+	leaves.Patch("/:id/change/approve", leaveController.ApproveLeaveChange)
+	leaves.Patch("/:id/change/reject", leaveController.RejectLeaveChange)
+	leaves.Post("/:id/cancel", leaveController.CancelLeave)
+	leaves.Post("/:id/modify", leaveController.ModifyLeave)
+	leaves.Post("/:id/documents", leaveController.AttachLeaveDocuments)
+
+	// Calendar apps subscribe without a bearer token; the secret in the URL identifies the user
+	calendar := api.Group("/calendar")
+	calendar.Get("/feed/:token", leaveController.GetCalendarFeed)
 
 	// ==================== SALARY STRUCTURE ROUTES ====================
 	salary := api.Group("/salary-structure")
 	salary.Use(middlewares.AuthMiddleware())
-	salary.Post("/", middlewares.CanModifySalaryInfo(), salaryController.CreateSalaryStructure)
+	salary.Post("/", middlewares.RequirePermission(models.PermSalaryManage), salaryController.CreateSalaryStructure)
 	salary.Get("/:employee_id", salaryController.GetSalaryStructure)
-	salary.Patch("/:employee_id", middlewares.CanModifySalaryInfo(), salaryController.UpdateSalaryStructure)
+	salary.Patch("/:employee_id", middlewares.RequirePermission(models.PermSalaryManage), salaryController.UpdateSalaryStructure)
 
 	// ==================== PAYROLL CONFIGURATION ROUTES ====================
 	payrollConfig := api.Group("/payroll/configuration")
 	payrollConfig.Use(middlewares.AuthMiddleware())
-	payrollConfig.Post("/", middlewares.RequireCompanyAdmin(), payrollController.CreateConfiguration)
-	payrollConfig.Get("/", middlewares.RequirePayrollOrAdmin(), payrollController.GetConfiguration)
+	payrollConfig.Post("/", middlewares.RequirePermission(models.PermPayrollConfigure), payrollController.CreateConfiguration)
+	payrollConfig.Get("/", middlewares.RequirePermission(models.PermPayrollRun, models.PermPayrollConfigure), payrollController.GetConfiguration)
 
 	// ==================== PAYROLL & PAYRUN ROUTES ====================
 	payruns := api.Group("/payruns")
 	payruns.Use(middlewares.AuthMiddleware())
-	payruns.Post("/", middlewares.RequirePayrollOrAdmin(), payrollController.CreatePayrun)
+	payruns.Post("/", middlewares.RequirePermission(models.PermPayrollRun), payrollController.CreatePayrun)
 	payruns.Get("/", payrollController.ListPayruns) // Allow all authenticated users with role filtering
 
 	payrolls := api.Group("/payrolls")
 	payrolls.Use(middlewares.AuthMiddleware())
 	payrolls.Get("/:employee_id", payrollController.GetEmployeePayroll)
-	payrolls.Patch("/:id/mark-paid", middlewares.RequirePayrollOrAdmin(), payrollController.MarkAsPaid)
+	payrolls.Patch("/:id/mark-paid", middlewares.RequirePermission(models.PermPayrollRun), payrollController.MarkAsPaid)
 
 	// ==================== DOCUMENT ROUTES ====================
 	documents := api.Group("/documents")
@@ -118,12 +208,12 @@ func RegisterRoutes(app *fiber.App) {
 	documents.Get("/", documentController.ListDocuments)                // All users can list (filtered by role in controller)
 	documents.Get("/:id/view", documentController.ViewDocument)         // View document (images, videos, PDFs)
 	documents.Get("/:id/download", documentController.DownloadDocument) // Download document
-	documents.Delete("/:id", middlewares.RequireCompanyAdmin(), documentController.DeleteDocument)
+	documents.Delete("/:id", middlewares.RequirePermission(models.PermDocumentDelete), documentController.DeleteDocument)
 
 	// ==================== DASHBOARD ROUTES ====================
 	dashboard := api.Group("/dashboard")
 	dashboard.Use(middlewares.AuthMiddleware())
 	dashboard.Get("/", dashboardController.GetDashboard) // General dashboard for all users
-	dashboard.Get("/admin", middlewares.RequireCompanyAdmin(), dashboardController.GetAdminDashboard)
+	dashboard.Get("/admin", middlewares.RequirePermission(models.PermDashboardAdmin), dashboardController.GetAdminDashboard)
 	dashboard.Get("/superadmin", middlewares.RequireSuperAdmin(), dashboardController.GetSuperAdminDashboard)
 }
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SaveAttendanceConfigurationRequest for company attendance rules
+type SaveAttendanceConfigurationRequest struct {
+	WorkingDays      []int    `json:"working_days"` // 0 = Sunday ... 6 = Saturday
+	Holidays         []string `json:"holidays"`     // YYYY-MM-DD
+	ShiftStart       string   `json:"shift_start"`  // HH:MM:SS
+	ShiftEnd         string   `json:"shift_end"`    // HH:MM:SS
+	DefaultWorkHours float64  `json:"default_work_hours"`
+	LateGraceMinutes *int     `json:"late_grace_minutes"`
+	AutoClosePolicy  string   `json:"auto_close_policy"` // shift_end | default_hours | absent
+
+	OfficeLocations []models.OfficeLocation `json:"office_locations"`
+	AllowedIPRanges []string                `json:"allowed_ip_ranges"` // CIDR or single IP
+	CheckInPolicy   string                  `json:"check_in_policy"`   // off | reject | flag
+}
+
+// SaveConfiguration creates or updates the attendance configuration of a company
+func (s *AttendanceService) SaveConfiguration(req *SaveAttendanceConfigurationRequest, companyID, userID primitive.ObjectID) (*models.AttendanceConfiguration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	configCollection := databases.MongoDBDatabase.Collection(collections.AttendanceConfigurations)
+
+	config := models.DefaultAttendanceConfiguration(companyID)
+
+	if req.WorkingDays != nil {
+		seen := make(map[int]bool)
+		days := []int{}
+		for _, day := range req.WorkingDays {
+			if day < 0 || day > 6 {
+				return nil, errors.New("working days must be between 0 (Sunday) and 6 (Saturday)")
+			}
+			if !seen[day] {
+				seen[day] = true
+				days = append(days, day)
+			}
+		}
+		sort.Ints(days)
+		config.WorkingDays = days
+	}
+
+	if req.Holidays != nil {
+		holidays := []string{}
+		for _, holiday := range req.Holidays {
+			if _, err := helpers.ParseDate(holiday); err != nil {
+				return nil, fmt.Errorf("invalid holiday date: %s", holiday)
+			}
+			holidays = append(holidays, holiday)
+		}
+		sort.Strings(holidays)
+		config.Holidays = holidays
+	}
+
+	if req.ShiftStart != "" {
+		config.ShiftStart = req.ShiftStart
+	}
+	if req.ShiftEnd != "" {
+		config.ShiftEnd = req.ShiftEnd
+	}
+	shiftHours, err := helpers.CalculateWorkHours(config.ShiftStart, config.ShiftEnd)
+	if err != nil {
+		return nil, errors.New("invalid shift time format, expected HH:MM:SS")
+	}
+	if shiftHours <= 0 {
+		return nil, errors.New("shift end must be after shift start")
+	}
+
+	if req.DefaultWorkHours != 0 {
+		if req.DefaultWorkHours < 0 || req.DefaultWorkHours > 24 {
+			return nil, errors.New("default work hours must be between 0 and 24")
+		}
+		config.DefaultWorkHours = req.DefaultWorkHours
+	}
+
+	if req.LateGraceMinutes != nil {
+		if *req.LateGraceMinutes < 0 || *req.LateGraceMinutes > 240 {
+			return nil, errors.New("late grace minutes must be between 0 and 240")
+		}
+		config.LateGraceMinutes = *req.LateGraceMinutes
+	}
+
+	if req.AutoClosePolicy != "" {
+		switch models.AutoClosePolicy(req.AutoClosePolicy) {
+		case models.AutoCloseShiftEnd, models.AutoCloseDefaultHours, models.AutoCloseAbsent:
+			config.AutoClosePolicy = models.AutoClosePolicy(req.AutoClosePolicy)
+		default:
+			return nil, errors.New("auto close policy must be shift_end, default_hours or absent")
+		}
+	}
+
+	if req.OfficeLocations != nil {
+		for _, location := range req.OfficeLocations {
+			if !helpers.ValidCoordinates(location.Latitude, location.Longitude) {
+				return nil, fmt.Errorf("invalid coordinates for office location %s", location.Name)
+			}
+			if location.RadiusMeters <= 0 {
+				return nil, fmt.Errorf("radius must be greater than zero for office location %s", location.Name)
+			}
+		}
+		config.OfficeLocations = req.OfficeLocations
+	}
+
+	if req.AllowedIPRanges != nil {
+		ranges := []string{}
+		for _, value := range req.AllowedIPRanges {
+			network, err := helpers.ParseIPRange(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid IP range: %s", value)
+			}
+			ranges = append(ranges, network.String())
+		}
+		config.AllowedIPRanges = ranges
+	}
+
+	if req.CheckInPolicy != "" {
+		switch models.CheckInPolicy(req.CheckInPolicy) {
+		case models.CheckInPolicyOff, models.CheckInPolicyReject, models.CheckInPolicyFlag:
+			config.CheckInPolicy = models.CheckInPolicy(req.CheckInPolicy)
+		default:
+			return nil, errors.New("check-in policy must be off, reject or flag")
+		}
+	}
+
+	var existing models.AttendanceConfiguration
+	err = configCollection.FindOne(ctx, bson.M{"company": companyID}).Decode(&existing)
+	if err == nil {
+		config.ID = existing.ID
+		config.CreatedAt = existing.CreatedAt
+		config.CreatedBy = existing.CreatedBy
+		config.UpdatedAt, config.UpdatedBy = helpers.SetUpdatedTimestamp(userID)
+		_, err = configCollection.ReplaceOne(ctx, bson.M{"_id": existing.ID}, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update attendance configuration: %w", err)
+		}
+	} else {
+		config.ID = primitive.NewObjectID()
+		config.CreatedAt, config.CreatedBy = helpers.SetCreatedTimestamp(userID)
+		config.UpdatedAt, config.UpdatedBy = helpers.SetUpdatedTimestamp(userID)
+		_, err = configCollection.InsertOne(ctx, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create attendance configuration: %w", err)
+		}
+	}
+
+	return &config, nil
+}
+
+// GetConfiguration retrieves the attendance configuration of a company, falling back to defaults
+func (s *AttendanceService) GetConfiguration(companyID primitive.ObjectID) (*models.AttendanceConfiguration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	config, err := loadAttendanceConfiguration(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// loadAttendanceConfiguration returns the saved configuration or the defaults when none exists
+func loadAttendanceConfiguration(ctx context.Context, companyID primitive.ObjectID) (models.AttendanceConfiguration, error) {
+	configCollection := databases.MongoDBDatabase.Collection(collections.AttendanceConfigurations)
+
+	var config models.AttendanceConfiguration
+	err := configCollection.FindOne(ctx, bson.M{"company": companyID}).Decode(&config)
+	if err == mongo.ErrNoDocuments {
+		return models.DefaultAttendanceConfiguration(companyID), nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("failed to load attendance configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+// isWorkingDay reports whether the date is a working day and not a holiday for the configuration
+func isWorkingDay(config *models.AttendanceConfiguration, date time.Time) bool {
+	dateStr := helpers.FormatDate(date)
+	for _, holiday := range config.Holidays {
+		if holiday == dateStr {
+			return false
+		}
+	}
+
+	weekday := int(date.Weekday())
+	for _, day := range config.WorkingDays {
+		if day == weekday {
+			return true
+		}
+	}
+
+	return false
+}
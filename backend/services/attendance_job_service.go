@@ -0,0 +1,391 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RerunAttendanceJobRequest for re-processing a single past date
+type RerunAttendanceJobRequest struct {
+	Date string `json:"date" validate:"required"` // YYYY-MM-DD
+}
+
+// RunDailyJob processes the given date for every active company (used by the scheduler)
+func (s *AttendanceService) RunDailyJob(date string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	companiesCollection := databases.MongoDBDatabase.Collection(collections.Companies)
+
+	cursor, err := companiesCollection.Find(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"is_active":   true,
+		"is_approved": true,
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to list companies: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var companies []models.Company
+	if err = cursor.All(ctx, &companies); err != nil {
+		return fmt.Errorf("failed to decode companies: %w", err)
+	}
+
+	for _, company := range companies {
+		if _, err := s.ProcessDay(company.ID, date, models.JobTriggerScheduled, primitive.NilObjectID); err != nil {
+			log.Printf("⚠️  Attendance job failed for company %s on %s: %v\n", company.ID.Hex(), date, err)
+		}
+	}
+
+	return nil
+}
+
+// RerunDailyJob re-processes a past date for one company on demand
+func (s *AttendanceService) RerunDailyJob(req *RerunAttendanceJobRequest, companyID, userID primitive.ObjectID) (*models.JobRun, error) {
+	if _, err := helpers.ParseDate(req.Date); err != nil {
+		return nil, errors.New("invalid date format")
+	}
+	if req.Date >= helpers.FormatDate(time.Now()) {
+		return nil, errors.New("only past dates can be processed")
+	}
+
+	return s.ProcessDay(companyID, req.Date, models.JobTriggerManual, userID)
+}
+
+// ProcessDay auto-closes open check-ins and marks absences for a company and date.
+// It is safe to run more than once for the same date.
+func (s *AttendanceService) ProcessDay(companyID primitive.ObjectID, date string, trigger models.JobTrigger, triggeredBy primitive.ObjectID) (*models.JobRun, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	runsCollection := databases.MongoDBDatabase.Collection(collections.JobRuns)
+
+	day, err := helpers.ParseDate(date)
+	if err != nil {
+		return nil, errors.New("invalid date format")
+	}
+
+	// Refuse to start while another run for the same date is still in progress
+	inProgress, err := runsCollection.CountDocuments(ctx, bson.M{
+		"job":        models.JobAttendanceDaily,
+		"company":    companyID,
+		"date":       date,
+		"status":     models.JobRunRunning,
+		"created_at": bson.M{"$gte": primitive.NewDateTimeFromTime(time.Now().Add(-10 * time.Minute))},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if inProgress > 0 {
+		return nil, errors.New("a run for this date is already in progress")
+	}
+
+	now := time.Now()
+	run := models.JobRun{
+		ID:          primitive.NewObjectID(),
+		Job:         models.JobAttendanceDaily,
+		Company:     companyID,
+		Date:        date,
+		Trigger:     trigger,
+		TriggeredBy: triggeredBy,
+		Status:      models.JobRunRunning,
+		StartedAt:   helpers.FormatDateTime(now),
+	}
+	run.CreatedAt, run.CreatedBy = helpers.SetCreatedTimestamp(triggeredBy)
+	run.UpdatedAt, run.UpdatedBy = helpers.SetUpdatedTimestamp(triggeredBy)
+
+	if _, err := runsCollection.InsertOne(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to record job run: %w", err)
+	}
+
+	stats, message, processErr := s.processAttendanceDay(ctx, companyID, day)
+
+	run.Stats = stats
+	run.Message = message
+	run.Status = models.JobRunCompleted
+	if processErr != nil {
+		run.Status = models.JobRunFailed
+		run.Error = processErr.Error()
+	}
+	run.FinishedAt = helpers.FormatDateTime(time.Now())
+	run.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	_, err = runsCollection.UpdateOne(ctx, bson.M{"_id": run.ID}, bson.M{
+		"$set": bson.M{
+			"status":      run.Status,
+			"stats":       run.Stats,
+			"message":     run.Message,
+			"error":       run.Error,
+			"finished_at": run.FinishedAt,
+			"updated_at":  run.UpdatedAt,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update job run: %w", err)
+	}
+
+	if processErr != nil {
+		return &run, processErr
+	}
+
+	return &run, nil
+}
+
+// ListJobRuns retrieves the attendance job history of a company
+func (s *AttendanceService) ListJobRuns(companyID primitive.ObjectID, date string, page, limit int64) ([]models.JobRun, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	runsCollection := databases.MongoDBDatabase.Collection(collections.JobRuns)
+
+	filter := bson.M{
+		"job":     models.JobAttendanceDaily,
+		"company": companyID,
+	}
+	if date != "" {
+		filter["date"] = date
+	}
+
+	total, err := runsCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skip := (page - 1) * limit
+	findOptions := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := runsCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	runs := []models.JobRun{}
+	if err = cursor.All(ctx, &runs); err != nil {
+		return nil, 0, err
+	}
+
+	return runs, total, nil
+}
+
+// processAttendanceDay does the actual work of a daily run and returns its counters
+func (s *AttendanceService) processAttendanceDay(ctx context.Context, companyID primitive.ObjectID, day time.Time) (map[string]int, string, error) {
+	stats := map[string]int{
+		"auto_closed":        0,
+		"marked_absent":      0,
+		"comp_off_requested": 0,
+	}
+
+	config, err := loadAttendanceConfiguration(ctx, companyID)
+	if err != nil {
+		return stats, "", err
+	}
+
+	closed, err := autoCloseOpenAttendance(ctx, &config, helpers.FormatDate(day))
+	stats["auto_closed"] = closed
+	if err != nil {
+		return stats, "", err
+	}
+
+	if !isWorkingDay(&config, day) {
+		requested, err := requestCompOffsForDay(ctx, &config, helpers.FormatDate(day))
+		stats["comp_off_requested"] = requested
+		if err != nil {
+			return stats, "", err
+		}
+		return stats, "non-working day, absences not marked", nil
+	}
+
+	marked, err := markAbsences(ctx, companyID, day)
+	stats["marked_absent"] = marked
+	if err != nil {
+		return stats, "", err
+	}
+
+	return stats, "", nil
+}
+
+// autoCloseOpenAttendance closes check-ins of the date that never got a check-out
+func autoCloseOpenAttendance(ctx context.Context, config *models.AttendanceConfiguration, date string) (int, error) {
+	attendanceCollection := databases.MongoDBDatabase.Collection(collections.Attendances)
+
+	openFilter := bson.M{
+		"company":  config.Company,
+		"date":     date,
+		"status":   bson.M{"$in": presentStatuses},
+		"check_in": bson.M{"$nin": []interface{}{nil, ""}},
+		"$or": []bson.M{
+			{"check_out": bson.M{"$exists": false}},
+			{"check_out": ""},
+		},
+	}
+
+	cursor, err := attendanceCollection.Find(ctx, openFilter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find open check-ins: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var open []models.Attendance
+	if err = cursor.All(ctx, &open); err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for _, attendance := range open {
+		update := bson.M{
+			"auto_closed": true,
+			"remarks":     "Auto-closed: no check-out recorded",
+			"updated_at":  primitive.NewDateTimeFromTime(time.Now()),
+		}
+
+		if config.AutoClosePolicy == models.AutoCloseAbsent {
+			// A half-day leave stays on record, only the worked half is lost
+			if attendance.Status != models.StatusHalfDay {
+				update["status"] = models.StatusAbsent
+			}
+			update["work_hours"] = 0
+		} else {
+			checkOut := autoCloseCheckOut(config, attendance.CheckIn)
+			workHours, err := helpers.CalculateWorkHours(attendance.CheckIn, checkOut)
+			if err != nil {
+				workHours = 0
+			}
+			update["check_out"] = checkOut
+			update["work_hours"] = workHours
+		}
+
+		// Re-apply the open filter so a late manual check-out is never overwritten
+		result, err := attendanceCollection.UpdateOne(ctx, bson.M{
+			"$and": []bson.M{{"_id": attendance.ID}, openFilter},
+		}, bson.M{"$set": update})
+		if err != nil {
+			return closed, fmt.Errorf("failed to auto-close attendance: %w", err)
+		}
+		closed += int(result.ModifiedCount)
+	}
+
+	return closed, nil
+}
+
+// autoCloseCheckOut picks the check-out time for an open check-in according to the policy
+func autoCloseCheckOut(config *models.AttendanceConfiguration, checkIn string) string {
+	if config.AutoClosePolicy == models.AutoCloseShiftEnd && checkIn < config.ShiftEnd {
+		return config.ShiftEnd
+	}
+
+	inTime, err := time.Parse("15:04:05", checkIn)
+	if err != nil {
+		return checkIn
+	}
+
+	outTime := inTime.Add(time.Duration(config.DefaultWorkHours * float64(time.Hour)))
+	endOfDay := time.Date(inTime.Year(), inTime.Month(), inTime.Day(), 23, 59, 59, 0, inTime.Location())
+	if outTime.After(endOfDay) {
+		outTime = endOfDay
+	}
+
+	return outTime.Format("15:04:05")
+}
+
+// markAbsences inserts an absent record for active employees with no attendance and no approved leave
+func markAbsences(ctx context.Context, companyID primitive.ObjectID, day time.Time) (int, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+	attendanceCollection := databases.MongoDBDatabase.Collection(collections.Attendances)
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	date := helpers.FormatDate(day)
+
+	// Admins and SuperAdmins do not mark attendance
+	cursor, err := usersCollection.Find(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"company": companyID,
+		"status":  models.UserActive,
+		"role":    bson.M{"$nin": []models.Role{models.RoleAdmin, models.RoleSuperAdmin}},
+	}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list employees: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var employees []models.User
+	if err = cursor.All(ctx, &employees); err != nil {
+		return 0, err
+	}
+
+	// Employees on approved leave are never absent; hourly leave still expects a check-in
+	onLeave := make(map[primitive.ObjectID]bool)
+	leaveCursor, err := leavesCollection.Find(ctx, bson.M{
+		"company":    companyID,
+		"status":     models.LeaveApproved,
+		"start_date": bson.M{"$lte": date},
+		"end_date":   bson.M{"$gte": date},
+		"duration":   bson.M{"$ne": models.LeaveHourly},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list leaves: %w", err)
+	}
+	defer leaveCursor.Close(ctx)
+
+	var leaves []models.Leave
+	if err = leaveCursor.All(ctx, &leaves); err != nil {
+		return 0, err
+	}
+	for _, leave := range leaves {
+		onLeave[leave.EmployeeID] = true
+	}
+
+	endOfDay := day.AddDate(0, 0, 1)
+	marked := 0
+	for _, employee := range employees {
+		if onLeave[employee.ID] {
+			continue
+		}
+
+		// Skip days before the employee joined
+		if employee.DateOfJoin != "" {
+			if employee.DateOfJoin > date {
+				continue
+			}
+		} else if employee.CreatedAt.Time().After(endOfDay) {
+			continue
+		}
+
+		now := primitive.NewDateTimeFromTime(time.Now())
+		result, err := attendanceCollection.UpdateOne(ctx,
+			bson.M{"employee_id": employee.ID, "date": date},
+			bson.M{"$setOnInsert": bson.M{
+				"employee_id": employee.ID,
+				"company":     companyID,
+				"date":        date,
+				"status":      models.StatusAbsent,
+				"remarks":     "Marked absent: no attendance recorded",
+				"created_at":  now,
+				"updated_at":  now,
+				"is_deleted":  false,
+			}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return marked, fmt.Errorf("failed to mark absence: %w", err)
+		}
+		if result.UpsertedCount > 0 {
+			marked++
+		}
+	}
+
+	return marked, nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+
+	"api.workzen.odoo/databases/models"
+)
+
+func TestAutoCloseCheckOut(t *testing.T) {
+	shiftEnd := &models.AttendanceConfiguration{AutoClosePolicy: models.AutoCloseShiftEnd, ShiftEnd: "18:00:00", DefaultWorkHours: 8}
+	defaultHours := &models.AttendanceConfiguration{AutoClosePolicy: models.AutoCloseDefaultHours, ShiftEnd: "18:00:00", DefaultWorkHours: 8}
+	halfHours := &models.AttendanceConfiguration{AutoClosePolicy: models.AutoCloseDefaultHours, DefaultWorkHours: 7.5}
+
+	tests := []struct {
+		name    string
+		config  *models.AttendanceConfiguration
+		checkIn string
+		want    string
+	}{
+		{"shift end after a morning check-in", shiftEnd, "09:15:00", "18:00:00"},
+		{"check-in after the shift end falls back to default hours", shiftEnd, "19:00:00", "23:59:59"},
+		{"late check-in before midnight is capped at the end of the day", shiftEnd, "18:30:00", "23:59:59"},
+		{"default hours", defaultHours, "09:15:00", "17:15:00"},
+		{"fractional default hours", halfHours, "08:00:00", "15:30:00"},
+		{"default hours capped at the end of the day", defaultHours, "20:00:00", "23:59:59"},
+		{"default hours ending exactly at midnight", defaultHours, "16:00:00", "23:59:59"},
+		{"unparsable check-in is kept", defaultHours, "9am", "9am"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := autoCloseCheckOut(tt.config, tt.checkIn); got != tt.want {
+				t.Errorf("autoCloseCheckOut(%q) = %q, want %q", tt.checkIn, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,670 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"sort"
+	"strings"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PunchInput is a single punch sent by a device or integration
+type PunchInput struct {
+	DeviceUserID string `json:"device_user_id" validate:"required"`
+	Timestamp    string `json:"timestamp" validate:"required"` // YYYY-MM-DD HH:MM:SS
+	DeviceID     string `json:"device_id"`
+}
+
+// IngestPunchesRequest for pushing punches from a device
+type IngestPunchesRequest struct {
+	DeviceID string       `json:"device_id"`
+	Punches  []PunchInput `json:"punches" validate:"required"`
+}
+
+// ResolvePunchErrorRequest for re-processing a queued punch with a corrected mapping
+type ResolvePunchErrorRequest struct {
+	EmployeeID string `json:"employee_id" validate:"required"` // encrypted
+	Timestamp  string `json:"timestamp"`                       // optional correction, YYYY-MM-DD HH:MM:SS
+}
+
+// PunchImportResult summarizes an ingestion or file import
+type PunchImportResult struct {
+	Received          int `json:"received"`
+	Imported          int `json:"imported"`
+	Duplicates        int `json:"duplicates"`
+	Queued            int `json:"queued"`
+	AttendanceUpdated int `json:"attendance_updated"`
+}
+
+// rawPunch is a punch before validation, with the original line kept for the error queue
+type rawPunch struct {
+	DeviceUserID string
+	DeviceID     string
+	Timestamp    string
+	RawLine      string
+	EmployeeID   primitive.ObjectID // set when resolving a queued punch
+}
+
+// punchTimestampLayouts are the timestamp formats accepted from devices
+var punchTimestampLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006/01/02 15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04",
+}
+
+// IngestPunches stores punches pushed by a device and folds them into attendance
+func (s *AttendanceService) IngestPunches(req *IngestPunchesRequest, companyID, userID primitive.ObjectID) (*PunchImportResult, error) {
+	if len(req.Punches) == 0 {
+		return nil, errors.New("no punches provided")
+	}
+
+	punches := make([]rawPunch, 0, len(req.Punches))
+	for _, punch := range req.Punches {
+		deviceID := punch.DeviceID
+		if deviceID == "" {
+			deviceID = req.DeviceID
+		}
+		punches = append(punches, rawPunch{
+			DeviceUserID: strings.TrimSpace(punch.DeviceUserID),
+			DeviceID:     deviceID,
+			Timestamp:    strings.TrimSpace(punch.Timestamp),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	return ingestPunches(ctx, punches, models.PunchSourceAPI, companyID, userID)
+}
+
+// ImportPunchFile imports a CSV or ZKTeco attendance log exported from a device
+func (s *AttendanceService) ImportPunchFile(file *multipart.FileHeader, format, deviceID string, companyID, userID primitive.ObjectID) (*PunchImportResult, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, errors.New("failed to open uploaded file")
+	}
+	defer src.Close()
+
+	var punches []rawPunch
+	var source models.PunchSource
+	switch models.PunchSource(format) {
+	case models.PunchSourceCSV:
+		source = models.PunchSourceCSV
+		punches, err = parsePunchCSV(src)
+	case models.PunchSourceZKTeco:
+		source = models.PunchSourceZKTeco
+		punches, err = parseZKTecoLog(src)
+	default:
+		return nil, errors.New("format must be csv or zkteco")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(punches) == 0 {
+		return nil, errors.New("no punches found in file")
+	}
+
+	for i := range punches {
+		if punches[i].DeviceID == "" {
+			punches[i].DeviceID = deviceID
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	return ingestPunches(ctx, punches, source, companyID, userID)
+}
+
+// ListPunchErrors retrieves queued punches that could not be imported
+func (s *AttendanceService) ListPunchErrors(companyID primitive.ObjectID, status string, page, limit int64) ([]models.PunchError, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	errorsCollection := databases.MongoDBDatabase.Collection(collections.PunchErrors)
+
+	filter := bson.M{"company": companyID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	total, err := errorsCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skip := (page - 1) * limit
+	findOptions := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := errorsCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	punchErrors := []models.PunchError{}
+	if err = cursor.All(ctx, &punchErrors); err != nil {
+		return nil, 0, err
+	}
+
+	return punchErrors, total, nil
+}
+
+// ResolvePunchError imports a queued punch for the given employee, optionally with a corrected timestamp
+func (s *AttendanceService) ResolvePunchError(errorID, employeeID, companyID, userID primitive.ObjectID, req *ResolvePunchErrorRequest) (*PunchImportResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	errorsCollection := databases.MongoDBDatabase.Collection(collections.PunchErrors)
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	var punchError models.PunchError
+	err := errorsCollection.FindOne(ctx, bson.M{"_id": errorID, "company": companyID}).Decode(&punchError)
+	if err != nil {
+		return nil, errors.New("punch error not found")
+	}
+	if punchError.Status != models.PunchErrorOpen {
+		return nil, errors.New("punch error is already closed")
+	}
+
+	var employee models.User
+	err = usersCollection.FindOne(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"_id":     employeeID,
+		"company": companyID,
+	})).Decode(&employee)
+	if err != nil {
+		return nil, errors.New("employee not found")
+	}
+
+	timestamp := punchError.Timestamp
+	if req.Timestamp != "" {
+		timestamp = req.Timestamp
+	}
+	if _, ok := parsePunchTimestamp(timestamp); !ok {
+		return nil, errors.New("invalid timestamp, expected YYYY-MM-DD HH:MM:SS")
+	}
+
+	punch := rawPunch{
+		DeviceUserID: punchError.DeviceUserID,
+		DeviceID:     punchError.DeviceID,
+		Timestamp:    timestamp,
+		RawLine:      punchError.RawLine,
+		EmployeeID:   employee.ID,
+	}
+	if punch.DeviceUserID == "" {
+		punch.DeviceUserID = employee.EmployeeCode
+	}
+
+	result, punchIDs, err := processPunches(ctx, []rawPunch{punch}, punchError.Source, companyID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewedAt, reviewedBy := helpers.SetUpdatedTimestamp(userID)
+	update := bson.M{
+		"status":      models.PunchErrorResolved,
+		"reviewed_by": reviewedBy,
+		"reviewed_at": helpers.FormatDateTime(time.Now()),
+		"updated_at":  reviewedAt,
+		"updated_by":  reviewedBy,
+	}
+	if len(punchIDs) > 0 {
+		update["punch_id"] = punchIDs[0]
+	}
+
+	_, err = errorsCollection.UpdateOne(ctx, bson.M{"_id": errorID}, bson.M{"$set": update})
+	if err != nil {
+		return nil, errors.New("failed to resolve punch error")
+	}
+
+	return result, nil
+}
+
+// DismissPunchError closes a queued punch without importing it
+func (s *AttendanceService) DismissPunchError(errorID, companyID, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	errorsCollection := databases.MongoDBDatabase.Collection(collections.PunchErrors)
+
+	reviewedAt, reviewedBy := helpers.SetUpdatedTimestamp(userID)
+	result, err := errorsCollection.UpdateOne(ctx,
+		bson.M{"_id": errorID, "company": companyID, "status": models.PunchErrorOpen},
+		bson.M{"$set": bson.M{
+			"status":      models.PunchErrorDismissed,
+			"reviewed_by": reviewedBy,
+			"reviewed_at": helpers.FormatDateTime(time.Now()),
+			"updated_at":  reviewedAt,
+			"updated_by":  reviewedBy,
+		}},
+	)
+	if err != nil {
+		return errors.New("failed to dismiss punch error")
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("open punch error not found")
+	}
+
+	return nil
+}
+
+// ingestPunches processes a batch and queues every punch that cannot be imported
+func ingestPunches(ctx context.Context, punches []rawPunch, source models.PunchSource, companyID, userID primitive.ObjectID) (*PunchImportResult, error) {
+	result, _, err := processPunches(ctx, punches, source, companyID, userID, true)
+	return result, err
+}
+
+// processPunches validates, deduplicates and stores punches, then folds them into attendance.
+// Rejected punches are queued when queueErrors is set, otherwise the first rejection is returned.
+func processPunches(ctx context.Context, punches []rawPunch, source models.PunchSource, companyID, userID primitive.ObjectID, queueErrors bool) (*PunchImportResult, []primitive.ObjectID, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+	punchesCollection := databases.MongoDBDatabase.Collection(collections.AttendancePunches)
+	errorsCollection := databases.MongoDBDatabase.Collection(collections.PunchErrors)
+
+	result := &PunchImportResult{Received: len(punches)}
+
+	// Map device user IDs to employees through their employee code
+	cursor, err := usersCollection.Find(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"company":       companyID,
+		"employee_code": bson.M{"$nin": []interface{}{nil, ""}},
+	}))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load employees: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var employees []models.User
+	if err = cursor.All(ctx, &employees); err != nil {
+		return nil, nil, err
+	}
+
+	byCode := make(map[string]primitive.ObjectID)
+	for _, employee := range employees {
+		byCode[strings.TrimSpace(employee.EmployeeCode)] = employee.ID
+	}
+
+	var punchErrors []interface{}
+	reject := func(punch rawPunch, reason string) error {
+		if !queueErrors {
+			return errors.New(reason)
+		}
+		punchError := models.PunchError{
+			ID:           primitive.NewObjectID(),
+			Company:      companyID,
+			DeviceUserID: punch.DeviceUserID,
+			DeviceID:     punch.DeviceID,
+			Timestamp:    punch.Timestamp,
+			RawLine:      punch.RawLine,
+			Source:       source,
+			Reason:       reason,
+			Status:       models.PunchErrorOpen,
+		}
+		punchError.CreatedAt, punchError.CreatedBy = helpers.SetCreatedTimestamp(userID)
+		punchError.UpdatedAt, punchError.UpdatedBy = helpers.SetUpdatedTimestamp(userID)
+		punchErrors = append(punchErrors, punchError)
+		result.Queued++
+		return nil
+	}
+
+	now := time.Now()
+	affected := make(map[primitive.ObjectID]map[string]bool)
+	var punchIDs []primitive.ObjectID
+
+	for _, punch := range punches {
+		if punch.Timestamp == "" {
+			if err := reject(punch, "missing timestamp"); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		at, ok := parsePunchTimestamp(punch.Timestamp)
+		if !ok {
+			if err := reject(punch, "invalid timestamp"); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		if at.After(now.Add(5 * time.Minute)) {
+			if err := reject(punch, "timestamp is in the future"); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		employeeID := punch.EmployeeID
+		if employeeID.IsZero() {
+			if punch.DeviceUserID == "" {
+				if err := reject(punch, "missing device user ID"); err != nil {
+					return nil, nil, err
+				}
+				continue
+			}
+			employeeID, ok = byCode[punch.DeviceUserID]
+			if !ok {
+				if err := reject(punch, "no employee with this employee code"); err != nil {
+					return nil, nil, err
+				}
+				continue
+			}
+		}
+
+		timestamp := helpers.FormatDateTime(at)
+		date := helpers.FormatDate(at)
+		createdAt, createdBy := helpers.SetCreatedTimestamp(userID)
+
+		// Deduplicate on employee + exact timestamp
+		upsert, err := punchesCollection.UpdateOne(ctx,
+			bson.M{"company": companyID, "employee_id": employeeID, "timestamp": timestamp},
+			bson.M{"$setOnInsert": bson.M{
+				"company":        companyID,
+				"employee_id":    employeeID,
+				"device_user_id": punch.DeviceUserID,
+				"device_id":      punch.DeviceID,
+				"timestamp":      timestamp,
+				"date":           date,
+				"time":           at.Format("15:04:05"),
+				"source":         source,
+				"created_at":     createdAt,
+				"created_by":     createdBy,
+				"updated_at":     createdAt,
+				"updated_by":     createdBy,
+				"is_deleted":     false,
+			}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to store punch: %w", err)
+		}
+		if upsert.UpsertedCount == 0 {
+			result.Duplicates++
+			continue
+		}
+		if id, ok := upsert.UpsertedID.(primitive.ObjectID); ok {
+			punchIDs = append(punchIDs, id)
+		}
+
+		result.Imported++
+		if affected[employeeID] == nil {
+			affected[employeeID] = make(map[string]bool)
+		}
+		affected[employeeID][date] = true
+	}
+
+	if len(punchErrors) > 0 {
+		if _, err := errorsCollection.InsertMany(ctx, punchErrors); err != nil {
+			return nil, nil, fmt.Errorf("failed to queue punch errors: %w", err)
+		}
+	}
+
+	for employeeID, dates := range affected {
+		for date := range dates {
+			updated, err := foldPunchesIntoAttendance(ctx, employeeID, companyID, date)
+			if err != nil {
+				return nil, nil, err
+			}
+			if updated {
+				result.AttendanceUpdated++
+			}
+		}
+	}
+
+	return result, punchIDs, nil
+}
+
+// foldPunchesIntoAttendance derives check-in and check-out for a day from its first and last punch.
+// Leave days and regularized records are left untouched.
+func foldPunchesIntoAttendance(ctx context.Context, employeeID, companyID primitive.ObjectID, date string) (bool, error) {
+	punchesCollection := databases.MongoDBDatabase.Collection(collections.AttendancePunches)
+	attendanceCollection := databases.MongoDBDatabase.Collection(collections.Attendances)
+
+	cursor, err := punchesCollection.Find(ctx, bson.M{
+		"company":     companyID,
+		"employee_id": employeeID,
+		"date":        date,
+	}, options.Find().SetSort(bson.D{{Key: "time", Value: 1}}))
+	if err != nil {
+		return false, fmt.Errorf("failed to load punches: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var punches []models.AttendancePunch
+	if err = cursor.All(ctx, &punches); err != nil {
+		return false, err
+	}
+	if len(punches) == 0 {
+		return false, nil
+	}
+
+	times := make([]string, 0, len(punches))
+	for _, punch := range punches {
+		times = append(times, punch.Time)
+	}
+	sort.Strings(times)
+
+	checkIn := times[0]
+	checkOut := ""
+	if len(times) > 1 {
+		checkOut = times[len(times)-1]
+	}
+
+	now := primitive.NewDateTimeFromTime(time.Now())
+
+	// Create the day from the punches unless a record exists; the unique index on employee and
+	// date makes concurrent imports of the same day land on one record
+	attendance := models.Attendance{
+		ID:         primitive.NewObjectID(),
+		EmployeeID: employeeID,
+		Company:    companyID,
+		Date:       date,
+		CheckIn:    checkIn,
+		CheckOut:   checkOut,
+		Status:     models.StatusPresent,
+		Source:     models.AttendanceSourceDevice,
+	}
+	if checkOut != "" {
+		attendance.WorkHours, _ = helpers.CalculateWorkHours(checkIn, checkOut)
+	}
+	attendance.CreatedAt = now
+	attendance.UpdatedAt = now
+
+	upsert, err := attendanceCollection.UpdateOne(ctx,
+		bson.M{"employee_id": employeeID, "date": date},
+		bson.M{"$setOnInsert": attendance},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create attendance: %w", err)
+	}
+	if upsert.UpsertedCount > 0 {
+		return true, nil
+	}
+
+	var existing models.Attendance
+	err = attendanceCollection.FindOne(ctx, bson.M{
+		"employee_id": employeeID,
+		"date":        date,
+	}).Decode(&existing)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch attendance: %w", err)
+	}
+
+	if existing.Status == models.StatusOnLeave || !existing.RegularizationID.IsZero() {
+		return false, nil
+	}
+
+	// Merge with a web check-in of the same day, keeping the widest span
+	if existing.Status != models.StatusAbsent {
+		if existing.CheckIn != "" && existing.CheckIn < checkIn {
+			checkIn = existing.CheckIn
+		}
+		if existing.CheckOut != "" && existing.CheckOut > checkOut && !existing.AutoClosed {
+			checkOut = existing.CheckOut
+		}
+	}
+
+	status := existing.Status
+	if status == models.StatusAbsent || status == "" {
+		status = models.StatusPresent
+	}
+
+	update := bson.M{
+		"check_in":   checkIn,
+		"status":     status,
+		"source":     models.AttendanceSourceDevice,
+		"updated_at": now,
+	}
+	if checkOut != "" && checkOut > checkIn {
+		workHours, _ := helpers.CalculateWorkHours(checkIn, checkOut)
+		update["check_out"] = checkOut
+		update["work_hours"] = workHours
+		update["auto_closed"] = false
+	}
+
+	_, err = attendanceCollection.UpdateOne(ctx, bson.M{"_id": existing.ID}, bson.M{"$set": update})
+	if err != nil {
+		return false, fmt.Errorf("failed to update attendance: %w", err)
+	}
+
+	return true, nil
+}
+
+// parsePunchTimestamp parses a device timestamp in any of the accepted layouts (server local time)
+func parsePunchTimestamp(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range punchTimestampLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parsePunchCSV reads "device_user_id,timestamp[,device_id]" rows, with an optional header row.
+// A header may also split the timestamp into separate date and time columns.
+func parsePunchCSV(src io.Reader) ([]rawPunch, error) {
+	reader := csv.NewReader(src)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV file: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{"user": 0, "timestamp": 1, "date": -1, "time": -1, "device": 2}
+	start := 0
+
+	// Detect a header row when the second column is not a timestamp
+	if len(rows[0]) > 1 {
+		if _, ok := parsePunchTimestamp(rows[0][1]); !ok {
+			columns = map[string]int{"user": -1, "timestamp": -1, "date": -1, "time": -1, "device": -1}
+			for i, name := range rows[0] {
+				switch strings.ToLower(strings.TrimSpace(name)) {
+				case "device_user_id", "user_id", "employee_code", "emp_code", "id":
+					columns["user"] = i
+				case "timestamp", "datetime", "punch_time":
+					columns["timestamp"] = i
+				case "date":
+					columns["date"] = i
+				case "time":
+					columns["time"] = i
+				case "device_id", "device", "terminal":
+					columns["device"] = i
+				}
+			}
+			if columns["user"] < 0 || (columns["timestamp"] < 0 && (columns["date"] < 0 || columns["time"] < 0)) {
+				return nil, errors.New("CSV header must contain device_user_id and timestamp (or date and time) columns")
+			}
+			start = 1
+		}
+	}
+
+	field := func(row []string, key string) string {
+		i := columns[key]
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var punches []rawPunch
+	for _, row := range rows[start:] {
+		if len(row) == 0 || (len(row) == 1 && strings.TrimSpace(row[0]) == "") {
+			continue
+		}
+
+		timestamp := field(row, "timestamp")
+		if timestamp == "" && columns["date"] >= 0 {
+			timestamp = strings.TrimSpace(field(row, "date") + " " + field(row, "time"))
+		}
+
+		punches = append(punches, rawPunch{
+			DeviceUserID: field(row, "user"),
+			DeviceID:     field(row, "device"),
+			Timestamp:    timestamp,
+			RawLine:      strings.Join(row, ","),
+		})
+	}
+
+	return punches, nil
+}
+
+// parseZKTecoLog reads a ZKTeco-style attendance log (attlog.dat):
+// tab separated "user_id, YYYY-MM-DD HH:MM:SS, verify, state, workcode, reserved"
+func parseZKTecoLog(src io.Reader) ([]rawPunch, error) {
+	scanner := bufio.NewScanner(src)
+
+	var punches []rawPunch
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		punch := rawPunch{RawLine: line}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) >= 2 {
+			punch.DeviceUserID = strings.TrimSpace(fields[0])
+			punch.Timestamp = strings.TrimSpace(fields[1])
+		} else {
+			// Some exports use spaces instead of tabs, splitting date and time
+			fields = strings.Fields(line)
+			if len(fields) >= 3 {
+				punch.DeviceUserID = fields[0]
+				punch.Timestamp = fields[1] + " " + fields[2]
+			} else if len(fields) > 0 {
+				punch.DeviceUserID = fields[0]
+			}
+		}
+
+		punches = append(punches, punch)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read attendance log: %w", err)
+	}
+
+	return punches, nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withoutRaw drops the raw lines, which the tests compare separately where they matter
+func withoutRaw(punches []rawPunch) []rawPunch {
+	out := make([]rawPunch, len(punches))
+	for i, punch := range punches {
+		punch.RawLine = ""
+		out[i] = punch
+	}
+	return out
+}
+
+func TestParsePunchCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []rawPunch
+		wantErr string
+	}{
+		{
+			name:  "no header",
+			input: "101,2025-03-10 09:02:11,GATE-1\n102,2025-03-10 09:05:00\n",
+			want: []rawPunch{
+				{DeviceUserID: "101", DeviceID: "GATE-1", Timestamp: "2025-03-10 09:02:11"},
+				{DeviceUserID: "102", Timestamp: "2025-03-10 09:05:00"},
+			},
+		},
+		{
+			name:  "header with reordered columns",
+			input: "Terminal,Punch_Time,Emp_Code\nGATE-2,2025-03-10 18:01:00,E-7\n",
+			want: []rawPunch{
+				{DeviceUserID: "E-7", DeviceID: "GATE-2", Timestamp: "2025-03-10 18:01:00"},
+			},
+		},
+		{
+			name:  "header with separate date and time",
+			input: "user_id,date,time\n101,2025-03-10,09:02\n",
+			want: []rawPunch{
+				{DeviceUserID: "101", Timestamp: "2025-03-10 09:02"},
+			},
+		},
+		{
+			name:  "blank lines and padding",
+			input: "101, 2025-03-10 09:02:11 \n\n 102 ,2025-03-10 09:05:00\n",
+			want: []rawPunch{
+				{DeviceUserID: "101", Timestamp: "2025-03-10 09:02:11"},
+				{DeviceUserID: "102", Timestamp: "2025-03-10 09:05:00"},
+			},
+		},
+		{
+			name:  "short rows are kept for the error queue",
+			input: "101,2025-03-10 09:02:11\n103\n",
+			want: []rawPunch{
+				{DeviceUserID: "101", Timestamp: "2025-03-10 09:02:11"},
+				{DeviceUserID: "103"},
+			},
+		},
+		{
+			name:  "empty file",
+			input: "",
+			want:  []rawPunch{},
+		},
+		{
+			name:    "header without a user column",
+			input:   "name,timestamp\nJane,2025-03-10 09:02:11\n",
+			wantErr: "must contain device_user_id",
+		},
+		{
+			name:    "header with a date but no time",
+			input:   "user_id,date\n101,2025-03-10\n",
+			wantErr: "must contain device_user_id",
+		},
+		{
+			name:    "malformed quoting",
+			input:   "101,\"2025-03-10 09:02:11\n",
+			wantErr: "invalid CSV file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			punches, err := parsePunchCSV(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := withoutRaw(punches); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePunchCSVRawLine(t *testing.T) {
+	punches, err := parsePunchCSV(strings.NewReader("101,2025-03-10 09:02:11,GATE-1\n"))
+	if err != nil || len(punches) != 1 {
+		t.Fatalf("got %v, %v", punches, err)
+	}
+	if punches[0].RawLine != "101,2025-03-10 09:02:11,GATE-1" {
+		t.Errorf("RawLine = %q", punches[0].RawLine)
+	}
+}
+
+func TestParseZKTecoLog(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []rawPunch
+	}{
+		{
+			name:  "tab separated attlog",
+			input: "  101\t2025-03-10 09:02:11\t1\t0\t0\t0\r\n102\t2025-03-10 18:30:45\t1\t1\t0\t0\r\n",
+			want: []rawPunch{
+				{DeviceUserID: "101", Timestamp: "2025-03-10 09:02:11", RawLine: "  101\t2025-03-10 09:02:11\t1\t0\t0\t0"},
+				{DeviceUserID: "102", Timestamp: "2025-03-10 18:30:45", RawLine: "102\t2025-03-10 18:30:45\t1\t1\t0\t0"},
+			},
+		},
+		{
+			name:  "space separated export",
+			input: "101 2025-03-10 09:02:11 1 0\n",
+			want: []rawPunch{
+				{DeviceUserID: "101", Timestamp: "2025-03-10 09:02:11", RawLine: "101 2025-03-10 09:02:11 1 0"},
+			},
+		},
+		{
+			name:  "incomplete lines are kept for the error queue",
+			input: "101\n\n   \n",
+			want: []rawPunch{
+				{DeviceUserID: "101", RawLine: "101"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			punches, err := parseZKTecoLog(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(punches, tt.want) {
+				t.Errorf("got %+v, want %+v", punches, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePunchTimestamp(t *testing.T) {
+	want := time.Date(2025, time.March, 10, 9, 2, 11, 0, time.Local)
+
+	tests := []struct {
+		value  string
+		want   time.Time
+		wantOK bool
+	}{
+		{"2025-03-10 09:02:11", want, true},
+		{"2025-03-10T09:02:11", want, true},
+		{"2025/03/10 09:02:11", want, true},
+		{" 2025-03-10 09:02:11 ", want, true},
+		{"2025-03-10 09:02", want.Add(-11 * time.Second), true},
+		{"2025/03/10 09:02", want.Add(-11 * time.Second), true},
+		{"10-03-2025 09:02:11", time.Time{}, false},
+		{"2025-03-10", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parsePunchTimestamp(tt.value)
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("parsePunchTimestamp(%q) = (%v, %v), want (%v, %v)", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
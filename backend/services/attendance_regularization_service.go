@@ -0,0 +1,397 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RegularizationRequest for proposing a correction to a past attendance record
+type RegularizationRequest struct {
+	Date     string `json:"date" validate:"required"` // YYYY-MM-DD
+	CheckIn  string `json:"check_in"`                 // HH:MM:SS
+	CheckOut string `json:"check_out"`                // HH:MM:SS
+	Status   string `json:"status"`                   // present | absent (defaults to present)
+	Reason   string `json:"reason" validate:"required"`
+}
+
+// ReviewRegularizationRequest for approving or rejecting a regularization
+type ReviewRegularizationRequest struct {
+	Remarks string `json:"remarks"`
+}
+
+// RequestRegularization creates a pending regularization request for a past date
+func (s *AttendanceService) RequestRegularization(req *RegularizationRequest, employeeID, companyID primitive.ObjectID) (*models.AttendanceRegularization, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	regularizationCollection := databases.MongoDBDatabase.Collection(collections.AttendanceRegularizations)
+	attendanceCollection := databases.MongoDBDatabase.Collection(collections.Attendances)
+
+	if _, err := helpers.ParseDate(req.Date); err != nil {
+		return nil, errors.New("invalid date format")
+	}
+	if req.Date >= helpers.FormatDate(time.Now()) {
+		return nil, errors.New("regularization is only allowed for past dates")
+	}
+
+	if req.Reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	status := models.AttendanceStatus(req.Status)
+	if status == "" {
+		status = models.StatusPresent
+	}
+
+	switch status {
+	case models.StatusPresent:
+		if req.CheckIn == "" {
+			return nil, errors.New("check-in time is required")
+		}
+		if _, err := time.Parse("15:04:05", req.CheckIn); err != nil {
+			return nil, errors.New("invalid check-in time format, expected HH:MM:SS")
+		}
+		if req.CheckOut != "" {
+			workHours, err := helpers.CalculateWorkHours(req.CheckIn, req.CheckOut)
+			if err != nil {
+				return nil, errors.New("invalid check-out time format, expected HH:MM:SS")
+			}
+			if workHours <= 0 {
+				return nil, errors.New("check-out time must be after check-in time")
+			}
+		}
+	case models.StatusAbsent:
+		req.CheckIn = ""
+		req.CheckOut = ""
+	default:
+		return nil, errors.New("status must be present or absent")
+	}
+
+	// Leave days are corrected through the leave workflow
+	var existing models.Attendance
+	err := attendanceCollection.FindOne(ctx, bson.M{
+		"employee_id": employeeID,
+		"date":        req.Date,
+	}).Decode(&existing)
+	if err == nil && existing.Status == models.StatusOnLeave {
+		return nil, errors.New("attendance for this date is an approved leave and cannot be regularized")
+	}
+
+	// Only one open request per date
+	count, err := regularizationCollection.CountDocuments(ctx, bson.M{
+		"employee_id": employeeID,
+		"date":        req.Date,
+		"status":      models.RegularizationPending,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("a regularization request for this date is already pending")
+	}
+
+	regularization := models.AttendanceRegularization{
+		ID:                primitive.NewObjectID(),
+		EmployeeID:        employeeID,
+		Company:           companyID,
+		Date:              req.Date,
+		RequestedCheckIn:  req.CheckIn,
+		RequestedCheckOut: req.CheckOut,
+		RequestedStatus:   status,
+		Reason:            req.Reason,
+		Status:            models.RegularizationPending,
+	}
+	if !existing.ID.IsZero() {
+		regularization.AttendanceID = existing.ID
+	}
+	regularization.CreatedAt, regularization.CreatedBy = helpers.SetCreatedTimestamp(employeeID)
+	regularization.UpdatedAt, regularization.UpdatedBy = helpers.SetUpdatedTimestamp(employeeID)
+
+	_, err = regularizationCollection.InsertOne(ctx, regularization)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create regularization request: %w", err)
+	}
+
+	return &regularization, nil
+}
+
+// ListRegularizations retrieves regularization requests visible to the caller.
+// HR and Admin see the whole company, everyone else sees their own and their direct reports' requests.
+func (s *AttendanceService) ListRegularizations(viewer *models.User, status string, page, limit int64) ([]models.AttendanceRegularization, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	regularizationCollection := databases.MongoDBDatabase.Collection(collections.AttendanceRegularizations)
+
+	filter := bson.M{"company": viewer.Company}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	if !can(viewer, models.PermAttendanceViewAll) {
+		employeeIDs, err := directReportIDs(ctx, viewer)
+		if err != nil {
+			return nil, 0, err
+		}
+		filter["employee_id"] = bson.M{"$in": append(employeeIDs, viewer.ID)}
+	}
+
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := regularizationCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var regularizations []models.AttendanceRegularization
+	if err = cursor.All(ctx, &regularizations); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := regularizationCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return regularizations, total, nil
+}
+
+// ApproveRegularization applies the requested correction to the attendance record,
+// keeping the original values on both the request and the attendance record
+func (s *AttendanceService) ApproveRegularization(regularizationID primitive.ObjectID, reviewer *models.User, req *ReviewRegularizationRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	regularizationCollection := databases.MongoDBDatabase.Collection(collections.AttendanceRegularizations)
+	attendanceCollection := databases.MongoDBDatabase.Collection(collections.Attendances)
+
+	regularization, err := s.getReviewableRegularization(ctx, regularizationID, reviewer)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	// Snapshot the current record before touching it
+	var attendance models.Attendance
+	err = attendanceCollection.FindOne(ctx, bson.M{
+		"employee_id": regularization.EmployeeID,
+		"date":        regularization.Date,
+	}).Decode(&attendance)
+	exists := err == nil
+
+	if exists && attendance.Status == models.StatusOnLeave {
+		return errors.New("attendance for this date is an approved leave and cannot be regularized")
+	}
+
+	original := &models.AttendanceSnapshot{Exists: exists}
+	if exists {
+		original.CheckIn = attendance.CheckIn
+		original.CheckOut = attendance.CheckOut
+		original.Status = attendance.Status
+		original.WorkHours = attendance.WorkHours
+	}
+
+	var workHours float64
+	if regularization.RequestedStatus == models.StatusPresent && regularization.RequestedCheckOut != "" {
+		workHours, _ = helpers.CalculateWorkHours(regularization.RequestedCheckIn, regularization.RequestedCheckOut)
+	}
+
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(reviewer.ID)
+
+	if exists {
+		// Only the worked half of a half-day leave is corrected
+		status := regularization.RequestedStatus
+		if attendance.Status == models.StatusHalfDay {
+			status = models.StatusHalfDay
+		}
+
+		set := bson.M{
+			"status":            status,
+			"work_hours":        workHours,
+			"regularization_id": regularization.ID,
+			"remarks":           "Regularized: " + regularization.Reason,
+			"updated_at":        updatedAt,
+			"updated_by":        updatedBy,
+		}
+		// Keep the values from before the first correction
+		if attendance.Original == nil {
+			set["original"] = original
+		}
+
+		update := bson.M{"$set": set}
+		if regularization.RequestedStatus == models.StatusPresent {
+			set["check_in"] = regularization.RequestedCheckIn
+			if regularization.RequestedCheckOut != "" {
+				set["check_out"] = regularization.RequestedCheckOut
+			} else {
+				update["$unset"] = bson.M{"check_out": ""}
+			}
+		} else {
+			update["$unset"] = bson.M{"check_in": "", "check_out": ""}
+		}
+
+		result, err := attendanceCollection.UpdateOne(ctx, bson.M{"_id": attendance.ID}, update)
+		if err != nil || result.MatchedCount == 0 {
+			return errors.New("failed to update attendance")
+		}
+	} else {
+		attendance = models.Attendance{
+			ID:               primitive.NewObjectID(),
+			EmployeeID:       regularization.EmployeeID,
+			Company:          regularization.Company,
+			Date:             regularization.Date,
+			CheckIn:          regularization.RequestedCheckIn,
+			CheckOut:         regularization.RequestedCheckOut,
+			Status:           regularization.RequestedStatus,
+			WorkHours:        workHours,
+			Remarks:          "Regularized: " + regularization.Reason,
+			RegularizationID: regularization.ID,
+			Original:         original,
+		}
+		attendance.CreatedAt, attendance.CreatedBy = helpers.SetCreatedTimestamp(reviewer.ID)
+		attendance.UpdatedAt, attendance.UpdatedBy = updatedAt, updatedBy
+
+		if _, err := attendanceCollection.InsertOne(ctx, attendance); err != nil {
+			return fmt.Errorf("failed to create attendance: %w", err)
+		}
+	}
+
+	result, err := regularizationCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": regularization.ID, "status": models.RegularizationPending},
+		bson.M{
+			"$set": bson.M{
+				"status":         models.RegularizationApproved,
+				"attendance_id":  attendance.ID,
+				"original":       original,
+				"reviewed_by":    reviewer.ID,
+				"reviewed_at":    helpers.FormatDateTime(now),
+				"review_remarks": req.Remarks,
+				"updated_at":     updatedAt,
+				"updated_by":     updatedBy,
+			},
+		},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		return errors.New("failed to approve regularization")
+	}
+
+	return nil
+}
+
+// RejectRegularization rejects a pending regularization request
+func (s *AttendanceService) RejectRegularization(regularizationID primitive.ObjectID, reviewer *models.User, req *ReviewRegularizationRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	regularizationCollection := databases.MongoDBDatabase.Collection(collections.AttendanceRegularizations)
+
+	regularization, err := s.getReviewableRegularization(ctx, regularizationID, reviewer)
+	if err != nil {
+		return err
+	}
+
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(reviewer.ID)
+
+	result, err := regularizationCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": regularization.ID, "status": models.RegularizationPending},
+		bson.M{
+			"$set": bson.M{
+				"status":         models.RegularizationRejected,
+				"reviewed_by":    reviewer.ID,
+				"reviewed_at":    helpers.FormatDateTime(time.Now()),
+				"review_remarks": req.Remarks,
+				"updated_at":     updatedAt,
+				"updated_by":     updatedBy,
+			},
+		},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		return errors.New("failed to reject regularization")
+	}
+
+	return nil
+}
+
+// getReviewableRegularization loads a pending request and checks that the reviewer
+// is HR/Admin or the employee's reporting manager
+func (s *AttendanceService) getReviewableRegularization(ctx context.Context, regularizationID primitive.ObjectID, reviewer *models.User) (*models.AttendanceRegularization, error) {
+	regularizationCollection := databases.MongoDBDatabase.Collection(collections.AttendanceRegularizations)
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	var regularization models.AttendanceRegularization
+	err := regularizationCollection.FindOne(ctx, bson.M{
+		"_id":     regularizationID,
+		"company": reviewer.Company,
+	}).Decode(&regularization)
+	if err != nil {
+		return nil, errors.New("regularization request not found")
+	}
+
+	if regularization.Status != models.RegularizationPending {
+		return nil, errors.New("regularization request is not pending")
+	}
+
+	if regularization.EmployeeID == reviewer.ID {
+		return nil, errors.New("you cannot review your own regularization request")
+	}
+
+	if can(reviewer, models.PermAttendanceApprove) {
+		return &regularization, nil
+	}
+
+	var employee models.User
+	err = usersCollection.FindOne(ctx, bson.M{"_id": regularization.EmployeeID}).Decode(&employee)
+	if err != nil {
+		return nil, errors.New("employee not found")
+	}
+	if employee.ManagerID != reviewer.ID {
+		return nil, errors.New("only the reporting manager or HR can review this request")
+	}
+
+	return &regularization, nil
+}
+
+// directReportIDs returns the IDs of users reporting to the given manager
+func directReportIDs(ctx context.Context, manager *models.User) ([]primitive.ObjectID, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	cursor, err := usersCollection.Find(
+		ctx,
+		helpers.AddNotDeletedFilter(bson.M{"company": manager.Company, "manager_id": manager.ID}),
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reports []models.User
+	if err = cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(reports))
+	for _, report := range reports {
+		ids = append(ids, report.ID)
+	}
+
+	return ids, nil
+}
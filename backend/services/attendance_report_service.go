@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/encryptions"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Muster roll day codes
+const (
+	MusterPresent    = "P"
+	MusterHalfDay    = "HD"
+	MusterAbsent     = "A"
+	MusterLeave      = "L"
+	MusterHoliday    = "H"
+	MusterWeeklyOff  = "WO"
+	MusterNotMarked  = "-"
+	MusterNotStarted = ""
+)
+
+// MusterRollRow is one employee line of the monthly muster roll
+type MusterRollRow struct {
+	EmployeeID    string   `json:"employee_id"`
+	EmployeeCode  string   `json:"employee_code,omitempty"`
+	Name          string   `json:"name"`
+	Department    string   `json:"department,omitempty"`
+	Days          []string `json:"days"` // one code per day of the month
+	Present       int      `json:"present"`
+	HalfDays      int      `json:"half_days"` // half-day leave, present for the other half
+	Absent        int      `json:"absent"`
+	Leave         int      `json:"leave"`
+	Holidays      int      `json:"holidays"`
+	WeeklyOffs    int      `json:"weekly_offs"`
+	TotalHours    float64  `json:"total_hours"`
+	LateCount     int      `json:"late_count"`
+	OvertimeHours float64  `json:"overtime_hours"`
+}
+
+// MusterRoll is the employees × days attendance grid of a month
+type MusterRoll struct {
+	Month  string            `json:"month"` // YYYY-MM
+	Dates  []string          `json:"dates"` // YYYY-MM-DD
+	Legend map[string]string `json:"legend"`
+	Rows   []MusterRollRow   `json:"rows"`
+}
+
+// musterRollEmployee is the shape produced by the muster roll aggregation
+type musterRollEmployee struct {
+	ID           primitive.ObjectID  `bson:"_id"`
+	FirstName    string              `bson:"first_name"`
+	LastName     string              `bson:"last_name"`
+	EmployeeCode string              `bson:"employee_code"`
+	DateOfJoin   string              `bson:"date_of_join"`
+	Status       models.UserStatus   `bson:"status"`
+	Department   string              `bson:"department"`
+	Attendance   []models.Attendance `bson:"attendance"`
+}
+
+// GetMusterRoll builds the monthly muster roll with a single aggregation over users and attendance
+func (s *AttendanceService) GetMusterRoll(companyID primitive.ObjectID, month string, departmentID primitive.ObjectID) (*MusterRoll, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+	monthStart, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, errors.New("invalid month format, expected YYYY-MM")
+	}
+	monthEnd := monthStart.AddDate(0, 1, -1)
+	startDate := helpers.FormatDate(monthStart)
+	endDate := helpers.FormatDate(monthEnd)
+
+	config, err := loadAttendanceConfiguration(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Admins and SuperAdmins do not mark attendance
+	userFilter := bson.M{
+		"company": companyID,
+		"role":    bson.M{"$nin": []models.Role{models.RoleAdmin, models.RoleSuperAdmin}},
+		"$or": []bson.M{
+			{"date_of_join": bson.M{"$exists": false}},
+			{"date_of_join": ""},
+			{"date_of_join": bson.M{"$lte": endDate}},
+		},
+	}
+	if !departmentID.IsZero() {
+		userFilter["department_id"] = departmentID
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: helpers.AddNotDeletedFilter(userFilter)}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": collections.Attendances,
+			"let":  bson.M{"employee": "$_id"},
+			"pipeline": []bson.M{
+				{"$match": bson.M{
+					"$expr": bson.M{"$eq": []string{"$employee_id", "$$employee"}},
+					"date":  bson.M{"$gte": startDate, "$lte": endDate},
+				}},
+				{"$project": bson.M{"date": 1, "status": 1, "check_in": 1, "work_hours": 1}},
+			},
+			"as": "attendance",
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         collections.Departments,
+			"localField":   "department_id",
+			"foreignField": "_id",
+			"as":           "department",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"first_name":    1,
+			"last_name":     1,
+			"employee_code": 1,
+			"date_of_join":  1,
+			"status":        1,
+			"attendance":    1,
+			"department":    bson.M{"$ifNull": []interface{}{bson.M{"$arrayElemAt": []interface{}{"$department.name", 0}}, ""}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "first_name", Value: 1}, {Key: "last_name", Value: 1}}}},
+	}
+
+	cursor, err := usersCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build muster roll: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var employees []musterRollEmployee
+	if err = cursor.All(ctx, &employees); err != nil {
+		return nil, err
+	}
+
+	var days []time.Time
+	for day := monthStart; !day.After(monthEnd); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+
+	roll := &MusterRoll{
+		Month: month,
+		Dates: make([]string, 0, len(days)),
+		Legend: map[string]string{
+			MusterPresent:   "Present",
+			MusterHalfDay:   "Half-day leave",
+			MusterAbsent:    "Absent",
+			MusterLeave:     "On leave",
+			MusterHoliday:   "Holiday",
+			MusterWeeklyOff: "Weekly off",
+			MusterNotMarked: "Not marked",
+		},
+		Rows: []MusterRollRow{},
+	}
+	for _, day := range days {
+		roll.Dates = append(roll.Dates, helpers.FormatDate(day))
+	}
+
+	today := helpers.FormatDate(time.Now())
+	lateAfter := config.ShiftStart
+	if shiftStart, err := time.Parse("15:04:05", config.ShiftStart); err == nil {
+		lateAfter = shiftStart.Add(time.Duration(config.LateGraceMinutes) * time.Minute).Format("15:04:05")
+	}
+
+	for _, employee := range employees {
+		// Inactive employees only appear for months they actually have records in
+		if employee.Status != models.UserActive && len(employee.Attendance) == 0 {
+			continue
+		}
+
+		byDate := make(map[string]models.Attendance, len(employee.Attendance))
+		for _, attendance := range employee.Attendance {
+			byDate[attendance.Date] = attendance
+		}
+
+		encryptedID, err := encryptions.EncryptID(employee.ID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt employee ID: %w", err)
+		}
+
+		row := MusterRollRow{
+			EmployeeID:   encryptedID,
+			EmployeeCode: employee.EmployeeCode,
+			Name:         employee.FirstName + " " + employee.LastName,
+			Department:   employee.Department,
+			Days:         make([]string, 0, len(days)),
+		}
+
+		for i, day := range days {
+			date := roll.Dates[i]
+
+			attendance, ok := byDate[date]
+			if !ok {
+				switch {
+				case date > today || (employee.DateOfJoin != "" && date < employee.DateOfJoin):
+					row.Days = append(row.Days, MusterNotStarted)
+				case containsString(config.Holidays, date):
+					row.Days = append(row.Days, MusterHoliday)
+					row.Holidays++
+				case !isWorkingDay(&config, day):
+					row.Days = append(row.Days, MusterWeeklyOff)
+					row.WeeklyOffs++
+				default:
+					row.Days = append(row.Days, MusterNotMarked)
+				}
+				continue
+			}
+
+			switch attendance.Status {
+			case models.StatusPresent, models.StatusWorkFromHome:
+				row.Days = append(row.Days, MusterPresent)
+				row.Present++
+				row.TotalHours += attendance.WorkHours
+				if attendance.CheckIn != "" && attendance.CheckIn > lateAfter {
+					row.LateCount++
+				}
+				if config.DefaultWorkHours > 0 && attendance.WorkHours > config.DefaultWorkHours {
+					row.OvertimeHours += attendance.WorkHours - config.DefaultWorkHours
+				}
+			case models.StatusHalfDay:
+				row.Days = append(row.Days, MusterHalfDay)
+				row.HalfDays++
+				row.TotalHours += attendance.WorkHours
+			case models.StatusOnLeave:
+				row.Days = append(row.Days, MusterLeave)
+				row.Leave++
+			case models.StatusAbsent:
+				row.Days = append(row.Days, MusterAbsent)
+				row.Absent++
+			default:
+				row.Days = append(row.Days, MusterNotMarked)
+			}
+		}
+
+		row.TotalHours = roundHours(row.TotalHours)
+		row.OvertimeHours = roundHours(row.OvertimeHours)
+		roll.Rows = append(roll.Rows, row)
+	}
+
+	return roll, nil
+}
+
+// Table flattens the muster roll into a header row and data rows for file exports
+func (roll *MusterRoll) Table() ([]string, [][]string) {
+	headers := []string{"Code", "Employee", "Department"}
+	for _, date := range roll.Dates {
+		headers = append(headers, date[8:])
+	}
+	headers = append(headers, "P", "HD", "A", "L", "H", "WO", "Hours", "Late", "OT")
+
+	rows := make([][]string, 0, len(roll.Rows))
+	for _, r := range roll.Rows {
+		row := []string{r.EmployeeCode, r.Name, r.Department}
+		row = append(row, r.Days...)
+		row = append(row,
+			strconv.Itoa(r.Present),
+			strconv.Itoa(r.HalfDays),
+			strconv.Itoa(r.Absent),
+			strconv.Itoa(r.Leave),
+			strconv.Itoa(r.Holidays),
+			strconv.Itoa(r.WeeklyOffs),
+			strconv.FormatFloat(r.TotalHours, 'f', 2, 64),
+			strconv.Itoa(r.LateCount),
+			strconv.FormatFloat(r.OvertimeHours, 'f', 2, 64),
+		)
+		rows = append(rows, row)
+	}
+
+	return headers, rows
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
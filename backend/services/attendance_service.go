@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"api.workzen.odoo/databases"
@@ -17,20 +18,47 @@ import (
 
 type AttendanceService struct{}
 
+// presentStatuses are the attendance statuses counted as a worked day
+var presentStatuses = []models.AttendanceStatus{models.StatusPresent, models.StatusWorkFromHome, models.StatusHalfDay}
+
 func NewAttendanceService() *AttendanceService {
 	return &AttendanceService{}
 }
 
-// CheckIn creates a new attendance record or resets existing one
-func (s *AttendanceService) CheckIn(employeeID, companyID primitive.ObjectID) (*models.Attendance, error) {
+// CheckInRequest carries the client location reported at check-in
+type CheckInRequest struct {
+	Latitude     *float64 `json:"latitude"`
+	Longitude    *float64 `json:"longitude"`
+	WorkFromHome bool     `json:"work_from_home"`
+}
+
+// CheckIn creates a new attendance record after validating location and IP rules
+func (s *AttendanceService) CheckIn(req *CheckInRequest, employee *models.User, companyID primitive.ObjectID, ipAddress string) (*models.Attendance, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	attendanceCollection := databases.MongoDBDatabase.Collection(collections.Attendances)
 
+	employeeID := employee.ID
 	today := helpers.FormatDate(time.Now())
 	now := time.Now()
 
+	var location *models.GeoPoint
+	if req.Latitude != nil && req.Longitude != nil {
+		if !helpers.ValidCoordinates(*req.Latitude, *req.Longitude) {
+			return nil, errors.New("invalid coordinates")
+		}
+		location = &models.GeoPoint{Latitude: *req.Latitude, Longitude: *req.Longitude}
+	}
+
+	status := models.StatusPresent
+	if req.WorkFromHome {
+		if !employee.WorkFromHomeAllowed {
+			return nil, errors.New("work from home is not allowed for your account")
+		}
+		status = models.StatusWorkFromHome
+	}
+
 	// Check if already checked in today
 	var existingAttendance models.Attendance
 	err := attendanceCollection.FindOne(ctx, bson.M{
@@ -38,7 +66,8 @@ func (s *AttendanceService) CheckIn(employeeID, companyID primitive.ObjectID) (*
 		"date":        today,
 	}).Decode(&existingAttendance)
 
-	if err == nil {
+	halfDay := err == nil && existingAttendance.Status == models.StatusHalfDay && existingAttendance.CheckIn == ""
+	if err == nil && !halfDay {
 		// Record exists - allow re-check-in only if already checked out
 		if existingAttendance.CheckOut == "" {
 			return nil, errors.New("already checked in today, please check out first")
@@ -47,14 +76,66 @@ func (s *AttendanceService) CheckIn(employeeID, companyID primitive.ObjectID) (*
 		return nil, errors.New("already completed attendance for today")
 	}
 
+	// Office check-ins must satisfy the company's location and IP rules
+	var violations []string
+	config, err := loadAttendanceConfiguration(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if status == models.StatusPresent {
+		violations = checkInViolations(&config, location, ipAddress)
+	}
+
+	flagged := false
+	if len(violations) > 0 {
+		switch config.CheckInPolicy {
+		case models.CheckInPolicyReject:
+			return nil, fmt.Errorf("check-in not allowed: %s", strings.Join(violations, "; "))
+		case models.CheckInPolicyFlag:
+			flagged = true
+		default:
+			violations = nil
+		}
+	}
+
+	// A half-day leave record is completed with the check-in of the other half
+	if halfDay {
+		attendance := existingAttendance
+		attendance.CheckIn = now.Format("15:04:05")
+		attendance.Location = location
+		attendance.IPAddress = ipAddress
+		attendance.Flagged = flagged
+		attendance.FlagReasons = violations
+		attendance.UpdatedAt = primitive.NewDateTimeFromTime(now)
+
+		_, err = attendanceCollection.UpdateOne(ctx, bson.M{"_id": attendance.ID}, bson.M{
+			"$set": bson.M{
+				"check_in":     attendance.CheckIn,
+				"location":     location,
+				"ip_address":   ipAddress,
+				"flagged":      flagged,
+				"flag_reasons": violations,
+				"updated_at":   attendance.UpdatedAt,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to check in: %w", err)
+		}
+		return &attendance, nil
+	}
+
 	// Create new attendance record
 	attendance := models.Attendance{
-		ID:         primitive.NewObjectID(),
-		EmployeeID: employeeID,
-		Company:    companyID,
-		Date:       today,
-		CheckIn:    now.Format("15:04:05"),
-		Status:     models.StatusPresent,
+		ID:          primitive.NewObjectID(),
+		EmployeeID:  employeeID,
+		Company:     companyID,
+		Date:        today,
+		CheckIn:     now.Format("15:04:05"),
+		Status:      status,
+		Location:    location,
+		IPAddress:   ipAddress,
+		Flagged:     flagged,
+		FlagReasons: violations,
 	}
 	attendance.CreatedAt = primitive.NewDateTimeFromTime(now)
 	attendance.UpdatedAt = primitive.NewDateTimeFromTime(now)
@@ -67,6 +148,39 @@ func (s *AttendanceService) CheckIn(employeeID, companyID primitive.ObjectID) (*
 	return &attendance, nil
 }
 
+// checkInViolations lists the location and IP rules an office check-in breaks
+func checkInViolations(config *models.AttendanceConfiguration, location *models.GeoPoint, ipAddress string) []string {
+	var violations []string
+
+	if len(config.OfficeLocations) > 0 {
+		if location == nil {
+			violations = append(violations, "location not provided")
+		} else {
+			inside := false
+			nearest := -1.0
+			for _, office := range config.OfficeLocations {
+				distance := helpers.DistanceMeters(location.Latitude, location.Longitude, office.Latitude, office.Longitude)
+				if distance <= office.RadiusMeters {
+					inside = true
+					break
+				}
+				if nearest < 0 || distance < nearest {
+					nearest = distance
+				}
+			}
+			if !inside {
+				violations = append(violations, fmt.Sprintf("outside office locations (nearest %.0fm away)", nearest))
+			}
+		}
+	}
+
+	if len(config.AllowedIPRanges) > 0 && !helpers.IPInRanges(ipAddress, config.AllowedIPRanges) {
+		violations = append(violations, fmt.Sprintf("IP address %s is not in an allowed range", ipAddress))
+	}
+
+	return violations
+}
+
 // CheckOut updates attendance with check-out time
 func (s *AttendanceService) CheckOut(employeeID primitive.ObjectID) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -83,7 +197,7 @@ func (s *AttendanceService) CheckOut(employeeID primitive.ObjectID) error {
 		"employee_id": employeeID,
 		"date":        today,
 	}).Decode(&attendance)
-	if err != nil {
+	if err != nil || attendance.CheckIn == "" {
 		return errors.New("no check-in found for today")
 	}
 
@@ -183,7 +297,8 @@ func (s *AttendanceService) ListAttendance(companyID primitive.ObjectID, filters
 	return attendances, total, nil
 }
 
-// ResetAttendance deletes today's attendance to allow re-check-in
+// ResetAttendance deletes today's own check-in to allow re-check-in.
+// Past dates, leave days and regularized records can only be changed through regularization.
 func (s *AttendanceService) ResetAttendance(employeeID primitive.ObjectID) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -192,10 +307,21 @@ func (s *AttendanceService) ResetAttendance(employeeID primitive.ObjectID) error
 
 	today := helpers.FormatDate(time.Now())
 
-	result, err := attendanceCollection.DeleteOne(ctx, bson.M{
+	var attendance models.Attendance
+	err := attendanceCollection.FindOne(ctx, bson.M{
 		"employee_id": employeeID,
 		"date":        today,
-	})
+	}).Decode(&attendance)
+	if err != nil {
+		return errors.New("no attendance found for today")
+	}
+
+	isCheckIn := attendance.Status == models.StatusPresent || attendance.Status == models.StatusWorkFromHome
+	if !isCheckIn || !attendance.RegularizationID.IsZero() {
+		return errors.New("this attendance record cannot be reset, please raise a regularization request")
+	}
+
+	result, err := attendanceCollection.DeleteOne(ctx, bson.M{"_id": attendance.ID})
 	if err != nil {
 		return errors.New("failed to reset attendance")
 	}
@@ -223,6 +349,18 @@ func (s *AttendanceService) GetAttendanceSummary(companyID primitive.ObjectID, d
 		"status":  models.StatusPresent,
 	})
 
+	workFromHomeCount, _ := attendanceCollection.CountDocuments(ctx, bson.M{
+		"company": companyID,
+		"date":    date,
+		"status":  models.StatusWorkFromHome,
+	})
+
+	flaggedCount, _ := attendanceCollection.CountDocuments(ctx, bson.M{
+		"company": companyID,
+		"date":    date,
+		"flagged": true,
+	})
+
 	onLeaveCount, _ := attendanceCollection.CountDocuments(ctx, bson.M{
 		"company": companyID,
 		"date":    date,
@@ -236,8 +374,10 @@ func (s *AttendanceService) GetAttendanceSummary(companyID primitive.ObjectID, d
 	})
 
 	return map[string]int64{
-		"present":  presentCount,
-		"on_leave": onLeaveCount,
-		"absent":   absentCount,
+		"present":        presentCount,
+		"work_from_home": workFromHomeCount,
+		"on_leave":       onLeaveCount,
+		"absent":         absentCount,
+		"flagged":        flaggedCount,
 	}, nil
 }
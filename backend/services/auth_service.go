@@ -20,6 +20,9 @@ import (
 
 type AuthService struct{}
 
+// dummyPasswordHash is checked against when a login names an unknown user
+var dummyPasswordHash = encryptions.HashPassword("workzen-dummy-password")
+
 func NewAuthService() *AuthService {
 	return &AuthService{}
 }
@@ -32,7 +35,7 @@ type SignupRequest struct {
 	Industry    string `json:"industry"`
 	FirstName   string `json:"first_name" validate:"required"`
 	LastName    string `json:"last_name" validate:"required"`
-	Password    string `json:"password" validate:"required,min=8"`
+	Password    string `json:"password" validate:"required"`
 }
 
 // LoginRequest represents login credentials
@@ -44,7 +47,7 @@ type LoginRequest struct {
 // ChangePasswordRequest represents password change request
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" validate:"required"`
-	NewPassword string `json:"new_password" validate:"required,min=8"`
+	NewPassword string `json:"new_password" validate:"required"`
 }
 
 // UserResponse represents user data with encrypted IDs for API responses
@@ -55,12 +58,15 @@ type UserResponse struct {
 	FirstName        string              `json:"first_name"`
 	LastName         string              `json:"last_name"`
 	Role             models.Role         `json:"role"`
+	CustomRoleID     string              `json:"custom_role_id,omitempty"` // replaces the role's default permissions
+	Permissions      []models.Permission `json:"permissions,omitempty"`    // only on the caller's own profile
 	IsSuperAdmin     bool                `json:"is_super_admin,omitempty"`
 	Designation      string              `json:"designation,omitempty"`
 	DepartmentID     string              `json:"department_id,omitempty"`
 	ManagerID        string              `json:"manager_id,omitempty"`
 	EmployeeCode     string              `json:"employee_code,omitempty"`
 	DateOfJoin       string              `json:"date_of_join,omitempty"`
+	Gender           models.Gender       `json:"gender,omitempty"`
 	Status           models.UserStatus   `json:"status"`
 	Phone            string              `json:"phone,omitempty"`
 	Address          models.Address      `json:"address,omitempty"`
@@ -69,35 +75,46 @@ type UserResponse struct {
 	Company          string              `json:"company,omitempty"`
 	BankDetails      *models.BankDetails `json:"bank_details,omitempty"`
 	LastLogin        primitive.DateTime  `json:"last_login,omitempty"`
+	LockedUntil      primitive.DateTime  `json:"locked_until,omitempty"` // set while failed logins keep the account locked
 	EmailVerified    bool                `json:"email_verified"`
 	TwoFactorEnabled bool                `json:"two_factor_enabled"`
+	WorkFromHome     bool                `json:"work_from_home_allowed"`
 	CreatedAt        primitive.DateTime  `json:"created_at,omitempty"`
 	UpdatedAt        primitive.DateTime  `json:"updated_at,omitempty"`
 }
 
 // CompanyResponse represents company data with encrypted IDs for API responses
 type CompanyResponse struct {
-	ID         string             `json:"id,omitempty"`
-	Name       string             `json:"name"`
-	Email      string             `json:"email"`
-	Phone      string             `json:"phone,omitempty"`
-	Industry   string             `json:"industry,omitempty"`
-	Website    string             `json:"website,omitempty"`
-	LogoURL    string             `json:"logo_url,omitempty"`
-	Address    models.Address     `json:"address,omitempty"`
-	OwnerID    string             `json:"owner_id,omitempty"`
-	ApprovedBy string             `json:"approved_by,omitempty"`
-	IsApproved bool               `json:"is_approved"`
-	IsActive   bool               `json:"is_active"`
-	CreatedAt  primitive.DateTime `json:"created_at,omitempty"`
-	UpdatedAt  primitive.DateTime `json:"updated_at,omitempty"`
+	ID         string                 `json:"id,omitempty"`
+	Name       string                 `json:"name"`
+	Email      string                 `json:"email"`
+	Phone      string                 `json:"phone,omitempty"`
+	Industry   string                 `json:"industry,omitempty"`
+	Website    string                 `json:"website,omitempty"`
+	LogoURL    string                 `json:"logo_url,omitempty"`
+	Address    models.Address         `json:"address,omitempty"`
+	OwnerID    string                 `json:"owner_id,omitempty"`
+	ApprovedBy string                 `json:"approved_by,omitempty"`
+	IsApproved bool                   `json:"is_approved"`
+	IsActive   bool                   `json:"is_active"`
+	TwoFactor  models.TwoFactorPolicy `json:"two_factor"`
+	Passwords  models.PasswordPolicy  `json:"password_policy"`
+	CreatedAt  primitive.DateTime     `json:"created_at,omitempty"`
+	UpdatedAt  primitive.DateTime     `json:"updated_at,omitempty"`
 }
 
-// LoginResponse represents the complete login response with user and company info
+// LoginResponse represents the complete login response with user and company info. Users who
+// sign in with two-factor authentication get a challenge token instead of the JWT.
 type LoginResponse struct {
-	Token   string           `json:"token"`
-	User    *UserResponse    `json:"user"`
-	Company *CompanyResponse `json:"company,omitempty"`
+	Token                  string           `json:"token,omitempty"`         // short-lived access token
+	RefreshToken           string           `json:"refresh_token,omitempty"` // exchanged at /auth/refresh for new tokens, single use
+	ExpiresIn              int64            `json:"expires_in,omitempty"`    // seconds until the access token expires
+	User                   *UserResponse    `json:"user,omitempty"`
+	Company                *CompanyResponse `json:"company,omitempty"`
+	TwoFactorRequired      bool             `json:"two_factor_required,omitempty"`       // exchange the challenge token and a code for the JWT
+	TwoFactorSetupRequired bool             `json:"two_factor_setup_required,omitempty"` // the company requires 2FA; enroll with the challenge token first
+	PasswordChangeRequired bool             `json:"password_change_required,omitempty"`  // the password expired; set a new one with the challenge token
+	ChallengeToken         string           `json:"challenge_token,omitempty"`
 }
 
 // ConvertUserToResponse converts User model to UserResponse with encrypted IDs (exported for use across services)
@@ -116,19 +133,25 @@ func ConvertUserToResponse(user *models.User) (*UserResponse, error) {
 		Designation:      user.Designation,
 		EmployeeCode:     user.EmployeeCode,
 		DateOfJoin:       user.DateOfJoin,
+		Gender:           user.Gender,
 		Status:           user.Status,
 		Phone:            user.Phone,
 		Address:          user.Address,
 		ProfilePic:       user.ProfilePic,
 		ResumeURL:        user.ResumeURL,
-		BankDetails:      user.BankDetails,
+		BankDetails:      maskBankDetails(user.BankDetails),
 		LastLogin:        user.LastLogin,
 		EmailVerified:    user.EmailVerified,
 		TwoFactorEnabled: user.TwoFactorEnabled,
+		WorkFromHome:     user.WorkFromHomeAllowed,
 		CreatedAt:        user.CreatedAt,
 		UpdatedAt:        user.UpdatedAt,
 	}
 
+	if user.LockedUntil.Time().After(time.Now()) {
+		response.LockedUntil = user.LockedUntil
+	}
+
 	// Encrypt user ID
 	if !user.ID.IsZero() {
 		encryptedID, err := encryptions.EncryptID(user.ID.Hex())
@@ -165,6 +188,15 @@ func ConvertUserToResponse(user *models.User) (*UserResponse, error) {
 		response.ManagerID = encryptedManagerID
 	}
 
+	// Encrypt custom role ID
+	if !user.CustomRoleID.IsZero() {
+		encryptedRoleID, err := encryptions.EncryptID(user.CustomRoleID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt custom role ID: %w", err)
+		}
+		response.CustomRoleID = encryptedRoleID
+	}
+
 	return response, nil
 }
 
@@ -184,6 +216,8 @@ func convertCompanyToResponse(company *models.Company) (*CompanyResponse, error)
 		Address:    company.Address,
 		IsApproved: company.IsApproved,
 		IsActive:   company.IsActive,
+		TwoFactor:  company.TwoFactor,
+		Passwords:  company.PasswordPolicy(),
 		CreatedAt:  company.CreatedAt,
 		UpdatedAt:  company.UpdatedAt,
 	}
@@ -226,6 +260,12 @@ func (s *AuthService) Signup(req *SignupRequest) error {
 	companiesCollection := databases.MongoDBDatabase.Collection(collections.Companies)
 	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
 
+	// The company is new, so the default policy applies; the username is not generated yet
+	signupUser := &models.User{Email: req.Email, FirstName: req.FirstName, LastName: req.LastName}
+	if err := checkPasswordPolicy(req.Password, models.DefaultPasswordPolicy, signupUser); err != nil {
+		return err
+	}
+
 	// Check if email already exists
 	var existingCompany models.Company
 	err := companiesCollection.FindOne(ctx, bson.M{"email": req.Email}).Decode(&existingCompany)
@@ -316,12 +356,16 @@ func (s *AuthService) Signup(req *SignupRequest) error {
 }
 
 // Login authenticates user and generates JWT token
-func (s *AuthService) Login(req *LoginRequest) (*LoginResponse, error) {
+func (s *AuthService) Login(req *LoginRequest, client LoginClient) (*LoginResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
-	companiesCollection := databases.MongoDBDatabase.Collection(collections.Companies)
+
+	now := time.Now()
+	if err := checkIPThrottle(ctx, client.IPAddress, now); err != nil {
+		return nil, err
+	}
 
 	// Find user by username or email (exclude soft-deleted users)
 	var user models.User
@@ -342,14 +386,61 @@ func (s *AuthService) Login(req *LoginRequest) (*LoginResponse, error) {
 		},
 	}).Decode(&user)
 	if err != nil {
+		// Spend the time of a password check anyway, so response times do not reveal usernames
+		encryptions.ComparePassword(req.Password, dummyPasswordHash)
+		recordIPFailure(ctx, client.IPAddress, now)
+		return nil, errors.New("invalid username or password")
+	}
+
+	// Locked or delayed accounts are refused like a wrong password, whatever the password, so neither
+	// the response nor its timing tells a known username from an unknown one. The owner learns of a
+	// lockout by email; a correct password during one is not revealed either.
+	passwordOK := encryptions.ComparePassword(req.Password, user.Password)
+	if checkAccountThrottle(&user, now) != nil {
+		recordIPFailure(ctx, client.IPAddress, now)
 		return nil, errors.New("invalid username or password")
 	}
 
 	// Verify password
-	if !encryptions.ComparePassword(req.Password, user.Password) {
+	if !passwordOK {
+		recordIPFailure(ctx, client.IPAddress, now)
+		recordAccountFailure(ctx, &user, client.IPAddress, now)
 		return nil, errors.New("invalid username or password")
 	}
 
+	// Hashes from before argon2id, or with an outdated cost, are replaced now that the password is known
+	if encryptions.PasswordNeedsRehash(user.Password) {
+		usersCollection.UpdateOne(ctx,
+			bson.M{"_id": user.ID, "password": user.Password},
+			bson.M{"$set": bson.M{"password": encryptions.HashPassword(req.Password)}},
+		)
+	}
+
+	company, err := checkLoginAllowed(ctx, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Users with two-factor authentication, or whose company requires it, finish with a code. Their
+	// failed logins are only forgotten once the code is right, so a known password cannot reset them.
+	if user.TwoFactorEnabled || (company != nil && company.RequiresTwoFactor(user.Role)) {
+		return startTwoFactorChallenge(ctx, &user)
+	}
+	clearAccountFailures(ctx, &user)
+
+	// An expired password must be replaced before the login completes
+	if passwordExpired(&user, company.PasswordPolicy(), now) {
+		return startPasswordChangeChallenge(ctx, &user)
+	}
+
+	return completeLogin(ctx, &user, company, client)
+}
+
+// checkLoginAllowed checks that the user and their company may sign in and returns the company
+// (nil for SuperAdmin)
+func checkLoginAllowed(ctx context.Context, user *models.User) (*models.Company, error) {
+	companiesCollection := databases.MongoDBDatabase.Collection(collections.Companies)
+
 	// Check if email is verified (except for SuperAdmin)
 	if !user.IsSuperAdmin && !user.EmailVerified {
 		return nil, errors.New("please verify your email address before logging in")
@@ -360,57 +451,51 @@ func (s *AuthService) Login(req *LoginRequest) (*LoginResponse, error) {
 		return nil, errors.New("user account is inactive")
 	}
 
-	var company models.Company
-	var companyResponse *CompanyResponse
+	if user.IsSuperAdmin {
+		return nil, nil
+	}
 
 	// If user belongs to a company, check if company is approved and active
-	if !user.IsSuperAdmin {
-		err = companiesCollection.FindOne(ctx, helpers.AddNotDeletedFilter(bson.M{"_id": user.Company})).Decode(&company)
-		if err != nil {
-			return nil, errors.New("company not found")
-		}
+	var company models.Company
+	err := companiesCollection.FindOne(ctx, helpers.AddNotDeletedFilter(bson.M{"_id": user.Company})).Decode(&company)
+	if err != nil {
+		return nil, errors.New("company not found")
+	}
 
-		if !company.IsApproved {
-			return nil, errors.New("company registration is pending approval")
-		}
+	if !company.IsApproved {
+		return nil, errors.New("company registration is pending approval")
+	}
 
-		if !company.IsActive {
-			return nil, errors.New("company account is inactive")
-		}
+	if !company.IsActive {
+		return nil, errors.New("company account is inactive")
+	}
+
+	return &company, nil
+}
 
+// completeLogin starts a session for a signed-in user, generates its tokens and records the login
+func completeLogin(ctx context.Context, user *models.User, company *models.Company, client LoginClient) (*LoginResponse, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	var companyResponse *CompanyResponse
+	if company != nil {
 		// Convert company to response with encrypted IDs
-		companyResponse, err = convertCompanyToResponse(&company)
+		var err error
+		companyResponse, err = convertCompanyToResponse(company)
 		if err != nil {
 			return nil, fmt.Errorf("failed to prepare company response: %w", err)
 		}
 	}
 
-	// Generate JWT token with encrypted IDs
-	expireTime := time.Now().Add(helpers.JWTExpireDuration)
-
-	// Encrypt user ID for JWT
-	encryptedUserID, err := encryptions.EncryptID(user.ID.Hex())
+	// Every login is a session; the access token is bound to it so it can be revoked
+	session, refreshToken, err := createSession(ctx, user, client)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt user ID for JWT: %w", err)
+		return nil, err
 	}
 
-	// Encrypt company ID for JWT
-	encryptedCompanyID, err := encryptions.EncryptID(user.Company.Hex())
+	token, err := generateAccessToken(user, session.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt company ID for JWT: %w", err)
-	}
-
-	payload := map[string]any{
-		"id":             encryptedUserID,
-		"username":       user.Username,
-		"role":           user.Role,
-		"company":        encryptedCompanyID,
-		"is_super_admin": user.IsSuperAdmin,
-	}
-
-	token, err := helpers.GenerateJWT(payload, expireTime)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
 
 	// Update last login
@@ -424,16 +509,18 @@ func (s *AuthService) Login(req *LoginRequest) (*LoginResponse, error) {
 	user.Password = ""
 
 	// Convert user to response with encrypted IDs
-	userResponse, err := ConvertUserToResponse(&user)
+	userResponse, err := ConvertUserToResponse(user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare user response: %w", err)
 	}
 
 	// Prepare login response
 	loginResponse := &LoginResponse{
-		Token:   token,
-		User:    userResponse,
-		Company: companyResponse,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(helpers.JWTAccessExpireDuration.Seconds()),
+		User:         userResponse,
+		Company:      companyResponse,
 	}
 
 	return loginResponse, nil
@@ -461,6 +548,12 @@ func (s *AuthService) GetUserProfile(userID primitive.ObjectID) (*UserResponse,
 		return nil, fmt.Errorf("failed to prepare user response: %w", err)
 	}
 
+	// The frontend shows only what the user may do
+	userResponse.Permissions, err = userPermissions(ctx, &user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load permissions: %w", err)
+	}
+
 	return userResponse, nil
 }
 
@@ -598,25 +691,29 @@ func (s *AuthService) ChangePassword(userID primitive.ObjectID, req *ChangePassw
 		return errors.New("old password is incorrect")
 	}
 
-	// Hash new password
-	hashedPassword := encryptions.HashPassword(req.NewPassword)
+	policy, err := loadPasswordPolicy(ctx, &user)
+	if err != nil {
+		return err
+	}
+	if err := checkPasswordPolicy(req.NewPassword, policy, &user); err != nil {
+		return err
+	}
 
 	// Update password
 	_, err = usersCollection.UpdateOne(
 		ctx,
 		bson.M{"_id": userID},
-		bson.M{
-			"$set": bson.M{
-				"password":             hashedPassword,
-				"timestamp.updated_at": primitive.NewDateTimeFromTime(time.Now()),
-			},
-		},
+		passwordUpdate(&user, req.NewPassword,
+			bson.M{"timestamp.updated_at": primitive.NewDateTimeFromTime(time.Now())},
+			bson.M{"password_reset_token": "", "password_reset_expiry": ""},
+		),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update password: %w", err)
 	}
 
-	return nil
+	// Sessions signed in with the old password end, including this one
+	return revokeSessions(ctx, bson.M{"user_id": userID}, models.SessionPasswordChange)
 }
 
 // ResetPasswordByAdmin allows admin to reset employee password
@@ -633,27 +730,38 @@ func (s *AuthService) ResetPasswordByAdmin(adminID, targetUserID primitive.Objec
 		return "", errors.New("admin not found")
 	}
 
-	if admin.Role != models.RoleAdmin && !admin.IsSuperAdmin {
-		return "", errors.New("only admins can reset passwords")
+	var target models.User
+	if err := usersCollection.FindOne(ctx, bson.M{"_id": targetUserID}).Decode(&target); err != nil {
+		return "", errors.New("user not found")
 	}
 
-	// Hash new password
-	hashedPassword := encryptions.HashPassword(newPassword)
+	if !HasPermission(&admin, models.PermUserManage, target.Company) {
+		return "", errors.New("you do not have permission to reset this user's password")
+	}
+	policy, err := loadPasswordPolicy(ctx, &target)
+	if err != nil {
+		return "", err
+	}
+	if err := checkPasswordPolicy(newPassword, policy, &target); err != nil {
+		return "", err
+	}
 
 	// Update target user password
 	_, err = usersCollection.UpdateOne(
 		ctx,
 		bson.M{"_id": targetUserID},
-		bson.M{
-			"$set": bson.M{
-				"password":             hashedPassword,
-				"timestamp.updated_at": primitive.NewDateTimeFromTime(time.Now()),
-			},
-		},
+		passwordUpdate(&target, newPassword,
+			bson.M{"timestamp.updated_at": primitive.NewDateTimeFromTime(time.Now())},
+			bson.M{"password_reset_token": "", "password_reset_expiry": ""},
+		),
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to reset password: %w", err)
 	}
 
+	if err := revokeSessions(ctx, bson.M{"user_id": targetUserID}, models.SessionPasswordChange); err != nil {
+		return "", err
+	}
+
 	return newPassword, nil
 }
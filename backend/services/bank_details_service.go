@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/encryptions"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// bankDetailsVisibleDigits is how much of an account number, PAN or UAN a masked response shows
+const bankDetailsVisibleDigits = 4
+
+// RevealBankDetails returns an employee's bank details unmasked for a user with bank.reveal in the same
+// company. Every reveal is written to the activity log.
+func (s *UserService) RevealBankDetails(userID primitive.ObjectID, actor *models.User, ipAddress string) (*models.BankDetails, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+	activityLogsCollection := databases.MongoDBDatabase.Collection(collections.ActivityLogs)
+
+	if !can(actor, models.PermBankReveal) {
+		return nil, errors.New("you do not have permission to reveal bank details")
+	}
+
+	filter := bson.M{"_id": userID}
+	if !actor.IsSuperAdmin {
+		filter["company"] = actor.Company
+	}
+
+	var user models.User
+	if err := usersCollection.FindOne(ctx, helpers.AddNotDeletedFilter(filter)).Decode(&user); err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.BankDetails == nil {
+		return nil, errors.New("no bank details on record")
+	}
+
+	details, err := decryptBankDetails(user.BankDetails)
+	if err != nil {
+		return nil, err
+	}
+
+	// The reveal is only returned once it is on record
+	log := models.ActivityLog{
+		ID:        primitive.NewObjectID(),
+		UserID:    actor.ID,
+		Company:   user.Company,
+		Action:    "reveal_bank_details",
+		Module:    "user",
+		Resource:  user.ID.Hex(),
+		IPAddress: ipAddress,
+		Metadata:  bson.M{"fields": []string{"account_number", "pan_no", "uan_no"}},
+	}
+	log.CreatedAt, log.CreatedBy = helpers.SetCreatedTimestamp(actor.ID)
+	log.UpdatedAt, log.UpdatedBy = helpers.SetUpdatedTimestamp(actor.ID)
+	if _, err := activityLogsCollection.InsertOne(ctx, log); err != nil {
+		return nil, fmt.Errorf("failed to log bank details reveal: %w", err)
+	}
+
+	return details, nil
+}
+
+// RunBankDetailsEncryption encrypts bank details still stored in plaintext, e.g. from before field
+// encryption (used by the scheduler)
+func (s *UserService) RunBankDetailsEncryption(now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	plaintext := bson.M{"$exists": true, "$ne": "", "$not": primitive.Regex{Pattern: "^enc:"}}
+	cursor, err := usersCollection.Find(ctx, bson.M{"$or": []bson.M{
+		{"bank_details.account_number": plaintext},
+		{"bank_details.pan_no": plaintext},
+		{"bank_details.uan_no": plaintext},
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to fetch users: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil || user.BankDetails == nil {
+			continue
+		}
+
+		// Only replace the values that were read, in case they changed meanwhile
+		filter, set := bson.M{"_id": user.ID}, bson.M{}
+		for field, value := range map[string]string{
+			"bank_details.account_number": user.BankDetails.AccountNumber,
+			"bank_details.pan_no":         user.BankDetails.PANNo,
+			"bank_details.uan_no":         user.BankDetails.UANNo,
+		} {
+			if value == "" || encryptions.IsEncryptedField(value) {
+				continue
+			}
+			encrypted, err := encryptions.EncryptField(value)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt bank details: %w", err)
+			}
+			filter[field], set[field] = value, encrypted
+		}
+		if len(set) == 0 {
+			continue
+		}
+
+		if _, err := usersCollection.UpdateOne(ctx, filter, bson.M{"$set": set}); err != nil {
+			return fmt.Errorf("failed to encrypt bank details: %w", err)
+		}
+	}
+
+	return cursor.Err()
+}
+
+// sealBankField encrypts a submitted account number, PAN or UAN. Forms send back the masked value
+// they were shown to keep the stored one, which is reused rather than taken from the request.
+func sealBankField(value, stored string) (string, error) {
+	if value != "" && stored != "" && value == maskField(stored) {
+		if encryptions.IsEncryptedField(stored) {
+			return stored, nil
+		}
+		value = stored
+	}
+
+	encrypted, err := encryptions.EncryptField(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt bank details: %w", err)
+	}
+	return encrypted, nil
+}
+
+// decryptBankDetails returns a copy of stored bank details with the encrypted fields in plaintext
+func decryptBankDetails(stored *models.BankDetails) (*models.BankDetails, error) {
+	details := *stored
+	for _, field := range []*string{&details.AccountNumber, &details.PANNo, &details.UANNo} {
+		decrypted, err := encryptions.DecryptField(*field)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt bank details: %w", err)
+		}
+		*field = decrypted
+	}
+	return &details, nil
+}
+
+// maskBankDetails returns a copy of stored bank details for responses, with the account number, PAN
+// and UAN masked down to their last digits
+func maskBankDetails(stored *models.BankDetails) *models.BankDetails {
+	if stored == nil {
+		return nil
+	}
+
+	details := *stored
+	details.AccountNumber = maskField(stored.AccountNumber)
+	details.PANNo = maskField(stored.PANNo)
+	details.UANNo = maskField(stored.UANNo)
+	return &details
+}
+
+// maskField decrypts a stored value and replaces all but its last digits with X
+func maskField(stored string) string {
+	value, err := encryptions.DecryptField(stored)
+	if err != nil {
+		// Unreadable, e.g. its key was removed; only show that a value exists
+		return strings.Repeat("X", bankDetailsVisibleDigits)
+	}
+	if len(value) <= bankDetailsVisibleDigits {
+		return strings.Repeat("X", len(value))
+	}
+	return strings.Repeat("X", len(value)-bankDetailsVisibleDigits) + value[len(value)-bankDetailsVisibleDigits:]
+}
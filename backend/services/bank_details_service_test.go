@@ -0,0 +1,77 @@
+package services
+
+import (
+	"testing"
+
+	"api.workzen.odoo/encryptions"
+)
+
+func mustEncryptField(t *testing.T, value string) string {
+	t.Helper()
+	encrypted, err := encryptions.EncryptField(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encrypted
+}
+
+func TestMaskField(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored string
+		want   string
+	}{
+		{"encrypted account number", mustEncryptField(t, "123456789012"), "XXXXXXXX9012"},
+		{"plaintext from before encryption", "ABCDE1234F", "XXXXXX234F"},
+		{"exactly the visible digits", mustEncryptField(t, "1234"), "XXXX"},
+		{"shorter than the visible digits", "12", "XX"},
+		{"empty", "", ""},
+		{"unreadable ciphertext", "enc:not-a-valid-value", "XXXX"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskField(tt.stored); got != tt.want {
+				t.Errorf("maskField() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSealBankField(t *testing.T) {
+	stored := mustEncryptField(t, "123456789012")
+
+	tests := []struct {
+		name      string
+		value     string
+		stored    string
+		want      string // plaintext after decrypting the result
+		keepsSame bool   // the stored ciphertext is reused as it is
+	}{
+		{"new value", "998877665544", stored, "998877665544", false},
+		{"masked value keeps the stored one", "XXXXXXXX9012", stored, "123456789012", true},
+		{"masked value of a plaintext record", "XXXXXXXX9012", "123456789012", "123456789012", false},
+		{"mask without a stored value", "XXXXXXXX9012", "", "XXXXXXXX9012", false},
+		{"client-supplied ciphertext is encrypted again", stored, "", stored, false},
+		{"empty", "", stored, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sealBankField(tt.value, tt.stored)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.keepsSame != (got == tt.stored) {
+				t.Errorf("reused the stored value = %v, want %v", got == tt.stored, tt.keepsSame)
+			}
+			if tt.want != "" && !encryptions.IsEncryptedField(got) {
+				t.Errorf("result %q is not encrypted", got)
+			}
+			plain, err := encryptions.DecryptField(got)
+			if err != nil || plain != tt.want {
+				t.Errorf("decrypted result = %q, %v; want %q", plain, err, tt.want)
+			}
+		})
+	}
+}
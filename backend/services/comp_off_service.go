@@ -0,0 +1,489 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RequestCompOffRequest for claiming comp-off for a weekly-off or holiday that was worked
+type RequestCompOffRequest struct {
+	Date   string `json:"date" validate:"required"` // YYYY-MM-DD
+	Reason string `json:"reason"`
+}
+
+// ReviewCompOffRequest for approving or rejecting a comp-off credit
+type ReviewCompOffRequest struct {
+	Remarks string `json:"remarks"`
+}
+
+// RequestCompOff creates a pending comp-off credit for a past non-working day the employee worked.
+// The daily attendance job raises these on its own; this covers days corrected afterwards.
+func (s *LeaveService) RequestCompOff(req *RequestCompOffRequest, employeeID, companyID primitive.ObjectID) (*models.CompOffCredit, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	attendanceCollection := databases.MongoDBDatabase.Collection(collections.Attendances)
+	creditsCollection := databases.MongoDBDatabase.Collection(collections.CompOffCredits)
+
+	day, err := helpers.ParseDate(req.Date)
+	if err != nil {
+		return nil, errors.New("invalid date format")
+	}
+	if req.Date >= helpers.FormatDate(time.Now()) {
+		return nil, errors.New("comp-off can only be claimed for past dates")
+	}
+
+	if _, err := loadLeaveType(ctx, companyID, models.CompOffLeaveType); err != nil {
+		return nil, errors.New("comp-off is not enabled for this company")
+	}
+
+	attendanceConfig, err := loadAttendanceConfiguration(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if isWorkingDay(&attendanceConfig, day) {
+		return nil, errors.New("comp-off is only earned on weekly-offs and holidays")
+	}
+
+	var attendance models.Attendance
+	err = attendanceCollection.FindOne(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"employee_id": employeeID,
+		"date":        req.Date,
+		"status":      bson.M{"$in": presentStatuses},
+	})).Decode(&attendance)
+	if err != nil {
+		return nil, errors.New("no attendance recorded for this date")
+	}
+
+	leaveConfig, err := loadLeaveConfiguration(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	credit, err := newCompOffCredit(&attendance, &attendanceConfig, &leaveConfig, req.Reason, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A unique index allows one credit per employee and date besides rejected ones
+	if _, err = creditsCollection.InsertOne(ctx, credit); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("comp-off for this date has already been claimed")
+		}
+		return nil, fmt.Errorf("failed to request comp-off: %w", err)
+	}
+
+	return credit, nil
+}
+
+// ListCompOffs retrieves comp-off credits visible to the caller.
+// HR and Admin see the whole company, everyone else sees their own and their direct reports' credits.
+func (s *LeaveService) ListCompOffs(viewer *models.User, companyID primitive.ObjectID, status string, page, limit int64) ([]models.CompOffCredit, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	creditsCollection := databases.MongoDBDatabase.Collection(collections.CompOffCredits)
+
+	filter := bson.M{"company": companyID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	if !can(viewer, models.PermLeaveViewAll) {
+		employeeIDs, err := directReportIDs(ctx, viewer)
+		if err != nil {
+			return nil, 0, err
+		}
+		filter["employee_id"] = bson.M{"$in": append(employeeIDs, viewer.ID)}
+	}
+
+	skip := (page - 1) * limit
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "work_date", Value: -1}})
+
+	cursor, err := creditsCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	credits := []models.CompOffCredit{}
+	if err = cursor.All(ctx, &credits); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := creditsCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return credits, total, nil
+}
+
+// ApproveCompOff grants a pending comp-off credit into the employee's comp-off balance
+func (s *LeaveService) ApproveCompOff(creditID, companyID primitive.ObjectID, reviewer *models.User, req *ReviewCompOffRequest) (*models.CompOffCredit, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	creditsCollection := databases.MongoDBDatabase.Collection(collections.CompOffCredits)
+
+	credit, err := getReviewableCompOff(ctx, creditID, companyID, reviewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := loadLeaveType(ctx, companyID, models.CompOffLeaveType); err != nil {
+		return nil, errors.New("comp-off is not enabled for this company")
+	}
+
+	now := time.Now()
+	if credit.ExpiresOn != "" && credit.ExpiresOn < helpers.FormatDate(now) {
+		return nil, fmt.Errorf("comp-off for %s expired on %s", credit.WorkDate, credit.ExpiresOn)
+	}
+
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(reviewer.ID)
+	result, err := creditsCollection.UpdateOne(ctx,
+		bson.M{"_id": credit.ID, "status": models.CompOffPending},
+		bson.M{"$set": bson.M{
+			"status":         models.CompOffApproved,
+			"reviewed_by":    reviewer.ID,
+			"reviewed_at":    helpers.FormatDateTime(now),
+			"review_remarks": req.Remarks,
+			"updated_at":     updatedAt,
+			"updated_by":     updatedBy,
+		}},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		return nil, errors.New("failed to approve comp-off")
+	}
+
+	_, err = addLedgerEntry(ctx, models.LeaveLedgerEntry{
+		Company:       credit.Company,
+		EmployeeID:    credit.EmployeeID,
+		LeaveType:     models.CompOffLeaveType,
+		EntryType:     models.LedgerCompOff,
+		Days:          credit.Days,
+		CompOffID:     credit.ID,
+		EffectiveDate: credit.WorkDate,
+		Remarks:       fmt.Sprintf("Worked on %s", credit.WorkDate),
+	}, reviewer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	credit.Status = models.CompOffApproved
+	credit.ReviewedBy = reviewer.ID
+	credit.ReviewedAt = helpers.FormatDateTime(now)
+	credit.ReviewRemarks = req.Remarks
+	return credit, nil
+}
+
+// RejectCompOff rejects a pending comp-off credit
+func (s *LeaveService) RejectCompOff(creditID, companyID primitive.ObjectID, reviewer *models.User, req *ReviewCompOffRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	creditsCollection := databases.MongoDBDatabase.Collection(collections.CompOffCredits)
+
+	credit, err := getReviewableCompOff(ctx, creditID, companyID, reviewer)
+	if err != nil {
+		return err
+	}
+
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(reviewer.ID)
+	result, err := creditsCollection.UpdateOne(ctx,
+		bson.M{"_id": credit.ID, "status": models.CompOffPending},
+		bson.M{"$set": bson.M{
+			"status":         models.CompOffRejected,
+			"reviewed_by":    reviewer.ID,
+			"reviewed_at":    helpers.FormatDateTime(time.Now()),
+			"review_remarks": req.Remarks,
+			"updated_at":     updatedAt,
+			"updated_by":     updatedBy,
+		}},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		return errors.New("failed to reject comp-off")
+	}
+
+	return nil
+}
+
+// RunCompOffExpiryForAllCompanies lapses the unused days of comp-off credits past their validity
+// (used by the scheduler)
+func (s *LeaveService) RunCompOffExpiryForAllCompanies(now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	companiesCollection := databases.MongoDBDatabase.Collection(collections.Companies)
+	creditsCollection := databases.MongoDBDatabase.Collection(collections.CompOffCredits)
+
+	cursor, err := companiesCollection.Find(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"is_active":   true,
+		"is_approved": true,
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to list companies: %w", err)
+	}
+	var companies []models.Company
+	err = cursor.All(ctx, &companies)
+	cursor.Close(ctx)
+	if err != nil {
+		return err
+	}
+
+	today := helpers.FormatDate(now)
+	for _, company := range companies {
+		employeeIDs, err := creditsCollection.Distinct(ctx, "employee_id", bson.M{
+			"company":    company.ID,
+			"status":     models.CompOffApproved,
+			"expires_on": bson.M{"$nin": []interface{}{"", nil}, "$lt": today},
+		})
+		if err != nil {
+			log.Printf("⚠️  Comp-off expiry failed for company %s: %v\n", company.ID.Hex(), err)
+			continue
+		}
+
+		for _, value := range employeeIDs {
+			employeeID, ok := value.(primitive.ObjectID)
+			if !ok {
+				continue
+			}
+			if err := expireCompOffs(ctx, employeeID, today); err != nil {
+				log.Printf("⚠️  Comp-off expiry failed for employee %s: %v\n", employeeID.Hex(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// requestCompOffsForDay raises pending comp-off credits for everyone who worked on a non-working day
+// (used by the daily attendance job)
+func requestCompOffsForDay(ctx context.Context, attendanceConfig *models.AttendanceConfiguration, date string) (int, error) {
+	attendanceCollection := databases.MongoDBDatabase.Collection(collections.Attendances)
+	creditsCollection := databases.MongoDBDatabase.Collection(collections.CompOffCredits)
+
+	// Companies that deactivated the comp-off leave type do not earn it
+	if _, err := loadLeaveType(ctx, attendanceConfig.Company, models.CompOffLeaveType); err != nil {
+		return 0, nil
+	}
+
+	leaveConfig, err := loadLeaveConfiguration(ctx, attendanceConfig.Company)
+	if err != nil {
+		return 0, err
+	}
+
+	cursor, err := attendanceCollection.Find(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"company":    attendanceConfig.Company,
+		"date":       date,
+		"status":     bson.M{"$in": presentStatuses},
+		"work_hours": bson.M{"$gt": 0},
+	}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load attendance: %w", err)
+	}
+	var records []models.Attendance
+	err = cursor.All(ctx, &records)
+	cursor.Close(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	requested := 0
+	for i := range records {
+		// Re-runs of the day never raise a credit twice, even after a rejection
+		count, err := creditsCollection.CountDocuments(ctx, bson.M{
+			"employee_id": records[i].EmployeeID,
+			"work_date":   date,
+		})
+		if err != nil {
+			return requested, err
+		}
+		if count > 0 {
+			continue
+		}
+
+		credit, err := newCompOffCredit(&records[i], attendanceConfig, &leaveConfig, "Worked on a non-working day", primitive.NilObjectID)
+		if err != nil {
+			// Too few hours for a comp-off
+			continue
+		}
+		if _, err := creditsCollection.InsertOne(ctx, credit); err != nil {
+			// Claimed by the employee or a concurrent run since the check above
+			if mongo.IsDuplicateKeyError(err) {
+				continue
+			}
+			return requested, fmt.Errorf("failed to request comp-off: %w", err)
+		}
+		requested++
+	}
+
+	return requested, nil
+}
+
+// newCompOffCredit builds a pending credit for a worked non-working day: a full day for a standard
+// day's hours, half a day for at least half of them
+func newCompOffCredit(attendance *models.Attendance, attendanceConfig *models.AttendanceConfiguration, leaveConfig *models.LeaveConfiguration, reason string, userID primitive.ObjectID) (*models.CompOffCredit, error) {
+	var days float64
+	switch {
+	case attendance.WorkHours >= attendanceConfig.DefaultWorkHours:
+		days = 1
+	case attendance.WorkHours >= attendanceConfig.DefaultWorkHours/2:
+		days = 0.5
+	default:
+		return nil, fmt.Errorf("at least %g work hours are needed for a comp-off", attendanceConfig.DefaultWorkHours/2)
+	}
+
+	credit := &models.CompOffCredit{
+		ID:           primitive.NewObjectID(),
+		EmployeeID:   attendance.EmployeeID,
+		Company:      attendance.Company,
+		AttendanceID: attendance.ID,
+		WorkDate:     attendance.Date,
+		WorkHours:    attendance.WorkHours,
+		Days:         days,
+		Reason:       reason,
+		Status:       models.CompOffPending,
+	}
+	if leaveConfig.CompOffValidityDays > 0 {
+		workDate, err := helpers.ParseDate(attendance.Date)
+		if err != nil {
+			return nil, errors.New("invalid attendance date")
+		}
+		credit.ExpiresOn = helpers.FormatDate(workDate.AddDate(0, 0, leaveConfig.CompOffValidityDays))
+	}
+	credit.CreatedAt, credit.CreatedBy = helpers.SetCreatedTimestamp(userID)
+	credit.UpdatedAt, credit.UpdatedBy = helpers.SetUpdatedTimestamp(userID)
+
+	return credit, nil
+}
+
+// expireCompOffs lapses an employee's comp-off credits that ended before today. Leave is taken from
+// the credits that expire first, so the balance left belongs to the latest credits and an expired
+// credit only loses what the later credits do not cover.
+func expireCompOffs(ctx context.Context, employeeID primitive.ObjectID, today string) error {
+	creditsCollection := databases.MongoDBDatabase.Collection(collections.CompOffCredits)
+
+	cursor, err := creditsCollection.Find(ctx, bson.M{
+		"employee_id": employeeID,
+		"status":      models.CompOffApproved,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load comp-off credits: %w", err)
+	}
+	var credits []models.CompOffCredit
+	err = cursor.All(ctx, &credits)
+	cursor.Close(ctx)
+	if err != nil {
+		return err
+	}
+
+	balance, err := ledgerBalance(ctx, employeeID, models.CompOffLeaveType)
+	if err != nil {
+		return err
+	}
+
+	var expired []models.CompOffCredit
+	remaining := balance
+	for _, credit := range credits {
+		if credit.ExpiresOn == "" || credit.ExpiresOn >= today {
+			remaining -= credit.Days
+		} else {
+			expired = append(expired, credit)
+		}
+	}
+
+	// Latest expiry first, as it holds whatever balance is left
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ExpiresOn > expired[j].ExpiresOn })
+
+	for _, credit := range expired {
+		lapsed := roundDays(math.Min(math.Max(remaining, 0), credit.Days))
+		remaining -= lapsed
+
+		updatedAt, updatedBy := helpers.SetUpdatedTimestamp(primitive.NilObjectID)
+		result, err := creditsCollection.UpdateOne(ctx,
+			bson.M{"_id": credit.ID, "status": models.CompOffApproved},
+			bson.M{"$set": bson.M{
+				"status":      models.CompOffExpired,
+				"lapsed_days": lapsed,
+				"updated_at":  updatedAt,
+				"updated_by":  updatedBy,
+			}},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to expire comp-off: %w", err)
+		}
+		if result.ModifiedCount == 0 || lapsed == 0 {
+			continue
+		}
+
+		_, err = addLedgerEntry(ctx, models.LeaveLedgerEntry{
+			Company:       credit.Company,
+			EmployeeID:    credit.EmployeeID,
+			LeaveType:     models.CompOffLeaveType,
+			EntryType:     models.LedgerExpiry,
+			Days:          -lapsed,
+			CompOffID:     credit.ID,
+			EffectiveDate: credit.ExpiresOn,
+			Remarks:       fmt.Sprintf("Comp-off for %s expired", credit.WorkDate),
+		}, primitive.NilObjectID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getReviewableCompOff loads a pending credit and checks that the reviewer
+// is HR/Admin or the employee's reporting manager
+func getReviewableCompOff(ctx context.Context, creditID, companyID primitive.ObjectID, reviewer *models.User) (*models.CompOffCredit, error) {
+	creditsCollection := databases.MongoDBDatabase.Collection(collections.CompOffCredits)
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	var credit models.CompOffCredit
+	err := creditsCollection.FindOne(ctx, bson.M{"_id": creditID, "company": companyID}).Decode(&credit)
+	if err != nil {
+		return nil, errors.New("comp-off not found")
+	}
+
+	if credit.Status != models.CompOffPending {
+		return nil, errors.New("comp-off is not pending")
+	}
+
+	if credit.EmployeeID == reviewer.ID {
+		return nil, errors.New("you cannot review your own comp-off")
+	}
+
+	if can(reviewer, models.PermLeaveApprove) {
+		return &credit, nil
+	}
+
+	var employee models.User
+	err = usersCollection.FindOne(ctx, bson.M{"_id": credit.EmployeeID}).Decode(&employee)
+	if err != nil {
+		return nil, errors.New("employee not found")
+	}
+	if employee.ManagerID != reviewer.ID {
+		return nil, errors.New("only the reporting manager or HR can review this comp-off")
+	}
+
+	return &credit, nil
+}
@@ -9,6 +9,7 @@ import (
 	"api.workzen.odoo/databases"
 	"api.workzen.odoo/databases/collections"
 	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -133,6 +134,133 @@ func (s *CompanyService) GetCompanyByID(companyID primitive.ObjectID) (*CompanyR
 	return companyResponse, nil
 }
 
+// SaveTwoFactorPolicyRequest for choosing who in a company must use two-factor authentication
+type SaveTwoFactorPolicyRequest struct {
+	Required bool          `json:"required"` // everyone in the company
+	Roles    []models.Role `json:"roles"`    // only these roles, when not required for everyone
+}
+
+// GetTwoFactorPolicy returns the company's two-factor authentication policy
+func (s *CompanyService) GetTwoFactorPolicy(companyID primitive.ObjectID) (*models.TwoFactorPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	companiesCollection := databases.MongoDBDatabase.Collection(collections.Companies)
+
+	var company models.Company
+	err := companiesCollection.FindOne(ctx, bson.M{"_id": companyID}).Decode(&company)
+	if err != nil {
+		return nil, errors.New("company not found")
+	}
+
+	return &company.TwoFactor, nil
+}
+
+// SaveTwoFactorPolicy sets who in the company must use two-factor authentication. Users it newly
+// covers are asked to enroll at their next login.
+func (s *CompanyService) SaveTwoFactorPolicy(companyID, userID primitive.ObjectID, req *SaveTwoFactorPolicyRequest) (*models.TwoFactorPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	companiesCollection := databases.MongoDBDatabase.Collection(collections.Companies)
+
+	policy := models.TwoFactorPolicy{Required: req.Required}
+	if !req.Required {
+		seen := make(map[models.Role]bool, len(req.Roles))
+		for _, role := range req.Roles {
+			switch role {
+			case models.RoleAdmin, models.RoleHR, models.RolePayroll, models.RoleEmployee:
+			default:
+				return nil, fmt.Errorf("invalid role %q", role)
+			}
+			if !seen[role] {
+				seen[role] = true
+				policy.Roles = append(policy.Roles, role)
+			}
+		}
+	}
+
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(userID)
+	result, err := companiesCollection.UpdateOne(ctx,
+		bson.M{"_id": companyID},
+		bson.M{"$set": bson.M{
+			"two_factor": policy,
+			"updated_at": updatedAt,
+			"updated_by": updatedBy,
+		}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save two-factor policy: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, errors.New("company not found")
+	}
+
+	return &policy, nil
+}
+
+// SavePasswordPolicyRequest for setting the rules of a company's passwords
+type SavePasswordPolicyRequest struct {
+	MinLength            int  `json:"min_length"` // at least 8; 0 uses 8
+	RequireUppercase     bool `json:"require_uppercase"`
+	RequireLowercase     bool `json:"require_lowercase"`
+	RequireDigit         bool `json:"require_digit"`
+	RequireSymbol        bool `json:"require_symbol"`
+	DisallowPersonalInfo bool `json:"disallow_personal_info"`
+	DisallowCommon       bool `json:"disallow_common"`
+	HistoryCount         int  `json:"history_count"` // 0-24
+	MaxAgeDays           int  `json:"max_age_days"`  // 0-365; 0 never expires
+}
+
+// GetPasswordPolicy returns the company's password policy, or the default when it has none
+func (s *CompanyService) GetPasswordPolicy(companyID primitive.ObjectID) (*models.PasswordPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	companiesCollection := databases.MongoDBDatabase.Collection(collections.Companies)
+
+	var company models.Company
+	err := companiesCollection.FindOne(ctx, bson.M{"_id": companyID}).Decode(&company)
+	if err != nil {
+		return nil, errors.New("company not found")
+	}
+
+	policy := company.PasswordPolicy()
+	return &policy, nil
+}
+
+// SavePasswordPolicy sets the rules for passwords of the company's users. Existing passwords are
+// checked against them when next changed; a shorter maximum age applies at the next login.
+func (s *CompanyService) SavePasswordPolicy(companyID, userID primitive.ObjectID, req *SavePasswordPolicyRequest) (*models.PasswordPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	companiesCollection := databases.MongoDBDatabase.Collection(collections.Companies)
+
+	policy := models.PasswordPolicy(*req)
+	if err := checkPasswordPolicyRules(&policy); err != nil {
+		return nil, err
+	}
+
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(userID)
+	result, err := companiesCollection.UpdateOne(ctx,
+		bson.M{"_id": companyID},
+		bson.M{"$set": bson.M{
+			"password_policy": policy,
+			"updated_at":      updatedAt,
+			"updated_by":      updatedBy,
+		}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save password policy: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, errors.New("company not found")
+	}
+
+	return &policy, nil
+}
+
 // ApproveCompany approves a pending company signup
 func (s *CompanyService) ApproveCompany(companyID, approvedByID primitive.ObjectID) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -216,5 +344,5 @@ func (s *CompanyService) DeactivateCompany(companyID primitive.ObjectID) error {
 		return fmt.Errorf("failed to deactivate users: %w", err)
 	}
 
-	return nil
+	return revokeSessions(ctx, bson.M{"company": companyID}, models.SessionDeactivated)
 }
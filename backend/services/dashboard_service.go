@@ -64,8 +64,24 @@ type AdminDashboardStats struct {
 	AttendanceRate       float64             `json:"attendance_rate"`
 }
 
+// dashboardExcludedRoles returns the roles left out of a user's dashboard counts, from the permissions
+// they hold rather than their built-in role. Admins are managers and never counted as employees.
+func dashboardExcludedRoles(user *models.User) []models.Role {
+	switch {
+	case can(user, models.PermUserViewAll):
+		// Everyone below Admin: HR, Payroll and Employees
+		return []models.Role{models.RoleSuperAdmin, models.RoleAdmin}
+	case can(user, models.PermPayrollViewAll):
+		// Payroll and Employees
+		return []models.Role{models.RoleSuperAdmin, models.RoleAdmin, models.RoleHR}
+	default:
+		// Employees only
+		return []models.Role{models.RoleSuperAdmin, models.RoleAdmin, models.RoleHR, models.RolePayroll}
+	}
+}
+
 // GetAdminDashboard retrieves dashboard stats for company admins
-func (s *DashboardService) GetAdminDashboard(companyID primitive.ObjectID, userRole models.Role) (*AdminDashboardStats, error) {
+func (s *DashboardService) GetAdminDashboard(companyID primitive.ObjectID, user *models.User) (*AdminDashboardStats, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -77,26 +93,7 @@ func (s *DashboardService) GetAdminDashboard(companyID primitive.ObjectID, userR
 	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
 	payrollCollection := databases.MongoDBDatabase.Collection(collections.Payrolls)
 
-	// Define role hierarchy - who can see whom
-	// Admin should not be counted as employee, they are managers
-	var excludeRoles []models.Role
-	switch userRole {
-	case models.RoleAdmin:
-		// Admin can see everyone except superadmin and other admins
-		excludeRoles = []models.Role{models.RoleSuperAdmin, models.RoleAdmin}
-	case models.RoleHR:
-		// HR can see HR, Payroll, and Employees (not Admin or SuperAdmin)
-		excludeRoles = []models.Role{models.RoleSuperAdmin, models.RoleAdmin}
-	case models.RolePayroll:
-		// Payroll can see Payroll and Employees (not Admin, HR, or SuperAdmin)
-		excludeRoles = []models.Role{models.RoleSuperAdmin, models.RoleAdmin, models.RoleHR}
-	case models.RoleEmployee:
-		// Employees can only see themselves
-		excludeRoles = []models.Role{models.RoleSuperAdmin, models.RoleAdmin, models.RoleHR, models.RolePayroll}
-	default:
-		// Default: exclude superadmin and admin
-		excludeRoles = []models.Role{models.RoleSuperAdmin, models.RoleAdmin}
-	}
+	excludeRoles := dashboardExcludedRoles(user)
 
 	// Total employees (excluding admins and higher roles)
 	total, err := usersCollection.CountDocuments(ctx, bson.M{
@@ -153,7 +150,7 @@ func (s *DashboardService) GetAdminDashboard(companyID primitive.ObjectID, userR
 		present, err = attendanceCollection.CountDocuments(ctx, bson.M{
 			"company":     companyID,
 			"date":        today,
-			"status":      models.StatusPresent,
+			"status":      bson.M{"$in": presentStatuses},
 			"employee_id": bson.M{"$in": employeeIDs},
 		})
 		if err == nil {
@@ -319,7 +316,7 @@ func (s *DashboardService) GetAdminDashboard(companyID primitive.ObjectID, userR
 					presentCount, _ = attendanceCollection.CountDocuments(ctx, bson.M{
 						"company":     companyID,
 						"date":        today,
-						"status":      models.StatusPresent,
+						"status":      bson.M{"$in": presentStatuses},
 						"employee_id": bson.M{"$in": deptEmployeeIDs},
 					})
 				}
@@ -372,7 +369,7 @@ func (s *DashboardService) GetAdminDashboard(companyID primitive.ObjectID, userR
 			presentMonth, _ = attendanceCollection.CountDocuments(ctx, bson.M{
 				"company":     companyID,
 				"date":        bson.M{"$regex": fmt.Sprintf("^%s", monthStr)},
-				"status":      models.StatusPresent,
+				"status":      bson.M{"$in": presentStatuses},
 				"employee_id": bson.M{"$in": employeeIDs},
 			})
 
@@ -400,14 +397,15 @@ func (s *DashboardService) GetAdminDashboard(companyID primitive.ObjectID, userR
 	}
 
 	// Get leave type statistics
-	leaveTypes := []models.LeaveType{
-		models.LeaveSick,
-		models.LeaveCasual,
-		models.LeaveVacation,
+	leaveTypes, err := loadLeaveTypes(ctx, companyID)
+	if err != nil {
+		return nil, err
 	}
 
 	stats.LeaveTypeStats = make([]LeaveTypeStats, 0, len(leaveTypes))
-	for _, leaveType := range leaveTypes {
+	for _, companyLeaveType := range leaveTypes {
+		leaveType := companyLeaveType.Code
+
 		pendingType := int64(0)
 		approvedType := int64(0)
 		rejectedType := int64(0)
@@ -559,7 +557,7 @@ func (s *DashboardService) GetSuperAdminDashboard() (*SuperAdminDashboardStats,
 	// Present today
 	presentToday, err := attendanceCollection.CountDocuments(ctx, bson.M{
 		"date":   today,
-		"status": models.StatusPresent,
+		"status": bson.M{"$in": presentStatuses},
 	})
 	if err == nil {
 		stats.PresentToday = presentToday
@@ -736,7 +734,7 @@ func (s *DashboardService) GetSuperAdminDashboard() (*SuperAdminDashboardStats,
 			presentCount, err := attendanceCollection.CountDocuments(ctx, bson.M{
 				"company": company.ID,
 				"date":    today,
-				"status":  models.StatusPresent,
+				"status":  bson.M{"$in": presentStatuses},
 			})
 			if err == nil {
 				stats.DepartmentStats[i].PresentToday = presentCount
@@ -770,7 +768,7 @@ func (s *DashboardService) GetSuperAdminDashboard() (*SuperAdminDashboardStats,
 				"$gte": monthStartStr,
 				"$lte": monthEndStr,
 			},
-			"status": models.StatusPresent,
+			"status": bson.M{"$in": presentStatuses},
 		})
 
 		// Absent count for the month
@@ -797,14 +795,18 @@ func (s *DashboardService) GetSuperAdminDashboard() (*SuperAdminDashboardStats,
 		})
 	}
 
-	// Leave type statistics
-	leaveTypes := []models.LeaveType{
-		models.LeaveSick,
-		models.LeaveCasual,
-		models.LeaveVacation,
+	// Leave type statistics across all companies
+	leaveTypes, err := leavesCollection.Distinct(ctx, "leave_type", bson.M{})
+	if err != nil {
+		return nil, err
 	}
 
-	for _, leaveType := range leaveTypes {
+	for _, value := range leaveTypes {
+		leaveType, ok := value.(string)
+		if !ok {
+			continue
+		}
+
 		pending, _ := leavesCollection.CountDocuments(ctx, bson.M{
 			"leave_type": leaveType,
 			"status":     models.LeavePending,
@@ -821,7 +823,7 @@ func (s *DashboardService) GetSuperAdminDashboard() (*SuperAdminDashboardStats,
 		})
 
 		stats.LeaveTypeStats = append(stats.LeaveTypeStats, LeaveTypeStats{
-			Type:     string(leaveType),
+			Type:     leaveType,
 			Pending:  pending,
 			Approved: approved,
 			Rejected: rejected,
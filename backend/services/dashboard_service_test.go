@@ -0,0 +1,42 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"api.workzen.odoo/databases/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDashboardExcludedRoles(t *testing.T) {
+	company := primitive.NewObjectID()
+	withPermissions := func(role models.Role, permissions []models.Permission) *models.User {
+		return &models.User{ID: primitive.NewObjectID(), Role: role, Company: company, Permissions: models.NewPermissionSet(permissions)}
+	}
+
+	everyone := []models.Role{models.RoleSuperAdmin, models.RoleAdmin}
+	payroll := []models.Role{models.RoleSuperAdmin, models.RoleAdmin, models.RoleHR}
+	employees := []models.Role{models.RoleSuperAdmin, models.RoleAdmin, models.RoleHR, models.RolePayroll}
+
+	tests := []struct {
+		name string
+		user *models.User
+		want []models.Role
+	}{
+		{"admin", withPermissions(models.RoleAdmin, models.DefaultRolePermissions[models.RoleAdmin]), everyone},
+		{"HR", withPermissions(models.RoleHR, models.DefaultRolePermissions[models.RoleHR]), everyone},
+		{"payroll officer", withPermissions(models.RolePayroll, models.DefaultRolePermissions[models.RolePayroll]), payroll},
+		{"employee", withPermissions(models.RoleEmployee, models.DefaultRolePermissions[models.RoleEmployee]), employees},
+		{"employee with a custom role viewing all users", withPermissions(models.RoleEmployee, []models.Permission{models.PermUserViewAll, models.PermDashboardAdmin}), everyone},
+		{"admin with a custom role without them", withPermissions(models.RoleAdmin, []models.Permission{models.PermDashboardAdmin}), employees},
+		{"SuperAdmin", &models.User{ID: primitive.NewObjectID(), IsSuperAdmin: true}, everyone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dashboardExcludedRoles(tt.user); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dashboardExcludedRoles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -46,6 +46,7 @@ func (s *DocumentService) UploadDocument(file *multipart.FileHeader, req *Upload
 		models.DocumentCategoryPayslip,
 		models.DocumentCategoryPolicy,
 		models.DocumentCategoryReport,
+		models.DocumentCategoryMedical,
 		models.DocumentCategoryOther,
 	}
 	isValid := false
@@ -120,7 +121,7 @@ func (s *DocumentService) UploadDocument(file *multipart.FileHeader, req *Upload
 		Size:        file.Size,
 		Description: req.Description,
 		UploadedBy:  uploadedByID,
-		IsPrivate:   req.Category == string(models.DocumentCategoryPayslip),
+		IsPrivate:   categoryEnum == models.DocumentCategoryPayslip || categoryEnum == models.DocumentCategoryMedical,
 	}
 	document.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
 	document.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ListPendingApprovals retrieves the pending leaves waiting on the user: leaves at their own step or at
+// the step of someone who delegated to them, HR steps for HR officers, and every pending leave for admins
+func (s *LeaveService) ListPendingApprovals(approver *models.User, companyID primitive.ObjectID, page, limit int64) ([]LeaveWithUser, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filters := map[string]interface{}{"status": models.LeavePending}
+
+	if !can(approver, models.PermLeaveOverride) {
+		delegators, err := activeDelegators(ctx, approver.ID, helpers.FormatDate(time.Now()))
+		if err != nil {
+			return nil, 0, err
+		}
+
+		or := []bson.M{
+			{"current_approver_id": bson.M{"$in": append(delegators, approver.ID)}},
+		}
+		if can(approver, models.PermLeaveApprove) {
+			// Leaves applied before approval chains existed wait on HR
+			or = append(or,
+				bson.M{"current_approver": models.ApproverHR},
+				bson.M{"current_approver": bson.M{"$exists": false}},
+			)
+		}
+		filters["$or"] = or
+		filters["employee_id"] = bson.M{"$ne": approver.ID}
+	}
+
+	return s.ListLeaves(companyID, filters, page, limit)
+}
+
+// RunEscalationForAllCompanies passes approval steps left pending too long to the next approver
+// (used by the scheduler)
+func (s *LeaveService) RunEscalationForAllCompanies(now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	companiesCollection := databases.MongoDBDatabase.Collection(collections.Companies)
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	cursor, err := companiesCollection.Find(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"is_active":   true,
+		"is_approved": true,
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to list companies: %w", err)
+	}
+	var companies []models.Company
+	err = cursor.All(ctx, &companies)
+	cursor.Close(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, company := range companies {
+		config, err := loadLeaveConfiguration(ctx, company.ID)
+		if err != nil {
+			log.Printf("⚠️  Leave escalation failed for company %s: %v\n", company.ID.Hex(), err)
+			continue
+		}
+		if config.EscalationHours <= 0 {
+			continue
+		}
+
+		cutoff := now.Add(-time.Duration(config.EscalationHours * float64(time.Hour)))
+		cursor, err := leavesCollection.Find(ctx, bson.M{
+			"company":          company.ID,
+			"status":           models.LeavePending,
+			"current_approver": bson.M{"$in": []models.ApproverRole{models.ApproverManager, models.ApproverDepartmentHead}},
+			"step_started_at":  bson.M{"$lt": primitive.NewDateTimeFromTime(cutoff)},
+		})
+		if err != nil {
+			log.Printf("⚠️  Leave escalation failed for company %s: %v\n", company.ID.Hex(), err)
+			continue
+		}
+		var leaves []models.Leave
+		err = cursor.All(ctx, &leaves)
+		cursor.Close(ctx)
+		if err != nil {
+			log.Printf("⚠️  Leave escalation failed for company %s: %v\n", company.ID.Hex(), err)
+			continue
+		}
+
+		for i := range leaves {
+			if err := escalateLeave(ctx, &leaves[i], config.EscalationHours, now); err != nil {
+				log.Printf("⚠️  Leave escalation failed for leave %s: %v\n", leaves[i].ID.Hex(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// escalateLeave marks the current step escalated and moves the leave to the next step, adding an
+// HR step when the chain has none left
+func escalateLeave(ctx context.Context, leave *models.Leave, hours float64, now time.Time) error {
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	step := leave.CurrentStep
+	if step >= len(leave.ApprovalSteps) {
+		return errors.New("leave has no current approval step")
+	}
+
+	steps := append([]models.LeaveApprovalStep{}, leave.ApprovalSteps...)
+	steps[step].Status = models.ApprovalEscalated
+	if step+1 == len(steps) {
+		steps = append(steps, models.LeaveApprovalStep{Approver: models.ApproverHR})
+	}
+	steps[step+1].Status = models.ApprovalPending
+
+	action := models.LeaveApprovalAction{
+		Step:     step,
+		Approver: steps[step].Approver,
+		Action:   models.ApprovalEscalated,
+		At:       helpers.FormatDateTime(now),
+		Remarks:  fmt.Sprintf("Pending for more than %g hour(s)", hours),
+	}
+
+	set, unset := approverFields(steps[step+1])
+	set["approval_steps"] = steps
+	set["current_step"] = step + 1
+	set["step_started_at"] = primitive.NewDateTimeFromTime(now)
+	set["updated_at"] = primitive.NewDateTimeFromTime(now)
+
+	update := bson.M{"$set": set, "$push": bson.M{"approval_history": action}}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	_, err := leavesCollection.UpdateOne(ctx,
+		bson.M{"_id": leave.ID, "status": models.LeavePending, "current_step": step},
+		update,
+	)
+	return err
+}
+
+// resolveApprovalSteps turns the leave type's chain into the approvers of one employee's leave.
+// Steps below their day threshold, without an approver or repeating an approver are dropped;
+// a chain left empty falls back to HR.
+func resolveApprovalSteps(ctx context.Context, leaveType *models.CompanyLeaveType, employee *models.User, days float64) ([]models.LeaveApprovalStep, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+	departmentsCollection := databases.MongoDBDatabase.Collection(collections.Departments)
+
+	chain := leaveType.ApprovalChain
+	if len(chain) == 0 {
+		chain = models.DefaultApprovalChain()
+	}
+
+	steps := []models.LeaveApprovalStep{}
+	seen := map[primitive.ObjectID]bool{employee.ID: true}
+	for _, rule := range chain {
+		if rule.OverDays > 0 && days <= rule.OverDays {
+			continue
+		}
+
+		var approverID primitive.ObjectID
+		switch rule.Approver {
+		case models.ApproverHR:
+			steps = append(steps, models.LeaveApprovalStep{Approver: models.ApproverHR, Status: models.ApprovalWaiting})
+			continue
+
+		case models.ApproverManager:
+			approverID = employee.ManagerID
+
+		case models.ApproverDepartmentHead:
+			if employee.DepartmentID.IsZero() {
+				continue
+			}
+			var department models.Department
+			err := departmentsCollection.FindOne(ctx, bson.M{"_id": employee.DepartmentID}).Decode(&department)
+			if err != nil {
+				continue
+			}
+			approverID = department.HeadID
+		}
+
+		if approverID.IsZero() || seen[approverID] {
+			continue
+		}
+
+		// Inactive or removed approvers are skipped rather than leaving the leave stuck
+		count, err := usersCollection.CountDocuments(ctx, helpers.AddNotDeletedFilter(bson.M{
+			"_id":     approverID,
+			"company": employee.Company,
+			"status":  models.UserActive,
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve approver: %w", err)
+		}
+		if count == 0 {
+			continue
+		}
+
+		seen[approverID] = true
+		steps = append(steps, models.LeaveApprovalStep{Approver: rule.Approver, ApproverID: approverID, Status: models.ApprovalWaiting})
+	}
+
+	if len(steps) == 0 {
+		steps = append(steps, models.LeaveApprovalStep{Approver: models.ApproverHR, Status: models.ApprovalWaiting})
+	}
+	steps[0].Status = models.ApprovalPending
+
+	return steps, nil
+}
+
+// startLeaveApproval puts a leave at the first step of its chain
+func startLeaveApproval(leave *models.Leave, steps []models.LeaveApprovalStep, now time.Time) {
+	leave.ApprovalSteps = steps
+	leave.CurrentStep = 0
+	leave.CurrentApprover = steps[0].Approver
+	leave.CurrentApproverID = steps[0].ApproverID
+	leave.StepStartedAt = primitive.NewDateTimeFromTime(now)
+}
+
+// restartLeaveApproval resolves the chain again for a pending leave whose length changed
+func restartLeaveApproval(ctx context.Context, leave *models.Leave, days float64, now time.Time) error {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	leaveType, err := loadLeaveType(ctx, leave.Company, leave.LeaveType)
+	if err != nil {
+		return err
+	}
+
+	var employee models.User
+	err = usersCollection.FindOne(ctx, bson.M{"_id": leave.EmployeeID}).Decode(&employee)
+	if err != nil {
+		return errors.New("employee not found")
+	}
+
+	steps, err := resolveApprovalSteps(ctx, leaveType, &employee, days)
+	if err != nil {
+		return err
+	}
+
+	startLeaveApproval(leave, steps, now)
+	return nil
+}
+
+// authorizeLeaveApprover checks the user may decide the current step of a leave. It returns the
+// approver the user stands in for when acting as a delegate.
+func authorizeLeaveApprover(ctx context.Context, leave *models.Leave, approver *models.User) (primitive.ObjectID, error) {
+	admin := can(approver, models.PermLeaveOverride)
+
+	if leave.EmployeeID == approver.ID && !admin {
+		return primitive.NilObjectID, errors.New("you cannot review your own leave")
+	}
+
+	step := currentApprovalStep(leave)
+	switch {
+	case step.Approver == models.ApproverHR && can(approver, models.PermLeaveApprove):
+		return primitive.NilObjectID, nil
+
+	case !step.ApproverID.IsZero() && step.ApproverID == approver.ID:
+		return primitive.NilObjectID, nil
+
+	case !step.ApproverID.IsZero():
+		delegators, err := activeDelegators(ctx, approver.ID, helpers.FormatDate(time.Now()))
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		for _, delegatorID := range delegators {
+			if delegatorID == step.ApproverID {
+				return delegatorID, nil
+			}
+		}
+	}
+
+	// Users with leave.override may decide any step
+	if admin {
+		return primitive.NilObjectID, nil
+	}
+
+	return primitive.NilObjectID, errors.New("you are not the current approver of this leave")
+}
+
+// currentApprovalStep returns the open step of a leave; leaves applied before approval chains wait on HR
+func currentApprovalStep(leave *models.Leave) models.LeaveApprovalStep {
+	if leave.CurrentStep < len(leave.ApprovalSteps) {
+		return leave.ApprovalSteps[leave.CurrentStep]
+	}
+	return models.LeaveApprovalStep{Approver: models.ApproverHR, Status: models.ApprovalPending}
+}
+
+// approverFields sets the current approver of a leave to the step's approver
+func approverFields(step models.LeaveApprovalStep) (bson.M, bson.M) {
+	set := bson.M{"current_approver": step.Approver}
+	unset := bson.M{}
+	if step.ApproverID.IsZero() {
+		unset["current_approver_id"] = ""
+	} else {
+		set["current_approver_id"] = step.ApproverID
+	}
+	return set, unset
+}
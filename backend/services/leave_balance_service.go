@@ -0,0 +1,642 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveLeavePolicyRequest for configuring a leave type
+type SaveLeavePolicyRequest struct {
+	LeaveType        string  `json:"leave_type" validate:"required"`
+	AnnualQuota      float64 `json:"annual_quota"`
+	AccrualFrequency string  `json:"accrual_frequency"` // monthly | yearly
+	ProRata          bool    `json:"pro_rata"`
+	MaxBalance       float64 `json:"max_balance"`
+	AllowNegative    bool    `json:"allow_negative"`
+	NegativeLimit    float64 `json:"negative_limit"`
+	CarryForward     bool    `json:"carry_forward"`
+	CarryForwardMax  float64 `json:"carry_forward_max"` // 0 = no cap
+	Encashable       bool    `json:"encashable"`
+	EncashmentMax    float64 `json:"encashment_max"` // 0 = no cap
+}
+
+// AdjustLeaveBalanceRequest for a manual balance correction by HR
+type AdjustLeaveBalanceRequest struct {
+	EmployeeID string  `json:"employee_id" validate:"required"` // encrypted
+	LeaveType  string  `json:"leave_type" validate:"required"`
+	Days       float64 `json:"days" validate:"required"` // positive to credit, negative to debit
+	Remarks    string  `json:"remarks" validate:"required"`
+}
+
+// RunAccrualRequest for crediting leave up to a given date
+type RunAccrualRequest struct {
+	Date string `json:"date"` // YYYY-MM-DD, defaults to today
+}
+
+// LeaveBalance is the balance of one leave type for an employee
+type LeaveBalance struct {
+	LeaveType   models.LeaveType `json:"leave_type"`
+	AnnualQuota float64          `json:"annual_quota"`
+	Balance     float64          `json:"balance"`   // sum of ledger entries
+	Pending     float64          `json:"pending"`   // reserved by pending requests
+	Available   float64          `json:"available"` // balance - pending
+}
+
+// AccrualResult summarizes an accrual run
+type AccrualResult struct {
+	Date      string  `json:"date"`
+	Employees int     `json:"employees"`
+	Entries   int     `json:"entries"`
+	Days      float64 `json:"days"`
+}
+
+// SavePolicy creates or updates the policy of a leave type for the company
+func (s *LeaveService) SavePolicy(req *SaveLeavePolicyRequest, companyID, userID primitive.ObjectID) (*models.LeavePolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	policiesCollection := databases.MongoDBDatabase.Collection(collections.LeavePolicies)
+
+	leaveType := models.LeaveType(req.LeaveType)
+	if _, err := loadLeaveType(ctx, companyID, leaveType); err != nil {
+		return nil, err
+	}
+	if req.AnnualQuota < 0 || req.MaxBalance < 0 || req.NegativeLimit < 0 {
+		return nil, errors.New("quota, max balance and negative limit cannot be negative")
+	}
+	if req.CarryForwardMax < 0 || req.EncashmentMax < 0 {
+		return nil, errors.New("carry forward and encashment caps cannot be negative")
+	}
+
+	frequency := models.AccrualFrequency(req.AccrualFrequency)
+	if frequency == "" {
+		frequency = models.AccrualMonthly
+	}
+	if frequency != models.AccrualMonthly && frequency != models.AccrualYearly {
+		return nil, errors.New("accrual frequency must be monthly or yearly")
+	}
+
+	policy := models.LeavePolicy{
+		Company:          companyID,
+		LeaveType:        leaveType,
+		AnnualQuota:      req.AnnualQuota,
+		AccrualFrequency: frequency,
+		ProRata:          req.ProRata,
+		MaxBalance:       req.MaxBalance,
+		AllowNegative:    req.AllowNegative,
+		NegativeLimit:    req.NegativeLimit,
+		CarryForward:     req.CarryForward,
+		CarryForwardMax:  req.CarryForwardMax,
+		Encashable:       req.Encashable,
+		EncashmentMax:    req.EncashmentMax,
+	}
+	if !policy.AllowNegative {
+		policy.NegativeLimit = 0
+	}
+
+	var existing models.LeavePolicy
+	err := policiesCollection.FindOne(ctx, bson.M{"company": companyID, "leave_type": leaveType}).Decode(&existing)
+	if err == nil {
+		policy.ID = existing.ID
+		policy.CreatedAt = existing.CreatedAt
+		policy.CreatedBy = existing.CreatedBy
+		policy.UpdatedAt, policy.UpdatedBy = helpers.SetUpdatedTimestamp(userID)
+		_, err = policiesCollection.ReplaceOne(ctx, bson.M{"_id": existing.ID}, policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update leave policy: %w", err)
+		}
+	} else {
+		policy.ID = primitive.NewObjectID()
+		policy.CreatedAt, policy.CreatedBy = helpers.SetCreatedTimestamp(userID)
+		policy.UpdatedAt, policy.UpdatedBy = helpers.SetUpdatedTimestamp(userID)
+		_, err = policiesCollection.InsertOne(ctx, policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create leave policy: %w", err)
+		}
+	}
+
+	return &policy, nil
+}
+
+// ListPolicies returns the company's leave policies, with defaults for built-in types it has not configured
+func (s *LeaveService) ListPolicies(companyID primitive.ObjectID) ([]models.LeavePolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return loadLeavePolicies(ctx, companyID)
+}
+
+// GetBalances returns the balance of every leave type for an employee
+func (s *LeaveService) GetBalances(employeeID, companyID primitive.ObjectID) ([]LeaveBalance, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ledgerCollection := databases.MongoDBDatabase.Collection(collections.LeaveLedger)
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	policies, err := loadLeavePolicies(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	sumByType := func(collection *mongo.Collection, match bson.M, field string) (map[models.LeaveType]float64, error) {
+		cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: match}},
+			{{Key: "$group", Value: bson.M{"_id": "$leave_type", "total": bson.M{"$sum": field}}}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer cursor.Close(ctx)
+
+		var rows []struct {
+			LeaveType models.LeaveType `bson:"_id"`
+			Total     float64          `bson:"total"`
+		}
+		if err := cursor.All(ctx, &rows); err != nil {
+			return nil, err
+		}
+
+		totals := make(map[models.LeaveType]float64, len(rows))
+		for _, row := range rows {
+			totals[row.LeaveType] = row.Total
+		}
+		return totals, nil
+	}
+
+	balances, err := sumByType(ledgerCollection, bson.M{"employee_id": employeeID, "company": companyID}, "$days")
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute leave balance: %w", err)
+	}
+
+	pending, err := sumByType(leavesCollection, bson.M{
+		"employee_id": employeeID,
+		"company":     companyID,
+		"status":      models.LeavePending,
+	}, "$days")
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute pending leave: %w", err)
+	}
+
+	result := make([]LeaveBalance, 0, len(policies))
+	for _, policy := range policies {
+		balance := roundDays(balances[policy.LeaveType])
+		reserved := roundDays(pending[policy.LeaveType])
+		result = append(result, LeaveBalance{
+			LeaveType:   policy.LeaveType,
+			AnnualQuota: policy.AnnualQuota,
+			Balance:     balance,
+			Pending:     reserved,
+			Available:   roundDays(balance - reserved),
+		})
+	}
+
+	return result, nil
+}
+
+// ListLedger retrieves the ledger entries of an employee, newest first
+func (s *LeaveService) ListLedger(employeeID, companyID primitive.ObjectID, leaveType string, page, limit int64) ([]models.LeaveLedgerEntry, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ledgerCollection := databases.MongoDBDatabase.Collection(collections.LeaveLedger)
+
+	filter := bson.M{"employee_id": employeeID, "company": companyID}
+	if leaveType != "" {
+		filter["leave_type"] = leaveType
+	}
+
+	total, err := ledgerCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skip := (page - 1) * limit
+	findOptions := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "effective_date", Value: -1}, {Key: "created_at", Value: -1}})
+
+	cursor, err := ledgerCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.LeaveLedgerEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// AdjustBalance records a manual credit or debit on an employee's balance
+func (s *LeaveService) AdjustBalance(req *AdjustLeaveBalanceRequest, employeeID, companyID, userID primitive.ObjectID) (*models.LeaveLedgerEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	if _, err := loadLeaveType(ctx, companyID, models.LeaveType(req.LeaveType)); err != nil {
+		return nil, err
+	}
+	if req.Days == 0 {
+		return nil, errors.New("days must not be zero")
+	}
+	if req.Remarks == "" {
+		return nil, errors.New("remarks are required for adjustments")
+	}
+
+	count, err := usersCollection.CountDocuments(ctx, helpers.AddNotDeletedFilter(bson.M{"_id": employeeID, "company": companyID}))
+	if err != nil || count == 0 {
+		return nil, errors.New("employee not found")
+	}
+
+	entry, err := addLedgerEntry(ctx, models.LeaveLedgerEntry{
+		Company:    companyID,
+		EmployeeID: employeeID,
+		LeaveType:  models.LeaveType(req.LeaveType),
+		EntryType:  models.LedgerAdjustment,
+		Days:       req.Days,
+		Remarks:    req.Remarks,
+	}, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// RunAccrual credits every accrual period up to the given date for all employees of a company.
+// Periods already credited are skipped, so the run can be repeated safely.
+func (s *LeaveService) RunAccrual(req *RunAccrualRequest, companyID, userID primitive.ObjectID) (*AccrualResult, error) {
+	asOf := time.Now()
+	if req.Date != "" {
+		date, err := helpers.ParseDate(req.Date)
+		if err != nil {
+			return nil, errors.New("invalid date format")
+		}
+		asOf = date
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	return accrueCompany(ctx, companyID, asOf, userID)
+}
+
+// RunAccrualForAllCompanies runs the accrual for every active company (used by the scheduler)
+func (s *LeaveService) RunAccrualForAllCompanies(asOf time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	companiesCollection := databases.MongoDBDatabase.Collection(collections.Companies)
+
+	cursor, err := companiesCollection.Find(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"is_active":   true,
+		"is_approved": true,
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to list companies: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var companies []models.Company
+	if err = cursor.All(ctx, &companies); err != nil {
+		return err
+	}
+
+	for _, company := range companies {
+		if _, err := accrueCompany(ctx, company.ID, asOf, primitive.NilObjectID); err != nil {
+			log.Printf("⚠️  Leave accrual failed for company %s: %v\n", company.ID.Hex(), err)
+		}
+	}
+
+	return nil
+}
+
+// accrueCompany credits all due accrual periods for the active employees of a company
+func accrueCompany(ctx context.Context, companyID primitive.ObjectID, asOf time.Time, userID primitive.ObjectID) (*AccrualResult, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	policies, err := loadLeavePolicies(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := usersCollection.Find(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"company": companyID,
+		"status":  models.UserActive,
+		"role":    bson.M{"$ne": models.RoleSuperAdmin},
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list employees: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var employees []models.User
+	if err = cursor.All(ctx, &employees); err != nil {
+		return nil, err
+	}
+
+	result := &AccrualResult{Date: helpers.FormatDate(asOf), Employees: len(employees)}
+	for i := range employees {
+		for j := range policies {
+			entries, days, err := accrueEmployee(ctx, &policies[j], &employees[i], asOf, userID)
+			if err != nil {
+				return result, err
+			}
+			result.Entries += entries
+			result.Days += days
+		}
+	}
+	result.Days = roundDays(result.Days)
+
+	return result, nil
+}
+
+// accrualPeriod is one creditable period of a policy
+type accrualPeriod struct {
+	Key   string // YYYY-MM or YYYY
+	Start time.Time
+	End   time.Time
+}
+
+// accrueEmployee credits every period of the current year that is due by asOf and not yet credited
+func accrueEmployee(ctx context.Context, policy *models.LeavePolicy, employee *models.User, asOf time.Time, userID primitive.ObjectID) (int, float64, error) {
+	if policy.AnnualQuota <= 0 {
+		return 0, 0, nil
+	}
+
+	ledgerCollection := databases.MongoDBDatabase.Collection(collections.LeaveLedger)
+
+	joinDate := employee.CreatedAt.Time()
+	if employee.DateOfJoin != "" {
+		if parsed, err := helpers.ParseDate(employee.DateOfJoin); err == nil {
+			joinDate = parsed
+		}
+	}
+	joinDate = time.Date(joinDate.Year(), joinDate.Month(), joinDate.Day(), 0, 0, 0, 0, time.UTC)
+	asOf = time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+
+	var periods []accrualPeriod
+	yearStart := time.Date(asOf.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	if policy.AccrualFrequency == models.AccrualYearly {
+		periods = append(periods, accrualPeriod{
+			Key:   yearStart.Format("2006"),
+			Start: yearStart,
+			End:   yearStart.AddDate(1, 0, -1),
+		})
+	} else {
+		for month := yearStart; !month.After(asOf); month = month.AddDate(0, 1, 0) {
+			periods = append(periods, accrualPeriod{
+				Key:   month.Format("2006-01"),
+				Start: month,
+				End:   month.AddDate(0, 1, -1),
+			})
+		}
+	}
+
+	entries := 0
+	credited := 0.0
+	for _, period := range periods {
+		if joinDate.After(period.End) || joinDate.After(asOf) {
+			continue
+		}
+
+		exists, err := ledgerCollection.CountDocuments(ctx, bson.M{
+			"employee_id": employee.ID,
+			"leave_type":  policy.LeaveType,
+			"entry_type":  models.LedgerAccrual,
+			"period":      period.Key,
+		})
+		if err != nil {
+			return entries, credited, err
+		}
+		if exists > 0 {
+			continue
+		}
+
+		credit := policy.AnnualQuota
+		if policy.AccrualFrequency != models.AccrualYearly {
+			credit = policy.AnnualQuota / 12
+		}
+
+		// Pro-rate the period the employee joined in
+		if policy.ProRata && joinDate.After(period.Start) {
+			if policy.AccrualFrequency == models.AccrualYearly {
+				monthsLeft := 12 - int(joinDate.Month()) + 1
+				credit = credit * float64(monthsLeft) / 12
+			} else {
+				daysInPeriod := period.End.Day()
+				credit = credit * float64(daysInPeriod-joinDate.Day()+1) / float64(daysInPeriod)
+			}
+			credit = math.Round(credit*2) / 2
+		}
+
+		if policy.MaxBalance > 0 {
+			balance, err := ledgerBalance(ctx, employee.ID, policy.LeaveType)
+			if err != nil {
+				return entries, credited, err
+			}
+			if balance+credit > policy.MaxBalance {
+				credit = policy.MaxBalance - balance
+			}
+		}
+		credit = roundDays(credit)
+		if credit <= 0 {
+			continue
+		}
+
+		_, err = addLedgerEntry(ctx, models.LeaveLedgerEntry{
+			Company:       employee.Company,
+			EmployeeID:    employee.ID,
+			LeaveType:     policy.LeaveType,
+			EntryType:     models.LedgerAccrual,
+			Days:          credit,
+			Period:        period.Key,
+			EffectiveDate: helpers.FormatDate(period.Start),
+			Remarks:       fmt.Sprintf("%s accrual for %s", policy.AccrualFrequency, period.Key),
+		}, userID)
+		if mongo.IsDuplicateKeyError(err) {
+			// A concurrent run credited the period first
+			continue
+		}
+		if err != nil {
+			return entries, credited, err
+		}
+
+		entries++
+		credited += credit
+	}
+
+	return entries, credited, nil
+}
+
+// addLedgerEntry inserts a ledger entry with timestamps, defaulting its effective date to today
+func addLedgerEntry(ctx context.Context, entry models.LeaveLedgerEntry, userID primitive.ObjectID) (*models.LeaveLedgerEntry, error) {
+	ledgerCollection := databases.MongoDBDatabase.Collection(collections.LeaveLedger)
+
+	entry.ID = primitive.NewObjectID()
+	entry.Days = roundDays(entry.Days)
+	if entry.EffectiveDate == "" {
+		entry.EffectiveDate = helpers.FormatDate(time.Now())
+	}
+	entry.CreatedAt, entry.CreatedBy = helpers.SetCreatedTimestamp(userID)
+	entry.UpdatedAt, entry.UpdatedBy = helpers.SetUpdatedTimestamp(userID)
+
+	if _, err := ledgerCollection.InsertOne(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to record leave ledger entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// ledgerBalance sums the ledger entries of a leave type for an employee
+func ledgerBalance(ctx context.Context, employeeID primitive.ObjectID, leaveType models.LeaveType) (float64, error) {
+	ledgerCollection := databases.MongoDBDatabase.Collection(collections.LeaveLedger)
+
+	cursor, err := ledgerCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"employee_id": employeeID, "leave_type": leaveType}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$days"}}}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute leave balance: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Total float64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	return roundDays(rows[0].Total), nil
+}
+
+// pendingLeaveDays sums the days reserved by pending requests, optionally excluding one leave
+func pendingLeaveDays(ctx context.Context, employeeID primitive.ObjectID, leaveType models.LeaveType, excludeID primitive.ObjectID) (float64, error) {
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	match := bson.M{
+		"employee_id": employeeID,
+		"leave_type":  leaveType,
+		"status":      models.LeavePending,
+	}
+	if !excludeID.IsZero() {
+		match["_id"] = bson.M{"$ne": excludeID}
+	}
+
+	cursor, err := leavesCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$days"}}}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute pending leave: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Total float64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	return roundDays(rows[0].Total), nil
+}
+
+// checkLeaveBalance verifies that taking days keeps the balance within the policy limits
+func checkLeaveBalance(available, days float64, policy *models.LeavePolicy) error {
+	floor := 0.0
+	if policy.AllowNegative {
+		floor = -policy.NegativeLimit
+	}
+
+	if available-days < floor {
+		return fmt.Errorf("insufficient %s leave balance: %.1f day(s) available", policy.LeaveType, math.Max(available-floor, 0))
+	}
+
+	return nil
+}
+
+// loadLeavePolicies returns the policies of the company's active leave types: saved policies first,
+// then the defaults for built-in types that have not been configured. Types without a policy
+// have no balance to track.
+func loadLeavePolicies(ctx context.Context, companyID primitive.ObjectID) ([]models.LeavePolicy, error) {
+	policiesCollection := databases.MongoDBDatabase.Collection(collections.LeavePolicies)
+
+	leaveTypes, err := loadLeaveTypes(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := policiesCollection.Find(ctx, bson.M{"company": companyID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load leave policies: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var saved []models.LeavePolicy
+	if err = cursor.All(ctx, &saved); err != nil {
+		return nil, err
+	}
+
+	byType := make(map[models.LeaveType]models.LeavePolicy, len(saved))
+	for _, policy := range models.DefaultLeavePolicies(companyID) {
+		byType[policy.LeaveType] = policy
+	}
+	for _, policy := range saved {
+		byType[policy.LeaveType] = policy
+	}
+
+	policies := make([]models.LeavePolicy, 0, len(byType))
+	for _, leaveType := range leaveTypes {
+		if policy, ok := byType[leaveType.Code]; ok && leaveType.IsActive {
+			policies = append(policies, policy)
+		}
+	}
+
+	return policies, nil
+}
+
+// loadLeavePolicy returns the effective policy of one leave type, or nil if its balance is not tracked
+func loadLeavePolicy(ctx context.Context, companyID primitive.ObjectID, leaveType models.LeaveType) (*models.LeavePolicy, error) {
+	policies, err := loadLeavePolicies(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range policies {
+		if policies[i].LeaveType == leaveType {
+			return &policies[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func roundDays(days float64) float64 {
+	return math.Round(days*100) / 100
+}
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/encryptions"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Calendar scopes, decided by the viewer's role
+const (
+	CalendarScopeCompany    = "company"    // HR and admins
+	CalendarScopeDepartment = "department" // department heads: their departments
+	CalendarScopeTeam       = "team"       // everyone else: their manager, peers and direct reports
+)
+
+const (
+	calendarMaxDays = 366
+
+	// Window of a subscribed calendar feed around today
+	calendarFeedPastDays   = 90
+	calendarFeedFutureDays = 365
+)
+
+// LeaveCalendar is the pending and approved leave visible to a user over a date range
+type LeaveCalendar struct {
+	From   string
+	To     string
+	Scope  string
+	Leaves []LeaveWithUser
+}
+
+// GetLeaveCalendar returns the pending and approved leave overlapping a date range for the viewer's
+// scope. HR and admins may narrow the company down to one department.
+func (s *LeaveService) GetLeaveCalendar(viewer *models.User, companyID primitive.ObjectID, from, to string, departmentID primitive.ObjectID) (*LeaveCalendar, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	if from == "" {
+		from = helpers.FormatDate(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()))
+	}
+	fromDate, err := helpers.ParseDate(from)
+	if err != nil {
+		return nil, errors.New("invalid from date format")
+	}
+	if to == "" {
+		to = helpers.FormatDate(fromDate.AddDate(0, 1, -1))
+	}
+	toDate, err := helpers.ParseDate(to)
+	if err != nil {
+		return nil, errors.New("invalid to date format")
+	}
+	if toDate.Before(fromDate) {
+		return nil, errors.New("to date must be after from date")
+	}
+	if toDate.Sub(fromDate).Hours()/24 >= calendarMaxDays {
+		return nil, fmt.Errorf("the calendar covers at most %d days", calendarMaxDays)
+	}
+
+	scope, employeeIDs, err := calendarScope(ctx, viewer, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !departmentID.IsZero() {
+		if scope != CalendarScopeCompany {
+			return nil, errors.New("only HR and admins can filter the calendar by department")
+		}
+		employeeIDs, err = departmentMemberIDs(ctx, companyID, []primitive.ObjectID{departmentID})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	leaves, err := calendarLeaves(ctx, companyID, employeeIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LeaveCalendar{From: from, To: to, Scope: scope, Leaves: leaves}, nil
+}
+
+// CreateCalendarFeedToken issues a new secret for the user's iCalendar feed, replacing any previous one.
+// Only its hash is stored, so the token is shown once.
+func (s *LeaveService) CreateCalendarFeedToken(userID primitive.ObjectID) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	token, err := helpers.GenerateSecretToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate calendar token: %w", err)
+	}
+	tokenHash, err := encryptions.Hash256(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash calendar token: %w", err)
+	}
+
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(userID)
+	result, err := usersCollection.UpdateOne(ctx,
+		helpers.AddNotDeletedFilter(bson.M{"_id": userID}),
+		bson.M{"$set": bson.M{
+			"calendar_token_hash": tokenHash,
+			"updated_at":          updatedAt,
+			"updated_by":          updatedBy,
+		}},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		return "", errors.New("failed to create calendar feed")
+	}
+
+	return token, nil
+}
+
+// RevokeCalendarFeedToken disables the user's iCalendar feed
+func (s *LeaveService) RevokeCalendarFeedToken(userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(userID)
+	_, err := usersCollection.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{
+			"$set":   bson.M{"updated_at": updatedAt, "updated_by": updatedBy},
+			"$unset": bson.M{"calendar_token_hash": ""},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke calendar feed: %w", err)
+	}
+
+	return nil
+}
+
+// GetCalendarFeed renders the iCalendar feed of the user owning the token: the leave of their
+// calendar scope from 90 days ago to a year ahead, pending leave marked tentative
+func (s *LeaveService) GetCalendarFeed(token string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	if token == "" {
+		return nil, errors.New("calendar feed not found")
+	}
+	tokenHash, err := encryptions.Hash256(token)
+	if err != nil {
+		return nil, errors.New("calendar feed not found")
+	}
+
+	var user models.User
+	err = usersCollection.FindOne(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"calendar_token_hash": tokenHash,
+		"status":              models.UserActive,
+	})).Decode(&user)
+	if err != nil {
+		return nil, errors.New("calendar feed not found")
+	}
+
+	scope, employeeIDs, err := calendarScope(ctx, &user, user.Company)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	from := helpers.FormatDate(now.AddDate(0, 0, -calendarFeedPastDays))
+	to := helpers.FormatDate(now.AddDate(0, 0, calendarFeedFutureDays))
+	leaves, err := calendarLeaves(ctx, user.Company, employeeIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	leaveTypes, err := loadLeaveTypes(ctx, user.Company)
+	if err != nil {
+		return nil, err
+	}
+	typeNames := make(map[models.LeaveType]string, len(leaveTypes))
+	for _, leaveType := range leaveTypes {
+		typeNames[leaveType.Code] = leaveType.Name
+	}
+
+	events := make([]helpers.ICalEvent, 0, len(leaves))
+	for _, leave := range leaves {
+		name := "Employee"
+		if leave.User != nil {
+			name = strings.TrimSpace(leave.User.FirstName + " " + leave.User.LastName)
+		}
+		typeName := typeNames[leave.LeaveType]
+		if typeName == "" {
+			typeName = string(leave.LeaveType)
+		}
+
+		summary := fmt.Sprintf("%s - %s", name, typeName)
+		switch {
+		case leave.Duration.IsHalfDay():
+			summary += " (" + strings.ReplaceAll(string(leave.Duration), "_", " ") + ")"
+		case leave.Duration == models.LeaveHourly:
+			summary += fmt.Sprintf(" (%gh)", leave.Hours)
+		}
+		if leave.Status == models.LeavePending {
+			summary += " [pending]"
+		}
+
+		events = append(events, helpers.ICalEvent{
+			UID:       leave.ID.Hex() + "@workzen",
+			Summary:   summary,
+			StartDate: leave.StartDate,
+			EndDate:   leave.EndDate,
+			Tentative: leave.Status == models.LeavePending,
+			Updated:   leave.UpdatedAt.Time(),
+		})
+	}
+
+	return helpers.ExportICal("WorkZen leave ("+scope+")", events), nil
+}
+
+// calendarScope decides whose leave a user may see on the calendar. A nil list means the whole company.
+func calendarScope(ctx context.Context, viewer *models.User, companyID primitive.ObjectID) (string, []primitive.ObjectID, error) {
+	if can(viewer, models.PermLeaveViewAll) {
+		return CalendarScopeCompany, nil, nil
+	}
+
+	departmentsCollection := databases.MongoDBDatabase.Collection(collections.Departments)
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	cursor, err := departmentsCollection.Find(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"company": companyID,
+		"head_id": viewer.ID,
+	}))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load departments: %w", err)
+	}
+	var departments []models.Department
+	err = cursor.All(ctx, &departments)
+	cursor.Close(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(departments) > 0 {
+		departmentIDs := make([]primitive.ObjectID, 0, len(departments))
+		for _, department := range departments {
+			departmentIDs = append(departmentIDs, department.ID)
+		}
+		employeeIDs, err := departmentMemberIDs(ctx, companyID, departmentIDs)
+		if err != nil {
+			return "", nil, err
+		}
+		return CalendarScopeDepartment, append(employeeIDs, viewer.ID), nil
+	}
+
+	team := []bson.M{{"_id": viewer.ID}, {"manager_id": viewer.ID}}
+	if !viewer.ManagerID.IsZero() {
+		team = append(team, bson.M{"_id": viewer.ManagerID}, bson.M{"manager_id": viewer.ManagerID})
+	}
+
+	cursor, err = usersCollection.Find(ctx,
+		helpers.AddNotDeletedFilter(bson.M{"company": companyID, "$or": team}),
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load team: %w", err)
+	}
+	var members []models.User
+	err = cursor.All(ctx, &members)
+	cursor.Close(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	employeeIDs := make([]primitive.ObjectID, 0, len(members))
+	for _, member := range members {
+		employeeIDs = append(employeeIDs, member.ID)
+	}
+	return CalendarScopeTeam, employeeIDs, nil
+}
+
+// departmentMemberIDs returns the IDs of the users in the departments
+func departmentMemberIDs(ctx context.Context, companyID primitive.ObjectID, departmentIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	cursor, err := usersCollection.Find(ctx,
+		helpers.AddNotDeletedFilter(bson.M{"company": companyID, "department_id": bson.M{"$in": departmentIDs}}),
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load department members: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var members []models.User
+	if err = cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.ID)
+	}
+	return ids, nil
+}
+
+// calendarLeaves loads the pending and approved leave overlapping a date range with the employees
+// populated, limited to the given employees unless the list is nil
+func calendarLeaves(ctx context.Context, companyID primitive.ObjectID, employeeIDs []primitive.ObjectID, from, to string) ([]LeaveWithUser, error) {
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	match := bson.M{
+		"company":    companyID,
+		"status":     bson.M{"$in": []models.LeaveStatus{models.LeavePending, models.LeaveApproved}},
+		"start_date": bson.M{"$lte": to},
+		"end_date":   bson.M{"$gte": from},
+	}
+	if employeeIDs != nil {
+		match["employee_id"] = bson.M{"$in": employeeIDs}
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$sort": bson.D{{Key: "start_date", Value: 1}, {Key: "employee_id", Value: 1}}},
+		{
+			"$lookup": bson.M{
+				"from":         collections.Users,
+				"localField":   "employee_id",
+				"foreignField": "_id",
+				"as":           "user",
+			},
+		},
+		{
+			"$unwind": bson.M{
+				"path":                       "$user",
+				"preserveNullAndEmptyArrays": true,
+			},
+		},
+	}
+
+	cursor, err := leavesCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load calendar: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	leaves := []LeaveWithUser{}
+	if err = cursor.All(ctx, &leaves); err != nil {
+		return nil, err
+	}
+
+	return leaves, nil
+}
@@ -0,0 +1,598 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CancelLeaveRequest for withdrawing a leave, entirely or from a date on
+type CancelLeaveRequest struct {
+	FromDate string `json:"from_date"` // YYYY-MM-DD, cancel from this date on (returning early); empty cancels the whole leave
+	Reason   string `json:"reason" validate:"required"`
+}
+
+// ModifyLeaveRequest for moving a leave to new dates
+type ModifyLeaveRequest struct {
+	StartDate string `json:"start_date" validate:"required"` // YYYY-MM-DD
+	EndDate   string `json:"end_date" validate:"required"`   // YYYY-MM-DD
+	Reason    string `json:"reason" validate:"required"`
+}
+
+// ReviewLeaveChangeRequest for approving or rejecting a change to an approved leave
+type ReviewLeaveChangeRequest struct {
+	Remarks string `json:"remarks"`
+}
+
+// CancelLeave cancels an employee's own leave. Pending leave is cancelled at once;
+// approved leave gets a change request that HR or Admin must approve.
+func (s *LeaveService) CancelLeave(leaveID, employeeID primitive.ObjectID, req *CancelLeaveRequest) (*models.Leave, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if req.Reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	leave, err := loadOwnLeave(ctx, leaveID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	change := models.LeaveChange{Type: models.LeaveChangeCancel, Reason: req.Reason}
+
+	if req.FromDate != "" {
+		if leave.Duration != "" && leave.Duration != models.LeaveFullDay {
+			return nil, errors.New("only full-day leave can be partially cancelled")
+		}
+		fromDate, err := helpers.ParseDate(req.FromDate)
+		if err != nil {
+			return nil, errors.New("invalid from date format")
+		}
+		if req.FromDate <= leave.StartDate || req.FromDate > leave.EndDate {
+			return nil, errors.New("from date must be after the start date and within the leave")
+		}
+
+		startDate, _ := helpers.ParseDate(leave.StartDate)
+		change.Type = models.LeaveChangeShorten
+		change.StartDate = leave.StartDate
+		change.EndDate = helpers.FormatDate(fromDate.AddDate(0, 0, -1))
+		change.Days = fromDate.Sub(startDate).Hours() / 24
+	}
+
+	return requestLeaveChange(ctx, leave, change, employeeID)
+}
+
+// ModifyLeave moves an employee's own leave to new dates, under the same rules as applying
+func (s *LeaveService) ModifyLeave(leaveID, employeeID primitive.ObjectID, req *ModifyLeaveRequest) (*models.Leave, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	if req.Reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	leave, err := loadOwnLeave(ctx, leaveID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate, err := helpers.ParseDate(req.StartDate)
+	if err != nil {
+		return nil, errors.New("invalid start date format")
+	}
+	endDate, err := helpers.ParseDate(req.EndDate)
+	if err != nil {
+		return nil, errors.New("invalid end date format")
+	}
+	if endDate.Before(startDate) {
+		return nil, errors.New("end date must be after start date")
+	}
+	if req.StartDate == leave.StartDate && req.EndDate == leave.EndDate {
+		return nil, errors.New("the leave already has these dates")
+	}
+
+	leaveType, err := loadLeaveType(ctx, leave.Company, leave.LeaveType)
+	if err != nil {
+		return nil, err
+	}
+
+	durationReq := &ApplyLeaveRequest{Duration: string(leave.Duration), Hours: leave.Hours}
+	duration, days, err := leaveDuration(ctx, durationReq, leave.EmployeeID, leave.Company, leave.ID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLeaveOverlap(ctx, leave.EmployeeID, req.StartDate, req.EndDate, duration, leave.ID); err != nil {
+		return nil, err
+	}
+
+	// Notice and tenure apply to a new start date; an unchanged start only needs the length checked
+	if req.StartDate != leave.StartDate {
+		var employee models.User
+		err = usersCollection.FindOne(ctx, bson.M{"_id": leave.EmployeeID}).Decode(&employee)
+		if err != nil {
+			return nil, errors.New("employee not found")
+		}
+		if err := checkLeaveEligibility(leaveType, &employee, startDate, days); err != nil {
+			return nil, err
+		}
+	} else if leaveType.MaxConsecutiveDays > 0 && days > float64(leaveType.MaxConsecutiveDays) {
+		return nil, fmt.Errorf("%s cannot exceed %d consecutive day(s)", leaveType.Name, leaveType.MaxConsecutiveDays)
+	}
+
+	if err := checkLeaveDocuments(leaveType, days, len(leave.Documents)); err != nil {
+		return nil, err
+	}
+	if err := checkLeaveChangeBalance(ctx, leave, days); err != nil {
+		return nil, err
+	}
+
+	change := models.LeaveChange{
+		Type:      models.LeaveChangeModify,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Days:      days,
+		Reason:    req.Reason,
+	}
+
+	return requestLeaveChange(ctx, leave, change, employeeID)
+}
+
+// ApproveLeaveChange applies the pending change of an approved leave
+func (s *LeaveService) ApproveLeaveChange(leaveID, companyID primitive.ObjectID, reviewer *models.User, req *ReviewLeaveChangeRequest) (*models.Leave, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	var leave models.Leave
+	err := leavesCollection.FindOne(ctx, bson.M{"_id": leaveID, "company": companyID}).Decode(&leave)
+	if err != nil {
+		return nil, errors.New("leave not found")
+	}
+	if leave.PendingChange == nil {
+		return nil, errors.New("leave has no pending change")
+	}
+	if err := authorizeLeaveChangeReviewer(ctx, &leave, reviewer); err != nil {
+		return nil, err
+	}
+
+	change := *leave.PendingChange
+	if err := checkLeaveChangeAllowed(ctx, &leave, &change); err != nil {
+		return nil, err
+	}
+	if change.Type == models.LeaveChangeModify {
+		if err := checkLeaveChangeBalance(ctx, &leave, change.Days); err != nil {
+			return nil, err
+		}
+	}
+
+	change.ReviewRemarks = req.Remarks
+	if err := applyApprovedLeaveChange(ctx, &leave, &change, reviewer.ID); err != nil {
+		return nil, err
+	}
+
+	return finishLeaveChange(ctx, &leave, change, models.LeaveApproved, reviewer.ID)
+}
+
+// RejectLeaveChange discards the pending change of an approved leave, keeping the leave as it was
+func (s *LeaveService) RejectLeaveChange(leaveID, companyID primitive.ObjectID, reviewer *models.User, req *ReviewLeaveChangeRequest) (*models.Leave, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	var leave models.Leave
+	err := leavesCollection.FindOne(ctx, bson.M{"_id": leaveID, "company": companyID}).Decode(&leave)
+	if err != nil {
+		return nil, errors.New("leave not found")
+	}
+	if leave.PendingChange == nil {
+		return nil, errors.New("leave has no pending change")
+	}
+	if err := authorizeLeaveChangeReviewer(ctx, &leave, reviewer); err != nil {
+		return nil, err
+	}
+
+	change := *leave.PendingChange
+	change.ReviewRemarks = req.Remarks
+
+	return finishLeaveChange(ctx, &leave, change, models.LeaveRejected, reviewer.ID)
+}
+
+// authorizeLeaveChangeReviewer checks the user may review a change to an approved leave. The leave has
+// finished its approval chain, so the change waits on HR; nobody reviews their own change without leave.override.
+func authorizeLeaveChangeReviewer(ctx context.Context, leave *models.Leave, reviewer *models.User) error {
+	if !reviewer.IsSuperAdmin && reviewer.Company != leave.Company {
+		return errors.New("leave not found")
+	}
+
+	_, err := authorizeLeaveApprover(ctx, leave, reviewer)
+	return err
+}
+
+// requestLeaveChange applies a change to a pending leave at once, or queues it for review on an approved leave
+func requestLeaveChange(ctx context.Context, leave *models.Leave, change models.LeaveChange, userID primitive.ObjectID) (*models.Leave, error) {
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	now := time.Now()
+	change.PreviousStart = leave.StartDate
+	change.PreviousEnd = leave.EndDate
+	change.RequestedBy = userID
+	change.RequestedAt = helpers.FormatDateTime(now)
+
+	switch leave.Status {
+	case models.LeavePending:
+		// Nothing has been generated for a pending leave yet
+		return finishLeaveChange(ctx, leave, change, models.LeaveApproved, primitive.NilObjectID)
+
+	case models.LeaveApproved:
+		if leave.PendingChange != nil {
+			return nil, errors.New("a change to this leave is already waiting for review")
+		}
+		if err := checkLeaveChangeAllowed(ctx, leave, &change); err != nil {
+			return nil, err
+		}
+
+		change.Status = models.LeavePending
+		result, err := leavesCollection.UpdateOne(ctx,
+			bson.M{"_id": leave.ID, "status": models.LeaveApproved, "pending_change": nil},
+			bson.M{"$set": bson.M{
+				"pending_change": change,
+				"updated_at":     primitive.NewDateTimeFromTime(now),
+			}},
+		)
+		if err != nil || result.MatchedCount == 0 {
+			return nil, errors.New("failed to request leave change")
+		}
+
+		leave.PendingChange = &change
+		return leave, nil
+	}
+
+	return nil, fmt.Errorf("%s leave cannot be changed", leave.Status)
+}
+
+// finishLeaveChange records the outcome of a change on the leave and, when approved, its new dates or status
+func finishLeaveChange(ctx context.Context, leave *models.Leave, change models.LeaveChange, outcome models.LeaveStatus, reviewerID primitive.ObjectID) (*models.Leave, error) {
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	now := time.Now()
+	change.Status = outcome
+	change.ReviewedBy = reviewerID
+	change.ReviewedAt = helpers.FormatDateTime(now)
+
+	// Guard against a concurrent status change or review
+	filter := bson.M{"_id": leave.ID, "status": leave.Status}
+	if leave.PendingChange != nil {
+		filter["pending_change"] = bson.M{"$ne": nil}
+	}
+
+	set := bson.M{"updated_at": primitive.NewDateTimeFromTime(now)}
+	unset := bson.M{"pending_change": ""}
+	if outcome == models.LeaveApproved {
+		if change.Type == models.LeaveChangeCancel {
+			set["status"] = models.LeaveCancelled
+			leave.Status = models.LeaveCancelled
+		} else {
+			set["start_date"] = change.StartDate
+			set["end_date"] = change.EndDate
+			set["days"] = change.Days
+			leave.StartDate = change.StartDate
+			leave.EndDate = change.EndDate
+			leave.Days = change.Days
+
+			// The new length may cross the leave type's document threshold, and the due date follows the end date
+			if err := leaveDocumentFields(ctx, leave, set, unset); err != nil {
+				return nil, err
+			}
+
+			// A pending leave of a new length may need a different chain, so its approval starts over
+			if leave.Status == models.LeavePending {
+				if err := restartLeaveApproval(ctx, leave, change.Days, now); err != nil {
+					return nil, err
+				}
+				approverSet, approverUnset := approverFields(leave.ApprovalSteps[0])
+				for key, value := range approverSet {
+					set[key] = value
+				}
+				for key := range approverUnset {
+					unset[key] = ""
+				}
+				set["approval_steps"] = leave.ApprovalSteps
+				set["current_step"] = leave.CurrentStep
+				set["step_started_at"] = leave.StepStartedAt
+			}
+		}
+	}
+
+	result, err := leavesCollection.UpdateOne(ctx, filter, bson.M{
+		"$set":   set,
+		"$unset": unset,
+		"$push":  bson.M{"change_history": change},
+	})
+	if err != nil || result.MatchedCount == 0 {
+		return nil, errors.New("failed to update leave")
+	}
+
+	leave.PendingChange = nil
+	leave.ChangeHistory = append(leave.ChangeHistory, change)
+	return leave, nil
+}
+
+// applyApprovedLeaveChange moves the balance and attendance of an approved leave to its new dates
+func applyApprovedLeaveChange(ctx context.Context, leave *models.Leave, change *models.LeaveChange, reviewerID primitive.ObjectID) error {
+	now := time.Now()
+
+	// Reverse what the leave consumed and consume the new length, if the leave was tracked
+	consumed, err := leaveLedgerNet(ctx, leave.ID)
+	if err != nil {
+		return err
+	}
+	if consumed != 0 {
+		_, err = addLedgerEntry(ctx, models.LeaveLedgerEntry{
+			Company:    leave.Company,
+			EmployeeID: leave.EmployeeID,
+			LeaveType:  leave.LeaveType,
+			EntryType:  models.LedgerReversal,
+			Days:       -consumed,
+			LeaveID:    leave.ID,
+			Remarks:    fmt.Sprintf("Leave %s to %s %s", leave.StartDate, leave.EndDate, leaveChangeVerb(change.Type)),
+		}, reviewerID)
+		if err != nil {
+			return err
+		}
+
+		if change.Type != models.LeaveChangeCancel {
+			_, err = addLedgerEntry(ctx, models.LeaveLedgerEntry{
+				Company:       leave.Company,
+				EmployeeID:    leave.EmployeeID,
+				LeaveType:     leave.LeaveType,
+				EntryType:     models.LedgerConsumption,
+				Days:          -change.Days,
+				LeaveID:       leave.ID,
+				EffectiveDate: change.StartDate,
+				Remarks:       fmt.Sprintf("Leave %s to %s", change.StartDate, change.EndDate),
+			}, reviewerID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	removed, _ := leaveChangeDates(leave, change)
+	if err := removeLeaveAttendance(ctx, leave, removed, now); err != nil {
+		return err
+	}
+
+	if change.Type != models.LeaveChangeCancel {
+		return markLeaveAttendance(ctx, leave, change.StartDate, change.EndDate, now)
+	}
+
+	return nil
+}
+
+// checkLeaveChangeAllowed refuses changes to an approved leave that would touch past days or paid months
+func checkLeaveChangeAllowed(ctx context.Context, leave *models.Leave, change *models.LeaveChange) error {
+	if leave.Status != models.LeaveApproved {
+		return nil
+	}
+
+	removed, added := leaveChangeDates(leave, change)
+
+	today := helpers.FormatDate(time.Now())
+	for _, date := range removed {
+		if date < today {
+			return errors.New("leave days before today cannot be cancelled, raise a regularization request instead")
+		}
+	}
+
+	seen := make(map[string]bool)
+	months := []string{}
+	for _, date := range append(removed, added...) {
+		if month := date[:7]; !seen[month] {
+			seen[month] = true
+			months = append(months, month)
+		}
+	}
+	sort.Strings(months)
+
+	month, err := lockedPayrunMonth(ctx, leave.Company, months)
+	if err != nil {
+		return err
+	}
+	if month != "" {
+		return fmt.Errorf("payroll for %s has already been run, the leave can no longer be changed", month)
+	}
+
+	return nil
+}
+
+// checkLeaveChangeBalance verifies the balance covers the new length of a leave
+func checkLeaveChangeBalance(ctx context.Context, leave *models.Leave, days float64) error {
+	if leave.Unpaid {
+		return nil
+	}
+
+	policy, err := loadLeavePolicy(ctx, leave.Company, leave.LeaveType)
+	if err != nil || policy == nil {
+		return err
+	}
+
+	balance, err := ledgerBalance(ctx, leave.EmployeeID, leave.LeaveType)
+	if err != nil {
+		return err
+	}
+	consumed, err := leaveLedgerNet(ctx, leave.ID)
+	if err != nil {
+		return err
+	}
+	pending, err := pendingLeaveDays(ctx, leave.EmployeeID, leave.LeaveType, leave.ID)
+	if err != nil {
+		return err
+	}
+
+	// consumed is negative for an approved leave, so its days become available again
+	return checkLeaveBalance(balance-consumed-pending, days, policy)
+}
+
+// leaveChangeDates returns the dates a change drops from the leave and the dates it adds
+func leaveChangeDates(leave *models.Leave, change *models.LeaveChange) ([]string, []string) {
+	current := dateRange(leave.StartDate, leave.EndDate)
+	next := []string{}
+	if change.Type != models.LeaveChangeCancel {
+		next = dateRange(change.StartDate, change.EndDate)
+	}
+
+	inCurrent := make(map[string]bool, len(current))
+	for _, date := range current {
+		inCurrent[date] = true
+	}
+	inNext := make(map[string]bool, len(next))
+	for _, date := range next {
+		inNext[date] = true
+	}
+
+	removed := []string{}
+	for _, date := range current {
+		if !inNext[date] {
+			removed = append(removed, date)
+		}
+	}
+	added := []string{}
+	for _, date := range next {
+		if !inCurrent[date] {
+			added = append(added, date)
+		}
+	}
+
+	return removed, added
+}
+
+// removeLeaveAttendance undoes the attendance a leave generated on the given dates.
+// Records with a check-in fall back to present; the others are deleted.
+func removeLeaveAttendance(ctx context.Context, leave *models.Leave, dates []string, now time.Time) error {
+	if len(dates) == 0 || leave.Duration == models.LeaveHourly {
+		return nil
+	}
+
+	attendanceCollection := databases.MongoDBDatabase.Collection(collections.Attendances)
+
+	// Records written before leave_id was stored are matched by their on_leave status
+	cursor, err := attendanceCollection.Find(ctx, bson.M{
+		"employee_id": leave.EmployeeID,
+		"date":        bson.M{"$in": dates},
+		"$or": []bson.M{
+			{"leave_id": leave.ID},
+			{"leave_id": bson.M{"$exists": false}, "status": models.StatusOnLeave},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load leave attendance: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []models.Attendance
+	if err = cursor.All(ctx, &records); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if record.CheckIn == "" {
+			_, err = attendanceCollection.DeleteOne(ctx, bson.M{"_id": record.ID})
+		} else {
+			_, err = attendanceCollection.UpdateOne(ctx, bson.M{"_id": record.ID}, bson.M{
+				"$set": bson.M{
+					"status":     models.StatusPresent,
+					"remarks":    "Leave cancelled",
+					"updated_at": primitive.NewDateTimeFromTime(now),
+				},
+				"$unset": bson.M{"leave_id": "", "leave_half": ""},
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update leave attendance: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// leaveLedgerNet sums the ledger entries recorded for a leave (negative while it consumes balance)
+func leaveLedgerNet(ctx context.Context, leaveID primitive.ObjectID) (float64, error) {
+	ledgerCollection := databases.MongoDBDatabase.Collection(collections.LeaveLedger)
+
+	cursor, err := ledgerCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"leave_id": leaveID}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$days"}}}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute leave consumption: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Total float64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	return roundDays(rows[0].Total), nil
+}
+
+// loadOwnLeave fetches a leave that belongs to the employee
+func loadOwnLeave(ctx context.Context, leaveID, employeeID primitive.ObjectID) (*models.Leave, error) {
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	var leave models.Leave
+	err := leavesCollection.FindOne(ctx, bson.M{"_id": leaveID, "employee_id": employeeID}).Decode(&leave)
+	if err != nil {
+		return nil, errors.New("leave not found")
+	}
+
+	return &leave, nil
+}
+
+// dateRange lists the dates from start to end inclusive
+func dateRange(start, end string) []string {
+	startDate, err := helpers.ParseDate(start)
+	if err != nil {
+		return nil
+	}
+	endDate, err := helpers.ParseDate(end)
+	if err != nil {
+		return nil
+	}
+
+	dates := []string{}
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, helpers.FormatDate(d))
+	}
+	return dates
+}
+
+func leaveChangeVerb(changeType models.LeaveChangeType) string {
+	switch changeType {
+	case models.LeaveChangeCancel:
+		return "cancelled"
+	case models.LeaveChangeShorten:
+		return "shortened"
+	}
+	return "modified"
+}
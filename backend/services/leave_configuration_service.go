@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SaveLeaveConfigurationRequest for company leave rules
+type SaveLeaveConfigurationRequest struct {
+	ShortLeaveMonthlyHours *float64 `json:"short_leave_monthly_hours"` // 0 disables hourly leave
+	ShortLeaveMaxHours     *float64 `json:"short_leave_max_hours"`
+	MinStaffingPercent     *float64 `json:"min_staffing_percent"`   // 0 disables the team staffing warning
+	EscalationHours        *float64 `json:"escalation_hours"`       // 0 disables approval escalation
+	CompOffValidityDays    *int     `json:"comp_off_validity_days"` // 0 keeps comp-off credits until used
+}
+
+// SaveConfiguration creates or updates the leave configuration of a company
+func (s *LeaveService) SaveConfiguration(req *SaveLeaveConfigurationRequest, companyID, userID primitive.ObjectID) (*models.LeaveConfiguration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	configCollection := databases.MongoDBDatabase.Collection(collections.LeaveConfigurations)
+
+	config, err := loadLeaveConfiguration(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ShortLeaveMonthlyHours != nil {
+		if *req.ShortLeaveMonthlyHours < 0 || *req.ShortLeaveMonthlyHours > 100 {
+			return nil, errors.New("short leave monthly hours must be between 0 and 100")
+		}
+		config.ShortLeaveMonthlyHours = *req.ShortLeaveMonthlyHours
+	}
+	if req.ShortLeaveMaxHours != nil {
+		if *req.ShortLeaveMaxHours <= 0 || *req.ShortLeaveMaxHours > 24 {
+			return nil, errors.New("short leave max hours must be between 0 and 24")
+		}
+		config.ShortLeaveMaxHours = *req.ShortLeaveMaxHours
+	}
+	if req.MinStaffingPercent != nil {
+		if *req.MinStaffingPercent < 0 || *req.MinStaffingPercent > 100 {
+			return nil, errors.New("min staffing percent must be between 0 and 100")
+		}
+		config.MinStaffingPercent = *req.MinStaffingPercent
+	}
+	if req.EscalationHours != nil {
+		if *req.EscalationHours < 0 || *req.EscalationHours > 720 {
+			return nil, errors.New("escalation hours must be between 0 and 720")
+		}
+		config.EscalationHours = *req.EscalationHours
+	}
+	if req.CompOffValidityDays != nil {
+		if *req.CompOffValidityDays < 0 || *req.CompOffValidityDays > 366 {
+			return nil, errors.New("comp-off validity days must be between 0 and 366")
+		}
+		config.CompOffValidityDays = *req.CompOffValidityDays
+	}
+
+	if !config.ID.IsZero() {
+		config.UpdatedAt, config.UpdatedBy = helpers.SetUpdatedTimestamp(userID)
+		_, err = configCollection.ReplaceOne(ctx, bson.M{"_id": config.ID}, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update leave configuration: %w", err)
+		}
+	} else {
+		config.ID = primitive.NewObjectID()
+		config.CreatedAt, config.CreatedBy = helpers.SetCreatedTimestamp(userID)
+		config.UpdatedAt, config.UpdatedBy = helpers.SetUpdatedTimestamp(userID)
+		_, err = configCollection.InsertOne(ctx, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create leave configuration: %w", err)
+		}
+	}
+
+	return &config, nil
+}
+
+// GetConfiguration retrieves the leave configuration of a company, falling back to defaults
+func (s *LeaveService) GetConfiguration(companyID primitive.ObjectID) (*models.LeaveConfiguration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	config, err := loadLeaveConfiguration(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// loadLeaveConfiguration returns the saved configuration or the defaults when none exists
+func loadLeaveConfiguration(ctx context.Context, companyID primitive.ObjectID) (models.LeaveConfiguration, error) {
+	configCollection := databases.MongoDBDatabase.Collection(collections.LeaveConfigurations)
+
+	var config models.LeaveConfiguration
+	err := configCollection.FindOne(ctx, bson.M{"company": companyID}).Decode(&config)
+	if err == mongo.ErrNoDocuments {
+		return models.DefaultLeaveConfiguration(companyID), nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("failed to load leave configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+// shortLeaveHoursUsed sums the hourly leave of an employee in a month that is pending or approved,
+// optionally excluding one leave
+func shortLeaveHoursUsed(ctx context.Context, employeeID primitive.ObjectID, month string, excludeID primitive.ObjectID) (float64, error) {
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	match := bson.M{
+		"employee_id": employeeID,
+		"duration":    models.LeaveHourly,
+		"status":      bson.M{"$in": []models.LeaveStatus{models.LeavePending, models.LeaveApproved}},
+		"start_date":  bson.M{"$regex": "^" + month},
+	}
+	if !excludeID.IsZero() {
+		match["_id"] = bson.M{"$ne": excludeID}
+	}
+
+	cursor, err := leavesCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$hours"}}}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute short leave hours: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Total float64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	return rows[0].Total, nil
+}
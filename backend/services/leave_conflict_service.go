@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrLeaveConflicts is returned when approving a leave would collide with attendance or team staffing
+// and the approver has not acknowledged it
+var ErrLeaveConflicts = errors.New("approving this leave conflicts with existing attendance or team staffing")
+
+// LeaveAttendanceConflict is a leave day on which the employee already has attendance
+type LeaveAttendanceConflict struct {
+	Date     string                  `json:"date"`
+	Status   models.AttendanceStatus `json:"status"`
+	CheckIn  string                  `json:"check_in,omitempty"`
+	CheckOut string                  `json:"check_out,omitempty"`
+}
+
+// TeamStaffingDay is the availability of the employee's team on one leave day
+type TeamStaffingDay struct {
+	Date             string   `json:"date"`
+	TeamSize         int      `json:"team_size"`         // including the employee
+	AlreadyOff       float64  `json:"already_off"`       // members on approved leave, half days count 0.5
+	OffMembers       []string `json:"off_members"`       // names of the members already off
+	AvailablePercent float64  `json:"available_percent"` // left available once this leave is approved
+	BelowMinimum     bool     `json:"below_minimum"`
+}
+
+// LeaveConflicts lists what approving a leave would collide with
+type LeaveConflicts struct {
+	Attendance         []LeaveAttendanceConflict `json:"attendance"`
+	Team               []TeamStaffingDay         `json:"team"`
+	MinStaffingPercent float64                   `json:"min_staffing_percent"` // 0 = staffing not checked
+}
+
+// HasConflicts reports whether the approver should be warned
+func (c *LeaveConflicts) HasConflicts() bool {
+	if len(c.Attendance) > 0 {
+		return true
+	}
+	for _, day := range c.Team {
+		if day.BelowMinimum {
+			return true
+		}
+	}
+	return false
+}
+
+// summary describes the conflicts in one line for the approver
+func (c *LeaveConflicts) summary() string {
+	parts := []string{}
+	if len(c.Attendance) > 0 {
+		parts = append(parts, fmt.Sprintf("the employee already has attendance on %d day(s)", len(c.Attendance)))
+	}
+	short := 0
+	for _, day := range c.Team {
+		if day.BelowMinimum {
+			short++
+		}
+	}
+	if short > 0 {
+		parts = append(parts, fmt.Sprintf("the team falls below %.0f%% staffing on %d day(s)", c.MinStaffingPercent, short))
+	}
+	return strings.Join(parts, " and ") + "; review the conflicts and approve again with acknowledge_conflicts"
+}
+
+// GetLeaveConflicts returns the attendance and team conflicts of a leave for HR, admins and its current approver
+func (s *LeaveService) GetLeaveConflicts(leaveID, companyID primitive.ObjectID, viewer *models.User) (*LeaveConflicts, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	var leave models.Leave
+	err := leavesCollection.FindOne(ctx, bson.M{"_id": leaveID, "company": companyID}).Decode(&leave)
+	if err != nil {
+		return nil, errors.New("leave not found")
+	}
+
+	if !can(viewer, models.PermLeaveOverride) && !can(viewer, models.PermLeaveViewAll) {
+		if _, err := authorizeLeaveApprover(ctx, &leave, viewer); err != nil {
+			return nil, err
+		}
+	}
+
+	return leaveConflicts(ctx, &leave)
+}
+
+// checkLeaveOverlap refuses a leave that overlaps another pending or approved leave of the employee.
+// Only the opposite halves of one day, or hourly leave next to a half day, may share a date.
+func checkLeaveOverlap(ctx context.Context, employeeID primitive.ObjectID, startDate, endDate string, duration models.LeaveDuration, excludeID primitive.ObjectID) error {
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	filter := bson.M{
+		"employee_id": employeeID,
+		"status":      bson.M{"$in": []models.LeaveStatus{models.LeavePending, models.LeaveApproved}},
+		"start_date":  bson.M{"$lte": endDate},
+		"end_date":    bson.M{"$gte": startDate},
+	}
+	if !excludeID.IsZero() {
+		filter["_id"] = bson.M{"$ne": excludeID}
+	}
+
+	cursor, err := leavesCollection.Find(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to check overlapping leave: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var leaves []models.Leave
+	if err = cursor.All(ctx, &leaves); err != nil {
+		return err
+	}
+
+	for _, other := range leaves {
+		if leaveDurationsOverlap(duration, other.Duration) {
+			return fmt.Errorf("leave overlaps your %s %s leave from %s to %s", other.Status, other.LeaveType, other.StartDate, other.EndDate)
+		}
+	}
+
+	return nil
+}
+
+// leaveDurationsOverlap reports whether two leaves on a shared date cover the same part of it
+func leaveDurationsOverlap(a, b models.LeaveDuration) bool {
+	if a == "" {
+		a = models.LeaveFullDay
+	}
+	if b == "" {
+		b = models.LeaveFullDay
+	}
+	if a == models.LeaveFullDay || b == models.LeaveFullDay {
+		return true
+	}
+	if a.IsHalfDay() && b.IsHalfDay() {
+		return a == b
+	}
+	// Hourly leave is capped by the monthly allowance instead
+	return false
+}
+
+// leaveConflicts collects the attendance already recorded on the leave days and the staffing of the
+// employee's team, i.e. the members sharing their department or manager
+func leaveConflicts(ctx context.Context, leave *models.Leave) (*LeaveConflicts, error) {
+	conflicts := &LeaveConflicts{
+		Attendance: []LeaveAttendanceConflict{},
+		Team:       []TeamStaffingDay{},
+	}
+
+	// Hourly leave does not take the employee off the day
+	if leave.Duration == models.LeaveHourly {
+		return conflicts, nil
+	}
+
+	attendanceCollection := databases.MongoDBDatabase.Collection(collections.Attendances)
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	// A half day keeps the check-in of the other half, so only full days conflict with attendance
+	if !leave.Duration.IsHalfDay() {
+		cursor, err := attendanceCollection.Find(ctx, helpers.AddNotDeletedFilter(bson.M{
+			"employee_id": leave.EmployeeID,
+			"date":        bson.M{"$gte": leave.StartDate, "$lte": leave.EndDate},
+			"check_in":    bson.M{"$nin": []interface{}{"", nil}},
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load attendance: %w", err)
+		}
+		var records []models.Attendance
+		err = cursor.All(ctx, &records)
+		cursor.Close(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range records {
+			conflicts.Attendance = append(conflicts.Attendance, LeaveAttendanceConflict{
+				Date:     record.Date,
+				Status:   record.Status,
+				CheckIn:  record.CheckIn,
+				CheckOut: record.CheckOut,
+			})
+		}
+	}
+
+	config, err := loadLeaveConfiguration(ctx, leave.Company)
+	if err != nil {
+		return nil, err
+	}
+	conflicts.MinStaffingPercent = config.MinStaffingPercent
+
+	var employee models.User
+	err = usersCollection.FindOne(ctx, bson.M{"_id": leave.EmployeeID}).Decode(&employee)
+	if err != nil {
+		return nil, errors.New("employee not found")
+	}
+
+	teamFilter := []bson.M{}
+	if !employee.DepartmentID.IsZero() {
+		teamFilter = append(teamFilter, bson.M{"department_id": employee.DepartmentID})
+	}
+	if !employee.ManagerID.IsZero() {
+		teamFilter = append(teamFilter, bson.M{"manager_id": employee.ManagerID})
+	}
+	if len(teamFilter) == 0 {
+		return conflicts, nil
+	}
+
+	cursor, err := usersCollection.Find(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"company": leave.Company,
+		"_id":     bson.M{"$ne": leave.EmployeeID},
+		"status":  models.UserActive,
+		"$or":     teamFilter,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load team: %w", err)
+	}
+	var members []models.User
+	err = cursor.All(ctx, &members)
+	cursor.Close(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return conflicts, nil
+	}
+
+	memberIDs := make([]primitive.ObjectID, 0, len(members))
+	names := make(map[primitive.ObjectID]string, len(members))
+	for _, member := range members {
+		memberIDs = append(memberIDs, member.ID)
+		names[member.ID] = strings.TrimSpace(member.FirstName + " " + member.LastName)
+	}
+
+	cursor, err = leavesCollection.Find(ctx, bson.M{
+		"employee_id": bson.M{"$in": memberIDs},
+		"status":      models.LeaveApproved,
+		"duration":    bson.M{"$ne": models.LeaveHourly},
+		"start_date":  bson.M{"$lte": leave.EndDate},
+		"end_date":    bson.M{"$gte": leave.StartDate},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load team leave: %w", err)
+	}
+	var teamLeaves []models.Leave
+	err = cursor.All(ctx, &teamLeaves)
+	cursor.Close(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	teamSize := len(members) + 1
+	ownShare := 1.0
+	if leave.Duration.IsHalfDay() {
+		ownShare = 0.5
+	}
+
+	for _, date := range dateRange(leave.StartDate, leave.EndDate) {
+		day := TeamStaffingDay{Date: date, TeamSize: teamSize, OffMembers: []string{}}
+		for _, other := range teamLeaves {
+			if other.StartDate > date || other.EndDate < date {
+				continue
+			}
+			if other.Duration.IsHalfDay() {
+				day.AlreadyOff += 0.5
+			} else {
+				day.AlreadyOff++
+			}
+			day.OffMembers = append(day.OffMembers, names[other.EmployeeID])
+		}
+
+		available := float64(teamSize) - day.AlreadyOff - ownShare
+		day.AvailablePercent = math.Round(available/float64(teamSize)*1000) / 10
+		day.BelowMinimum = config.MinStaffingPercent > 0 && day.AvailablePercent < config.MinStaffingPercent
+		conflicts.Team = append(conflicts.Team, day)
+	}
+
+	return conflicts, nil
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CreateLeaveDelegationRequest for handing over leave approvals while away
+type CreateLeaveDelegationRequest struct {
+	DelegateID string `json:"delegate_id" validate:"required"` // encrypted
+	StartDate  string `json:"start_date" validate:"required"`  // YYYY-MM-DD
+	EndDate    string `json:"end_date" validate:"required"`    // YYYY-MM-DD
+	Reason     string `json:"reason"`
+}
+
+// CreateDelegation lets another user of the company approve leave on the delegator's behalf between two dates
+func (s *LeaveService) CreateDelegation(req *CreateLeaveDelegationRequest, delegatorID, companyID primitive.ObjectID) (*models.LeaveDelegation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	delegationsCollection := databases.MongoDBDatabase.Collection(collections.LeaveDelegations)
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	delegateID, err := helpers.DecryptObjectID(req.DelegateID)
+	if err != nil {
+		return nil, errors.New("invalid delegate ID")
+	}
+	if delegateID == delegatorID {
+		return nil, errors.New("you cannot delegate to yourself")
+	}
+
+	startDate, err := helpers.ParseDate(req.StartDate)
+	if err != nil {
+		return nil, errors.New("invalid start date format")
+	}
+	endDate, err := helpers.ParseDate(req.EndDate)
+	if err != nil {
+		return nil, errors.New("invalid end date format")
+	}
+	if endDate.Before(startDate) {
+		return nil, errors.New("end date must be after start date")
+	}
+	if req.EndDate < helpers.FormatDate(time.Now()) {
+		return nil, errors.New("delegation must not end in the past")
+	}
+
+	count, err := usersCollection.CountDocuments(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"_id":     delegateID,
+		"company": companyID,
+		"status":  models.UserActive,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, errors.New("delegate not found")
+	}
+
+	// One delegate at a time keeps it clear who acts for the approver
+	count, err = delegationsCollection.CountDocuments(ctx, bson.M{
+		"delegator_id": delegatorID,
+		"is_active":    true,
+		"start_date":   bson.M{"$lte": req.EndDate},
+		"end_date":     bson.M{"$gte": req.StartDate},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("you already have a delegation in this period")
+	}
+
+	delegation := models.LeaveDelegation{
+		ID:          primitive.NewObjectID(),
+		Company:     companyID,
+		DelegatorID: delegatorID,
+		DelegateID:  delegateID,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		Reason:      req.Reason,
+		IsActive:    true,
+	}
+	delegation.CreatedAt, delegation.CreatedBy = helpers.SetCreatedTimestamp(delegatorID)
+	delegation.UpdatedAt, delegation.UpdatedBy = helpers.SetUpdatedTimestamp(delegatorID)
+
+	if _, err = delegationsCollection.InsertOne(ctx, delegation); err != nil {
+		return nil, fmt.Errorf("failed to create delegation: %w", err)
+	}
+
+	return &delegation, nil
+}
+
+// ListDelegations retrieves the delegations the user gave or received, newest first
+func (s *LeaveService) ListDelegations(userID, companyID primitive.ObjectID) ([]models.LeaveDelegation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	delegationsCollection := databases.MongoDBDatabase.Collection(collections.LeaveDelegations)
+
+	cursor, err := delegationsCollection.Find(ctx, bson.M{
+		"company": companyID,
+		"$or": []bson.M{
+			{"delegator_id": userID},
+			{"delegate_id": userID},
+		},
+	}, options.Find().SetSort(bson.D{{Key: "start_date", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list delegations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	delegations := []models.LeaveDelegation{}
+	if err = cursor.All(ctx, &delegations); err != nil {
+		return nil, err
+	}
+
+	return delegations, nil
+}
+
+// RevokeDelegation ends one of the user's own delegations
+func (s *LeaveService) RevokeDelegation(delegationID, delegatorID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	delegationsCollection := databases.MongoDBDatabase.Collection(collections.LeaveDelegations)
+
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(delegatorID)
+	result, err := delegationsCollection.UpdateOne(ctx,
+		bson.M{"_id": delegationID, "delegator_id": delegatorID, "is_active": true},
+		bson.M{"$set": bson.M{
+			"is_active":  false,
+			"updated_at": updatedAt,
+			"updated_by": updatedBy,
+		}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke delegation: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("delegation not found")
+	}
+
+	return nil
+}
+
+// activeDelegators returns the users who delegated their approvals to the delegate on the date
+func activeDelegators(ctx context.Context, delegateID primitive.ObjectID, date string) ([]primitive.ObjectID, error) {
+	delegationsCollection := databases.MongoDBDatabase.Collection(collections.LeaveDelegations)
+
+	cursor, err := delegationsCollection.Find(ctx, bson.M{
+		"delegate_id": delegateID,
+		"is_active":   true,
+		"start_date":  bson.M{"$lte": date},
+		"end_date":    bson.M{"$gte": date},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load delegations: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var delegations []models.LeaveDelegation
+	if err = cursor.All(ctx, &delegations); err != nil {
+		return nil, err
+	}
+
+	delegators := make([]primitive.ObjectID, 0, len(delegations))
+	for _, delegation := range delegations {
+		delegators = append(delegators, delegation.DelegatorID)
+	}
+	return delegators, nil
+}
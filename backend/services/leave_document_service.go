@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AttachLeaveDocumentsRequest for attaching supporting documents to an existing leave
+type AttachLeaveDocumentsRequest struct {
+	DocumentIDs []string `json:"document_ids" validate:"required"` // encrypted, uploaded by or for the employee
+}
+
+// AttachLeaveDocuments adds documents to the employee's own pending or approved leave, e.g. a
+// medical certificate submitted after returning to work
+func (s *LeaveService) AttachLeaveDocuments(leaveID, employeeID primitive.ObjectID, req *AttachLeaveDocumentsRequest) (*models.Leave, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	if len(req.DocumentIDs) == 0 {
+		return nil, errors.New("at least one document is required")
+	}
+
+	leave, err := loadOwnLeave(ctx, leaveID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if leave.Status != models.LeavePending && leave.Status != models.LeaveApproved {
+		return nil, fmt.Errorf("documents cannot be attached to a %s leave", leave.Status)
+	}
+
+	documentIDs, err := leaveDocumentIDs(ctx, req.DocumentIDs, leave.EmployeeID, leave.Company)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{"updated_at": primitive.NewDateTimeFromTime(time.Now())}
+	if leave.DocumentStatus != "" {
+		set["document_status"] = models.LeaveDocumentSubmitted
+	}
+
+	result, err := leavesCollection.UpdateOne(ctx,
+		bson.M{"_id": leave.ID, "status": leave.Status},
+		bson.M{
+			"$set":      set,
+			"$addToSet": bson.M{"documents": bson.M{"$each": documentIDs}},
+		},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		return nil, errors.New("failed to attach documents")
+	}
+
+	if err := leavesCollection.FindOne(ctx, bson.M{"_id": leave.ID}).Decode(leave); err != nil {
+		return nil, errors.New("leave not found")
+	}
+
+	return leave, nil
+}
+
+// RunLeaveDocumentOverdueForAllCompanies flags leaves whose supporting document was not submitted by
+// its due date (used by the scheduler)
+func (s *LeaveService) RunLeaveDocumentOverdueForAllCompanies(now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	_, err := leavesCollection.UpdateMany(ctx,
+		bson.M{
+			"status":            bson.M{"$in": []models.LeaveStatus{models.LeavePending, models.LeaveApproved}},
+			"document_status":   models.LeaveDocumentPending,
+			"document_due_date": bson.M{"$lt": helpers.FormatDate(now)},
+		},
+		bson.M{"$set": bson.M{
+			"document_status": models.LeaveDocumentOverdue,
+			"updated_at":      primitive.NewDateTimeFromTime(now),
+		}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to flag overdue leave documents: %w", err)
+	}
+
+	return nil
+}
+
+// checkLeaveDocuments refuses a leave without documents when its type needs one at once; types with a
+// submission deadline accept the document after the employee returns
+func checkLeaveDocuments(leaveType *models.CompanyLeaveType, days float64, documents int) error {
+	if documents > 0 || !leaveType.RequiresDocumentFor(days) || leaveType.DocumentDeadlineDays > 0 {
+		return nil
+	}
+	if leaveType.RequiresDocument {
+		return fmt.Errorf("%s requires a supporting document", leaveType.Name)
+	}
+
+	return fmt.Errorf("%s of more than %g day(s) requires a supporting document", leaveType.Name, leaveType.DocumentAfterDays)
+}
+
+// leaveDocumentStatus returns the document status of a leave and, while a required document is
+// missing, the date it is due by
+func leaveDocumentStatus(leaveType *models.CompanyLeaveType, days float64, endDate string, documents int) (models.LeaveDocumentStatus, string) {
+	if !leaveType.RequiresDocumentFor(days) {
+		return "", ""
+	}
+	if documents > 0 {
+		return models.LeaveDocumentSubmitted, ""
+	}
+
+	end, err := helpers.ParseDate(endDate)
+	if err != nil {
+		return models.LeaveDocumentPending, endDate
+	}
+
+	return models.LeaveDocumentPending, helpers.FormatDate(end.AddDate(0, 0, leaveType.DocumentDeadlineDays))
+}
+
+// leaveDocumentFields recomputes the document status of a leave whose dates changed
+func leaveDocumentFields(ctx context.Context, leave *models.Leave, set, unset bson.M) error {
+	leaveTypes, err := loadLeaveTypes(ctx, leave.Company)
+	if err != nil {
+		return err
+	}
+
+	var leaveType *models.CompanyLeaveType
+	for i := range leaveTypes {
+		if leaveTypes[i].Code == leave.LeaveType {
+			leaveType = &leaveTypes[i]
+			break
+		}
+	}
+	if leaveType == nil {
+		return nil
+	}
+
+	leave.DocumentStatus, leave.DocumentDueDate = leaveDocumentStatus(leaveType, leave.Days, leave.EndDate, len(leave.Documents))
+	if leave.DocumentStatus == "" {
+		unset["document_status"] = ""
+	} else {
+		set["document_status"] = leave.DocumentStatus
+	}
+	if leave.DocumentDueDate == "" {
+		unset["document_due_date"] = ""
+	} else {
+		set["document_due_date"] = leave.DocumentDueDate
+	}
+
+	return nil
+}
@@ -0,0 +1,470 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// encashmentDayDivisor turns a monthly basic salary into the pay of one encashed day
+const encashmentDayDivisor = 30
+
+// RunLeaveRolloverRequest for closing a leave year
+type RunLeaveRolloverRequest struct {
+	Year   int  `json:"year" validate:"required"` // calendar leave year to close
+	DryRun bool `json:"dry_run"`                  // compute and return the outcome without recording it
+}
+
+// RunRollover closes a leave year for the company: unused balances carry forward up to each policy's
+// cap, the excess is encashed where the policy allows it and the rest lapses. Encashment becomes a
+// payroll adjustment paid with the next payrun. A dry run returns the same outcome without saving it.
+func (s *LeaveService) RunRollover(req *RunLeaveRolloverRequest, companyID, userID primitive.ObjectID) (*models.LeaveRollover, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	rolloversCollection := databases.MongoDBDatabase.Collection(collections.LeaveRollovers)
+
+	if req.Year < 2000 || req.Year > time.Now().Year() {
+		return nil, errors.New("invalid leave year")
+	}
+	yearEnd := fmt.Sprintf("%d-12-31", req.Year)
+	if !req.DryRun && yearEnd >= helpers.FormatDate(time.Now()) {
+		return nil, fmt.Errorf("leave year %d has not ended yet, only a dry run is possible", req.Year)
+	}
+
+	rollover, err := computeRollover(ctx, companyID, req.Year)
+	if err != nil {
+		return nil, err
+	}
+	if req.DryRun {
+		rollover.Status = models.RolloverPreview
+		return rollover, nil
+	}
+
+	// Claim the year first: the unique company/year index lets only one run insert its record
+	rollover.ID = primitive.NewObjectID()
+	rollover.Status = models.RolloverRunning
+	rollover.CreatedAt, rollover.CreatedBy = helpers.SetCreatedTimestamp(userID)
+	rollover.UpdatedAt, rollover.UpdatedBy = helpers.SetUpdatedTimestamp(userID)
+
+	if _, err := rolloversCollection.InsertOne(ctx, rollover); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("leave year %d has already been rolled over or is being rolled over", req.Year)
+		}
+		return nil, fmt.Errorf("failed to record leave rollover: %w", err)
+	}
+
+	if err := writeRollover(ctx, rollover, userID); err != nil {
+		undoRollover(rollover.ID)
+		return nil, err
+	}
+
+	now := time.Now()
+	result, err := rolloversCollection.UpdateOne(ctx,
+		bson.M{"_id": rollover.ID, "status": models.RolloverRunning},
+		bson.M{"$set": bson.M{
+			"status":     models.RolloverCompleted,
+			"updated_at": primitive.NewDateTimeFromTime(now),
+			"updated_by": userID,
+		}},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		undoRollover(rollover.ID)
+		return nil, errors.New("failed to complete leave rollover")
+	}
+	rollover.Status = models.RolloverCompleted
+	rollover.UpdatedAt = primitive.NewDateTimeFromTime(now)
+
+	return rollover, nil
+}
+
+// writeRollover records the lapsed and encashed days of a claimed rollover in the leave ledger
+// and its encashment as payroll adjustments
+func writeRollover(ctx context.Context, rollover *models.LeaveRollover, userID primitive.ObjectID) error {
+	adjustmentsCollection := databases.MongoDBDatabase.Collection(collections.PayrollAdjustments)
+
+	period := fmt.Sprint(rollover.Year)
+	yearEnd := fmt.Sprintf("%d-12-31", rollover.Year)
+
+	for _, line := range rollover.Lines {
+		if line.Lapsed > 0 {
+			_, err := addLedgerEntry(ctx, models.LeaveLedgerEntry{
+				Company:       rollover.Company,
+				EmployeeID:    line.EmployeeID,
+				LeaveType:     line.LeaveType,
+				EntryType:     models.LedgerExpiry,
+				Days:          -line.Lapsed,
+				Period:        period,
+				RolloverID:    rollover.ID,
+				EffectiveDate: yearEnd,
+				Remarks:       fmt.Sprintf("Lapsed at the end of %d", rollover.Year),
+			}, userID)
+			if err != nil {
+				return err
+			}
+		}
+
+		if line.Encashed > 0 {
+			_, err := addLedgerEntry(ctx, models.LeaveLedgerEntry{
+				Company:       rollover.Company,
+				EmployeeID:    line.EmployeeID,
+				LeaveType:     line.LeaveType,
+				EntryType:     models.LedgerEncashment,
+				Days:          -line.Encashed,
+				Period:        period,
+				RolloverID:    rollover.ID,
+				EffectiveDate: yearEnd,
+				Remarks:       fmt.Sprintf("Encashed at the end of %d", rollover.Year),
+			}, userID)
+			if err != nil {
+				return err
+			}
+
+			adjustment := models.PayrollAdjustment{
+				ID:          primitive.NewObjectID(),
+				Company:     rollover.Company,
+				EmployeeID:  line.EmployeeID,
+				Code:        models.AdjustmentLeaveEncashment,
+				Description: fmt.Sprintf("Encashment of %g %s leave day(s) for %d", line.Encashed, line.LeaveType, rollover.Year),
+				Amount:      line.EncashmentAmount,
+				SourceID:    rollover.ID,
+				Status:      models.AdjustmentPending,
+			}
+			adjustment.CreatedAt, adjustment.CreatedBy = helpers.SetCreatedTimestamp(userID)
+			adjustment.UpdatedAt, adjustment.UpdatedBy = helpers.SetUpdatedTimestamp(userID)
+			if _, err := adjustmentsCollection.InsertOne(ctx, adjustment); err != nil {
+				return fmt.Errorf("failed to record leave encashment: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// undoRollover removes what a failed run wrote and releases its claim on the year. If the
+// clean-up fails the rollover stays running, which keeps the year blocked rather than letting
+// a retry write the ledger twice.
+func undoRollover(rolloverID primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	ledgerCollection := databases.MongoDBDatabase.Collection(collections.LeaveLedger)
+	adjustmentsCollection := databases.MongoDBDatabase.Collection(collections.PayrollAdjustments)
+	rolloversCollection := databases.MongoDBDatabase.Collection(collections.LeaveRollovers)
+
+	if _, err := ledgerCollection.DeleteMany(ctx, bson.M{"rollover_id": rolloverID}); err != nil {
+		log.Printf("⚠️  Failed to undo ledger entries of leave rollover %s: %v\n", rolloverID.Hex(), err)
+		return
+	}
+	if _, err := adjustmentsCollection.DeleteMany(ctx, bson.M{"source_id": rolloverID, "status": models.AdjustmentPending}); err != nil {
+		log.Printf("⚠️  Failed to undo encashment of leave rollover %s: %v\n", rolloverID.Hex(), err)
+		return
+	}
+	if _, err := rolloversCollection.DeleteOne(ctx, bson.M{"_id": rolloverID, "status": models.RolloverRunning}); err != nil {
+		log.Printf("⚠️  Failed to release leave rollover %s: %v\n", rolloverID.Hex(), err)
+	}
+}
+
+// ListRollovers returns the company's leave rollovers, newest year first
+func (s *LeaveService) ListRollovers(companyID primitive.ObjectID) ([]models.LeaveRollover, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rolloversCollection := databases.MongoDBDatabase.Collection(collections.LeaveRollovers)
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "year", Value: -1}, {Key: "created_at", Value: -1}})
+	cursor, err := rolloversCollection.Find(ctx, bson.M{"company": companyID}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leave rollovers: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	rollovers := []models.LeaveRollover{}
+	if err = cursor.All(ctx, &rollovers); err != nil {
+		return nil, err
+	}
+
+	return rollovers, nil
+}
+
+// ReverseRollover undoes a rollover by crediting back its lapsed and encashed days and cancelling
+// its encashment. It is refused once a payrun has paid any of the encashment.
+func (s *LeaveService) ReverseRollover(rolloverID, companyID, userID primitive.ObjectID) (*models.LeaveRollover, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	rolloversCollection := databases.MongoDBDatabase.Collection(collections.LeaveRollovers)
+	adjustmentsCollection := databases.MongoDBDatabase.Collection(collections.PayrollAdjustments)
+	ledgerCollection := databases.MongoDBDatabase.Collection(collections.LeaveLedger)
+
+	var rollover models.LeaveRollover
+	err := rolloversCollection.FindOne(ctx, bson.M{"_id": rolloverID, "company": companyID}).Decode(&rollover)
+	if err != nil {
+		return nil, errors.New("leave rollover not found")
+	}
+	if rollover.Status == models.RolloverRunning {
+		return nil, errors.New("leave rollover is still running")
+	}
+	if rollover.Status != models.RolloverCompleted {
+		return nil, errors.New("leave rollover has already been reversed")
+	}
+
+	count, err := adjustmentsCollection.CountDocuments(ctx, bson.M{
+		"source_id": rollover.ID,
+		"status":    models.AdjustmentApplied,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("the encashment of this rollover has already been paid in a payrun")
+	}
+
+	now := time.Now()
+	result, err := rolloversCollection.UpdateOne(ctx,
+		bson.M{"_id": rollover.ID, "status": models.RolloverCompleted},
+		bson.M{"$set": bson.M{
+			"status":      models.RolloverReversed,
+			"reversed_by": userID,
+			"reversed_at": helpers.FormatDateTime(now),
+			"updated_at":  primitive.NewDateTimeFromTime(now),
+			"updated_by":  userID,
+		}},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		return nil, errors.New("failed to reverse leave rollover")
+	}
+
+	_, err = adjustmentsCollection.UpdateMany(ctx,
+		bson.M{"source_id": rollover.ID, "status": models.AdjustmentPending},
+		bson.M{"$set": bson.M{
+			"status":     models.AdjustmentCancelled,
+			"updated_at": primitive.NewDateTimeFromTime(now),
+			"updated_by": userID,
+		}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel leave encashment: %w", err)
+	}
+
+	cursor, err := ledgerCollection.Find(ctx, bson.M{
+		"rollover_id": rollover.ID,
+		"entry_type":  bson.M{"$in": []models.LedgerEntryType{models.LedgerExpiry, models.LedgerEncashment}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rollover ledger entries: %w", err)
+	}
+	var entries []models.LeaveLedgerEntry
+	err = cursor.All(ctx, &entries)
+	cursor.Close(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		_, err := addLedgerEntry(ctx, models.LeaveLedgerEntry{
+			Company:       entry.Company,
+			EmployeeID:    entry.EmployeeID,
+			LeaveType:     entry.LeaveType,
+			EntryType:     models.LedgerReversal,
+			Days:          -entry.Days,
+			Period:        entry.Period,
+			RolloverID:    rollover.ID,
+			EffectiveDate: entry.EffectiveDate,
+			Remarks:       fmt.Sprintf("Reversed %s rollover %s", entry.Period, entry.EntryType),
+		}, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rollover.Status = models.RolloverReversed
+	rollover.ReversedBy = userID
+	rollover.ReversedAt = helpers.FormatDateTime(now)
+	return &rollover, nil
+}
+
+// computeRollover works out the year-end outcome of every tracked leave balance in the company
+func computeRollover(ctx context.Context, companyID primitive.ObjectID, year int) (*models.LeaveRollover, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+	ledgerCollection := databases.MongoDBDatabase.Collection(collections.LeaveLedger)
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+	salaryCollection := databases.MongoDBDatabase.Collection(collections.SalaryStructures)
+
+	yearStart := fmt.Sprintf("%d-01-01", year)
+	yearEnd := fmt.Sprintf("%d-12-31", year)
+
+	policies, err := loadLeavePolicies(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := usersCollection.Find(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"company": companyID,
+		"status":  models.UserActive,
+		"role":    bson.M{"$ne": models.RoleSuperAdmin},
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list employees: %w", err)
+	}
+	var employees []models.User
+	err = cursor.All(ctx, &employees)
+	cursor.Close(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type balanceKey struct {
+		EmployeeID primitive.ObjectID `bson:"employee_id"`
+		LeaveType  models.LeaveType   `bson:"leave_type"`
+	}
+
+	// Balance at year end and what was accrued during the year, from the ledger
+	cursor, err = ledgerCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"company": companyID, "effective_date": bson.M{"$lte": yearEnd}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     bson.M{"employee_id": "$employee_id", "leave_type": "$leave_type"},
+			"balance": bson.M{"$sum": "$days"},
+			"accrued": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$entry_type", models.LedgerAccrual}},
+					bson.M{"$gte": bson.A{"$effective_date", yearStart}},
+				}},
+				"$days", 0,
+			}}},
+		}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute leave balances: %w", err)
+	}
+	var ledgerRows []struct {
+		Key     balanceKey `bson:"_id"`
+		Balance float64    `bson:"balance"`
+		Accrued float64    `bson:"accrued"`
+	}
+	err = cursor.All(ctx, &ledgerRows)
+	cursor.Close(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Days taken during the year, from approved leave
+	cursor, err = leavesCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"company":    companyID,
+			"status":     models.LeaveApproved,
+			"start_date": bson.M{"$gte": yearStart, "$lte": yearEnd},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":  bson.M{"employee_id": "$employee_id", "leave_type": "$leave_type"},
+			"used": bson.M{"$sum": "$days"},
+		}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute used leave: %w", err)
+	}
+	var usedRows []struct {
+		Key  balanceKey `bson:"_id"`
+		Used float64    `bson:"used"`
+	}
+	err = cursor.All(ctx, &usedRows)
+	cursor.Close(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err = salaryCollection.Find(ctx, bson.M{"company": companyID, "is_active": true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load salary structures: %w", err)
+	}
+	var salaries []models.SalaryStructure
+	err = cursor.All(ctx, &salaries)
+	cursor.Close(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[balanceKey]float64, len(ledgerRows))
+	accrued := make(map[balanceKey]float64, len(ledgerRows))
+	for _, row := range ledgerRows {
+		balances[row.Key] = roundDays(row.Balance)
+		accrued[row.Key] = roundDays(row.Accrued)
+	}
+	used := make(map[balanceKey]float64, len(usedRows))
+	for _, row := range usedRows {
+		used[row.Key] = roundDays(row.Used)
+	}
+	basic := make(map[primitive.ObjectID]float64, len(salaries))
+	for _, salary := range salaries {
+		basic[salary.EmployeeID] = salary.BasicSalary.Amount
+	}
+
+	rollover := &models.LeaveRollover{
+		Company: companyID,
+		Year:    year,
+		Lines:   []models.LeaveRolloverLine{},
+	}
+
+	for _, employee := range employees {
+		for _, policy := range policies {
+			key := balanceKey{EmployeeID: employee.ID, LeaveType: policy.LeaveType}
+			line := models.LeaveRolloverLine{
+				EmployeeID: employee.ID,
+				LeaveType:  policy.LeaveType,
+				Accrued:    accrued[key],
+				Used:       used[key],
+				Balance:    balances[key],
+			}
+			if line.Balance == 0 && line.Accrued == 0 && line.Used == 0 {
+				continue
+			}
+
+			// Negative balances carry over as they are and are recovered from later accruals
+			remaining := math.Max(line.Balance, 0)
+			if policy.CarryForward {
+				line.CarriedForward = remaining
+				if policy.CarryForwardMax > 0 {
+					line.CarriedForward = math.Min(remaining, policy.CarryForwardMax)
+				}
+				remaining -= line.CarriedForward
+			}
+			// Encashment is paid on the basic salary, so employees without one lapse instead
+			if policy.Encashable && basic[employee.ID] > 0 {
+				line.Encashed = remaining
+				if policy.EncashmentMax > 0 {
+					line.Encashed = math.Min(remaining, policy.EncashmentMax)
+				}
+				remaining -= line.Encashed
+				line.EncashmentAmount = math.Round(basic[employee.ID]/encashmentDayDivisor*line.Encashed*100) / 100
+			}
+			line.Lapsed = roundDays(remaining)
+			line.CarriedForward = roundDays(line.CarriedForward)
+			line.Encashed = roundDays(line.Encashed)
+
+			rollover.Lines = append(rollover.Lines, line)
+			rollover.TotalCarriedForward += line.CarriedForward
+			rollover.TotalLapsed += line.Lapsed
+			rollover.TotalEncashed += line.Encashed
+			rollover.TotalEncashmentAmount += line.EncashmentAmount
+		}
+	}
+
+	rollover.TotalCarriedForward = roundDays(rollover.TotalCarriedForward)
+	rollover.TotalLapsed = roundDays(rollover.TotalLapsed)
+	rollover.TotalEncashed = roundDays(rollover.TotalEncashed)
+	rollover.TotalEncashmentAmount = math.Round(rollover.TotalEncashmentAmount*100) / 100
+
+	return rollover, nil
+}
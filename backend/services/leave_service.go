@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"api.workzen.odoo/databases"
@@ -12,6 +14,7 @@ import (
 	"api.workzen.odoo/helpers"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type LeaveService struct{}
@@ -22,10 +25,13 @@ func NewLeaveService() *LeaveService {
 
 // ApplyLeaveRequest for creating leave application
 type ApplyLeaveRequest struct {
-	LeaveType string `json:"leave_type" validate:"required"`
-	Reason    string `json:"reason" validate:"required"`
-	StartDate string `json:"start_date" validate:"required"` // YYYY-MM-DD
-	EndDate   string `json:"end_date" validate:"required"`   // YYYY-MM-DD
+	LeaveType   string   `json:"leave_type" validate:"required"` // code of a company leave type
+	Reason      string   `json:"reason" validate:"required"`
+	StartDate   string   `json:"start_date" validate:"required"` // YYYY-MM-DD
+	EndDate     string   `json:"end_date" validate:"required"`   // YYYY-MM-DD
+	Duration    string   `json:"duration"`                       // full_day (default) | first_half | second_half | hourly
+	Hours       float64  `json:"hours"`                          // hourly leave only
+	DocumentIDs []string `json:"document_ids"`                   // encrypted, required by some leave types or lengths
 }
 
 // ApplyLeave creates a new leave request
@@ -34,6 +40,7 @@ func (s *LeaveService) ApplyLeave(req *ApplyLeaveRequest, employeeID, companyID
 	defer cancel()
 
 	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
 
 	// Parse dates
 	startDate, err := helpers.ParseDate(req.StartDate)
@@ -51,22 +58,85 @@ func (s *LeaveService) ApplyLeave(req *ApplyLeaveRequest, employeeID, companyID
 	}
 
 	// Calculate days
-	days := int(endDate.Sub(startDate).Hours()/24) + 1
+	duration, days, err := leaveDuration(ctx, req, employeeID, companyID, primitive.NilObjectID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkLeaveOverlap(ctx, employeeID, req.StartDate, req.EndDate, duration, primitive.NilObjectID); err != nil {
+		return nil, err
+	}
+
+	leaveType, err := loadLeaveType(ctx, companyID, models.LeaveType(req.LeaveType))
+	if err != nil {
+		return nil, err
+	}
+
+	var employee models.User
+	err = usersCollection.FindOne(ctx, helpers.AddNotDeletedFilter(bson.M{"_id": employeeID, "company": companyID})).Decode(&employee)
+	if err != nil {
+		return nil, errors.New("employee not found")
+	}
+
+	if err := checkLeaveEligibility(leaveType, &employee, startDate, days); err != nil {
+		return nil, err
+	}
+
+	documentIDs, err := leaveDocumentIDs(ctx, req.DocumentIDs, employeeID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLeaveDocuments(leaveType, days, len(documentIDs)); err != nil {
+		return nil, err
+	}
+
+	// Validate against the balance, keeping pending requests reserved. Unpaid leave and
+	// types without a policy have no balance.
+	if leaveType.Paid {
+		policy, err := loadLeavePolicy(ctx, companyID, leaveType.Code)
+		if err != nil {
+			return nil, err
+		}
+		if policy != nil {
+			balance, err := ledgerBalance(ctx, employeeID, leaveType.Code)
+			if err != nil {
+				return nil, err
+			}
+			pending, err := pendingLeaveDays(ctx, employeeID, leaveType.Code, primitive.NilObjectID)
+			if err != nil {
+				return nil, err
+			}
+			if err := checkLeaveBalance(balance-pending, days, policy); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	steps, err := resolveApprovalSteps(ctx, leaveType, &employee, days)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create leave
 	leave := models.Leave{
 		ID:         primitive.NewObjectID(),
 		EmployeeID: employeeID,
 		Company:    companyID,
-		LeaveType:  models.LeaveType(req.LeaveType),
+		LeaveType:  leaveType.Code,
 		Reason:     req.Reason,
 		StartDate:  req.StartDate,
 		EndDate:    req.EndDate,
+		Duration:   duration,
+		Hours:      req.Hours,
 		Days:       days,
+		Unpaid:     !leaveType.Paid,
+		Documents:  documentIDs,
 		Status:     models.LeavePending,
 	}
+	leave.DocumentStatus, leave.DocumentDueDate = leaveDocumentStatus(leaveType, days, req.EndDate, len(documentIDs))
 	leave.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
 	leave.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+	startLeaveApproval(&leave, steps, time.Now())
 
 	_, err = leavesCollection.InsertOne(ctx, leave)
 	if err != nil {
@@ -79,7 +149,8 @@ func (s *LeaveService) ApplyLeave(req *ApplyLeaveRequest, employeeID, companyID
 // LeaveWithUser represents a leave with populated user data
 type LeaveWithUser struct {
 	models.Leave `bson:",inline"`
-	User         *models.User `bson:"user,omitempty" json:"user,omitempty"`
+	User         *models.User      `bson:"user,omitempty" json:"user,omitempty"`
+	Attachments  []models.Document `bson:"attachments,omitempty" json:"attachments,omitempty"` // the leave's documents
 }
 
 // ListLeaves retrieves leaves with filters and populates user data
@@ -121,6 +192,14 @@ func (s *LeaveService) ListLeaves(companyID primitive.ObjectID, filters map[stri
 				"preserveNullAndEmptyArrays": true,
 			},
 		},
+		{
+			"$lookup": bson.M{
+				"from":         collections.Documents,
+				"localField":   "documents",
+				"foreignField": "_id",
+				"as":           "attachments",
+			},
+		},
 	}
 
 	cursor, err := leavesCollection.Aggregate(ctx, pipeline)
@@ -142,8 +221,21 @@ func (s *LeaveService) ListLeaves(companyID primitive.ObjectID, filters map[stri
 	return leaves, total, nil
 }
 
-// ApproveLeave approves a leave request and updates attendance
-func (s *LeaveService) ApproveLeave(leaveID, approvedByID primitive.ObjectID) error {
+// ApproveLeaveRequest for approving a leave
+type ApproveLeaveRequest struct {
+	AcknowledgeConflicts bool   `json:"acknowledge_conflicts"` // approve despite attendance or staffing conflicts
+	Remarks              string `json:"remarks"`
+}
+
+// RejectLeaveRequest for rejecting a leave
+type RejectLeaveRequest struct {
+	Remarks string `json:"remarks"`
+}
+
+// ApproveLeave approves the current step of a leave's approval chain. The last step approves the
+// leave itself and updates attendance. Conflicts with existing attendance or team staffing block
+// the approval until the approver acknowledges them.
+func (s *LeaveService) ApproveLeave(leaveID, companyID primitive.ObjectID, approver *models.User, req *ApproveLeaveRequest) (*models.Leave, *LeaveConflicts, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -151,58 +243,163 @@ func (s *LeaveService) ApproveLeave(leaveID, approvedByID primitive.ObjectID) er
 
 	// Get leave details
 	var leave models.Leave
-	err := leavesCollection.FindOne(ctx, bson.M{"_id": leaveID}).Decode(&leave)
+	err := leavesCollection.FindOne(ctx, bson.M{"_id": leaveID, "company": companyID}).Decode(&leave)
 	if err != nil {
-		return errors.New("leave not found")
+		return nil, nil, errors.New("leave not found")
 	}
 
 	if leave.Status != models.LeavePending {
-		return errors.New("leave is not pending")
+		return nil, nil, errors.New("leave is not pending")
+	}
+
+	onBehalfOf, err := authorizeLeaveApprover(ctx, &leave, approver)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The balance may have changed since the request was made
+	var policy *models.LeavePolicy
+	if !leave.Unpaid {
+		policy, err = loadLeavePolicy(ctx, leave.Company, leave.LeaveType)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if policy != nil {
+		balance, err := ledgerBalance(ctx, leave.EmployeeID, leave.LeaveType)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := checkLeaveBalance(balance, leave.Days, policy); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	conflicts, err := leaveConflicts(ctx, &leave)
+	if err != nil {
+		return nil, nil, err
+	}
+	if conflicts.HasConflicts() && !req.AcknowledgeConflicts {
+		return nil, conflicts, fmt.Errorf("%w: %s", ErrLeaveConflicts, conflicts.summary())
 	}
 
-	// Update leave status
 	now := time.Now()
-	result, err := leavesCollection.UpdateOne(
-		ctx,
-		bson.M{"_id": leaveID},
-		bson.M{
-			"$set": bson.M{
-				"status":      models.LeaveApproved,
-				"approved_by": approvedByID,
-				"reviewed_at": helpers.FormatDateTime(now),
-				"updated_at":  primitive.NewDateTimeFromTime(now),
-			},
-		},
-	)
-	if err != nil || result.MatchedCount == 0 {
-		return errors.New("failed to approve leave")
+	step := leave.CurrentStep
+	action := models.LeaveApprovalAction{
+		Step:       step,
+		Approver:   currentApprovalStep(&leave).Approver,
+		Action:     models.ApprovalApproved,
+		By:         approver.ID,
+		OnBehalfOf: onBehalfOf,
+		At:         helpers.FormatDateTime(now),
+		Remarks:    req.Remarks,
+	}
+	filter := bson.M{"_id": leaveID, "status": models.LeavePending, "current_step": step}
+	if len(leave.ApprovalSteps) == 0 {
+		filter["current_step"] = bson.M{"$in": []interface{}{0, nil}}
 	}
 
-	// Create attendance records for leave days
-	attendanceCollection := databases.MongoDBDatabase.Collection(collections.Attendances)
-	startDate, _ := helpers.ParseDate(leave.StartDate)
-	endDate, _ := helpers.ParseDate(leave.EndDate)
+	// Intermediate steps forward the leave to the next approver
+	if step+1 < len(leave.ApprovalSteps) {
+		next := leave.ApprovalSteps[step+1]
+		set, unset := approverFields(next)
+		set[fmt.Sprintf("approval_steps.%d.status", step)] = models.ApprovalApproved
+		set[fmt.Sprintf("approval_steps.%d.status", step+1)] = models.ApprovalPending
+		set["current_step"] = step + 1
+		set["step_started_at"] = primitive.NewDateTimeFromTime(now)
+		set["updated_at"] = primitive.NewDateTimeFromTime(now)
+
+		update := bson.M{"$set": set, "$push": bson.M{"approval_history": action}}
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
 
-	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
-		attendance := models.Attendance{
-			ID:         primitive.NewObjectID(),
-			EmployeeID: leave.EmployeeID,
-			Company:    leave.Company,
-			Date:       helpers.FormatDate(d),
-			Status:     models.StatusOnLeave,
-			Remarks:    "Approved leave: " + string(leave.LeaveType),
+		result, err := leavesCollection.UpdateOne(ctx, filter, update)
+		if err != nil || result.MatchedCount == 0 {
+			return nil, nil, errors.New("failed to approve leave")
 		}
-		attendance.CreatedAt = primitive.NewDateTimeFromTime(now)
-		attendance.UpdatedAt = primitive.NewDateTimeFromTime(now)
 
-		attendanceCollection.InsertOne(ctx, attendance)
+		leave.ApprovalSteps[step].Status = models.ApprovalApproved
+		leave.ApprovalSteps[step+1].Status = models.ApprovalPending
+		leave.CurrentStep = step + 1
+		leave.CurrentApprover = next.Approver
+		leave.CurrentApproverID = next.ApproverID
+		leave.ApprovalHistory = append(leave.ApprovalHistory, action)
+		return &leave, conflicts, nil
 	}
 
-	return nil
+	// Consume the days before the leave counts as approved, so an approved leave never lacks them
+	var consumption *models.LeaveLedgerEntry
+	if policy != nil {
+		consumption, err = addLedgerEntry(ctx, models.LeaveLedgerEntry{
+			Company:       leave.Company,
+			EmployeeID:    leave.EmployeeID,
+			LeaveType:     leave.LeaveType,
+			EntryType:     models.LedgerConsumption,
+			Days:          -leave.Days,
+			LeaveID:       leave.ID,
+			EffectiveDate: leave.StartDate,
+			Remarks:       fmt.Sprintf("Leave %s to %s", leave.StartDate, leave.EndDate),
+		}, approver.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Update leave status
+	set := bson.M{
+		"status":      models.LeaveApproved,
+		"approved_by": approver.ID,
+		"reviewed_at": helpers.FormatDateTime(now),
+		"updated_at":  primitive.NewDateTimeFromTime(now),
+	}
+	if len(leave.ApprovalSteps) > 0 {
+		set[fmt.Sprintf("approval_steps.%d.status", step)] = models.ApprovalApproved
+	}
+	result, err := leavesCollection.UpdateOne(ctx, filter, bson.M{
+		"$set":   set,
+		"$unset": bson.M{"current_approver": "", "current_approver_id": ""},
+		"$push":  bson.M{"approval_history": action},
+	})
+	if err != nil || result.MatchedCount == 0 {
+		// Another approval or a withdrawal got there first; give the days back
+		if consumption != nil {
+			_, undoErr := addLedgerEntry(ctx, models.LeaveLedgerEntry{
+				Company:       leave.Company,
+				EmployeeID:    leave.EmployeeID,
+				LeaveType:     leave.LeaveType,
+				EntryType:     models.LedgerReversal,
+				Days:          leave.Days,
+				LeaveID:       leave.ID,
+				EffectiveDate: leave.StartDate,
+				Remarks:       fmt.Sprintf("Leave %s to %s not approved", leave.StartDate, leave.EndDate),
+			}, approver.ID)
+			if undoErr != nil {
+				fmt.Printf("Failed to reverse consumption of leave %s: %v\n", leave.ID.Hex(), undoErr)
+			}
+		}
+		return nil, nil, errors.New("failed to approve leave")
+	}
+
+	// Create attendance records for leave days
+	if err := markLeaveAttendance(ctx, &leave, leave.StartDate, leave.EndDate, now); err != nil {
+		return nil, nil, fmt.Errorf("leave approved but its attendance was not updated: %w", err)
+	}
+
+	leave.Status = models.LeaveApproved
+	leave.ApprovedBy = approver.ID
+	leave.ReviewedAt = helpers.FormatDateTime(now)
+	if len(leave.ApprovalSteps) > 0 {
+		leave.ApprovalSteps[step].Status = models.ApprovalApproved
+	}
+	leave.CurrentApprover = ""
+	leave.CurrentApproverID = primitive.NilObjectID
+	leave.ApprovalHistory = append(leave.ApprovalHistory, action)
+	return &leave, conflicts, nil
 }
 
-// RejectLeave rejects a leave request
-func (s *LeaveService) RejectLeave(leaveID, rejectedByID primitive.ObjectID) error {
+// RejectLeave rejects a leave at the current step of its approval chain
+func (s *LeaveService) RejectLeave(leaveID, companyID primitive.ObjectID, approver *models.User, req *RejectLeaveRequest) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -210,7 +407,7 @@ func (s *LeaveService) RejectLeave(leaveID, rejectedByID primitive.ObjectID) err
 
 	// Get leave details
 	var leave models.Leave
-	err := leavesCollection.FindOne(ctx, bson.M{"_id": leaveID}).Decode(&leave)
+	err := leavesCollection.FindOne(ctx, bson.M{"_id": leaveID, "company": companyID}).Decode(&leave)
 	if err != nil {
 		return errors.New("leave not found")
 	}
@@ -219,17 +416,39 @@ func (s *LeaveService) RejectLeave(leaveID, rejectedByID primitive.ObjectID) err
 		return errors.New("leave is not pending")
 	}
 
+	onBehalfOf, err := authorizeLeaveApprover(ctx, &leave, approver)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	step := leave.CurrentStep
+	set := bson.M{
+		"status":      models.LeaveRejected,
+		"rejected_by": approver.ID,
+		"reviewed_at": helpers.FormatDateTime(now),
+		"updated_at":  primitive.NewDateTimeFromTime(now),
+	}
+	if len(leave.ApprovalSteps) > 0 {
+		set[fmt.Sprintf("approval_steps.%d.status", step)] = models.ApprovalRejected
+	}
+
 	// Update leave status
 	result, err := leavesCollection.UpdateOne(
 		ctx,
-		bson.M{"_id": leaveID},
+		bson.M{"_id": leaveID, "status": models.LeavePending},
 		bson.M{
-			"$set": bson.M{
-				"status":      models.LeaveRejected,
-				"rejected_by": rejectedByID,
-				"reviewed_at": helpers.FormatDateTime(time.Now()),
-				"updated_at":  primitive.NewDateTimeFromTime(time.Now()),
-			},
+			"$set":   set,
+			"$unset": bson.M{"current_approver": "", "current_approver_id": ""},
+			"$push": bson.M{"approval_history": models.LeaveApprovalAction{
+				Step:       step,
+				Approver:   currentApprovalStep(&leave).Approver,
+				Action:     models.ApprovalRejected,
+				By:         approver.ID,
+				OnBehalfOf: onBehalfOf,
+				At:         helpers.FormatDateTime(now),
+				Remarks:    req.Remarks,
+			}},
 		},
 	)
 	if err != nil || result.MatchedCount == 0 {
@@ -238,3 +457,160 @@ func (s *LeaveService) RejectLeave(leaveID, rejectedByID primitive.ObjectID) err
 
 	return nil
 }
+
+// leaveDocumentIDs decrypts the documents attached to a leave and checks they belong to the employee
+func leaveDocumentIDs(ctx context.Context, encryptedIDs []string, employeeID, companyID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	if len(encryptedIDs) == 0 {
+		return nil, nil
+	}
+
+	documentsCollection := databases.MongoDBDatabase.Collection(collections.Documents)
+
+	documentIDs := make([]primitive.ObjectID, 0, len(encryptedIDs))
+	seen := make(map[primitive.ObjectID]bool, len(encryptedIDs))
+	for _, encryptedID := range encryptedIDs {
+		documentID, err := helpers.DecryptObjectID(encryptedID)
+		if err != nil {
+			return nil, errors.New("invalid document ID")
+		}
+		if !seen[documentID] {
+			seen[documentID] = true
+			documentIDs = append(documentIDs, documentID)
+		}
+	}
+
+	count, err := documentsCollection.CountDocuments(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"_id":         bson.M{"$in": documentIDs},
+		"company":     companyID,
+		"employee_id": employeeID,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if int(count) != len(documentIDs) {
+		return nil, errors.New("document not found")
+	}
+
+	return documentIDs, nil
+}
+
+// leaveDuration validates the requested duration and returns the days it consumes.
+// excludeID leaves a leave being modified out of the short leave allowance.
+func leaveDuration(ctx context.Context, req *ApplyLeaveRequest, employeeID, companyID, excludeID primitive.ObjectID, startDate, endDate time.Time) (models.LeaveDuration, float64, error) {
+	duration := models.LeaveDuration(req.Duration)
+	if duration == "" {
+		duration = models.LeaveFullDay
+	}
+
+	switch duration {
+	case models.LeaveFullDay:
+		if req.Hours != 0 {
+			return "", 0, errors.New("hours are only allowed for hourly leave")
+		}
+		return duration, endDate.Sub(startDate).Hours()/24 + 1, nil
+
+	case models.LeaveFirstHalf, models.LeaveSecondHalf:
+		if !startDate.Equal(endDate) {
+			return "", 0, errors.New("half-day leave must start and end on the same date")
+		}
+		if req.Hours != 0 {
+			return "", 0, errors.New("hours are only allowed for hourly leave")
+		}
+		return duration, 0.5, nil
+
+	case models.LeaveHourly:
+		if !startDate.Equal(endDate) {
+			return "", 0, errors.New("hourly leave must start and end on the same date")
+		}
+
+		config, err := loadLeaveConfiguration(ctx, companyID)
+		if err != nil {
+			return "", 0, err
+		}
+		used, err := shortLeaveHoursUsed(ctx, employeeID, startDate.Format("2006-01"), excludeID)
+		if err != nil {
+			return "", 0, err
+		}
+		attendanceConfig, err := loadAttendanceConfiguration(ctx, companyID)
+		if err != nil {
+			return "", 0, err
+		}
+
+		days, err := hourlyLeaveDays(req.Hours, used, attendanceConfig.DefaultWorkHours, config)
+		if err != nil {
+			return "", 0, err
+		}
+		return duration, days, nil
+	}
+
+	return "", 0, errors.New("duration must be full_day, first_half, second_half or hourly")
+}
+
+// hourlyLeaveDays checks an hourly leave against the company's short leave allowance, given the hours
+// already used in its month, and returns the days it consumes out of a working day of workHours
+func hourlyLeaveDays(hours, used, workHours float64, config models.LeaveConfiguration) (float64, error) {
+	if config.ShortLeaveMonthlyHours <= 0 {
+		return 0, errors.New("hourly leave is not enabled for your company")
+	}
+	if hours <= 0 || hours > config.ShortLeaveMaxHours {
+		return 0, fmt.Errorf("hourly leave must be between 0 and %.1f hour(s)", config.ShortLeaveMaxHours)
+	}
+	if used+hours > config.ShortLeaveMonthlyHours {
+		return 0, fmt.Errorf("monthly short leave allowance exceeded: %.1f of %.1f hour(s) left",
+			math.Max(config.ShortLeaveMonthlyHours-used, 0), config.ShortLeaveMonthlyHours)
+	}
+
+	if workHours <= 0 {
+		workHours = 8
+	}
+	if hours >= workHours {
+		return 0, errors.New("hourly leave must be shorter than a working day")
+	}
+
+	return roundDays(hours / workHours), nil
+}
+
+// markLeaveAttendance writes the attendance of an approved leave for the dates from..to.
+// Half days keep any check-in of the other half; hourly leave leaves attendance untouched.
+func markLeaveAttendance(ctx context.Context, leave *models.Leave, from, to string, now time.Time) error {
+	if leave.Duration == models.LeaveHourly {
+		return nil
+	}
+
+	attendanceCollection := databases.MongoDBDatabase.Collection(collections.Attendances)
+	startDate, _ := helpers.ParseDate(from)
+	endDate, _ := helpers.ParseDate(to)
+
+	set := bson.M{
+		"status":     models.StatusOnLeave,
+		"leave_id":   leave.ID,
+		"remarks":    "Approved leave: " + string(leave.LeaveType),
+		"updated_at": primitive.NewDateTimeFromTime(now),
+	}
+	if leave.Duration.IsHalfDay() {
+		set["status"] = models.StatusHalfDay
+		set["leave_half"] = leave.Duration
+		set["remarks"] = fmt.Sprintf("Approved %s leave: %s", strings.ReplaceAll(string(leave.Duration), "_", " "), leave.LeaveType)
+	}
+
+	// Upsert so absences already marked by the daily job are replaced
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		_, err := attendanceCollection.UpdateOne(ctx,
+			bson.M{"employee_id": leave.EmployeeID, "date": helpers.FormatDate(d)},
+			bson.M{
+				"$set": set,
+				"$setOnInsert": bson.M{
+					"company":    leave.Company,
+					"created_at": primitive.NewDateTimeFromTime(now),
+					"is_deleted": false,
+				},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to mark leave attendance for %s: %w", helpers.FormatDate(d), err)
+		}
+	}
+
+	return nil
+}
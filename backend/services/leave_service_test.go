@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"api.workzen.odoo/databases/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLeaveDuration(t *testing.T) {
+	day := func(date string) time.Time {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name         string
+		req          ApplyLeaveRequest
+		start, end   string
+		wantDuration models.LeaveDuration
+		wantDays     float64
+		wantErr      string
+	}{
+		{"full day by default", ApplyLeaveRequest{}, "2025-03-10", "2025-03-10", models.LeaveFullDay, 1, ""},
+		{"full days over a range", ApplyLeaveRequest{Duration: "full_day"}, "2025-03-10", "2025-03-14", models.LeaveFullDay, 5, ""},
+		{"full days across a month end", ApplyLeaveRequest{}, "2025-02-27", "2025-03-02", models.LeaveFullDay, 4, ""},
+		{"full day with hours", ApplyLeaveRequest{Hours: 2}, "2025-03-10", "2025-03-10", "", 0, "only allowed for hourly"},
+		{"first half", ApplyLeaveRequest{Duration: "first_half"}, "2025-03-10", "2025-03-10", models.LeaveFirstHalf, 0.5, ""},
+		{"second half", ApplyLeaveRequest{Duration: "second_half"}, "2025-03-10", "2025-03-10", models.LeaveSecondHalf, 0.5, ""},
+		{"half day over two dates", ApplyLeaveRequest{Duration: "first_half"}, "2025-03-10", "2025-03-11", "", 0, "same date"},
+		{"half day with hours", ApplyLeaveRequest{Duration: "second_half", Hours: 1}, "2025-03-10", "2025-03-10", "", 0, "only allowed for hourly"},
+		{"hourly over two dates", ApplyLeaveRequest{Duration: "hourly", Hours: 1}, "2025-03-10", "2025-03-11", "", 0, "same date"},
+		{"unknown duration", ApplyLeaveRequest{Duration: "quarter"}, "2025-03-10", "2025-03-10", "", 0, "duration must be"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duration, days, err := leaveDuration(context.Background(), &tt.req, primitive.NewObjectID(), primitive.NewObjectID(), primitive.NilObjectID, day(tt.start), day(tt.end))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if duration != tt.wantDuration || days != tt.wantDays {
+				t.Errorf("got (%s, %v), want (%s, %v)", duration, days, tt.wantDuration, tt.wantDays)
+			}
+		})
+	}
+}
+
+func TestHourlyLeaveDays(t *testing.T) {
+	config := models.LeaveConfiguration{ShortLeaveMonthlyHours: 4, ShortLeaveMaxHours: 2}
+
+	tests := []struct {
+		name      string
+		hours     float64
+		used      float64
+		workHours float64
+		config    models.LeaveConfiguration
+		wantDays  float64
+		wantErr   string
+	}{
+		{"two hours of an eight hour day", 2, 0, 8, config, 0.25, ""},
+		{"one hour of a nine hour day", 1, 0, 9, config, 0.11, ""},
+		{"unset work hours default to eight", 2, 0, 0, config, 0.25, ""},
+		{"uses up the monthly allowance", 2, 2, 8, config, 0.25, ""},
+		{"disabled for the company", 1, 0, 8, models.LeaveConfiguration{ShortLeaveMaxHours: 2}, 0, "not enabled"},
+		{"zero hours", 0, 0, 8, config, 0, "between 0 and 2.0"},
+		{"longer than a single short leave", 2.5, 0, 8, config, 0, "between 0 and 2.0"},
+		{"over the monthly allowance", 2, 3, 8, config, 0, "1.0 of 4.0 hour(s) left"},
+		{"allowance already used up", 1, 5, 8, config, 0, "0.0 of 4.0 hour(s) left"},
+		{"as long as the working day", 2, 0, 2, config, 0, "shorter than a working day"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days, err := hourlyLeaveDays(tt.hours, tt.used, tt.workHours, tt.config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if days != tt.wantDays {
+				t.Errorf("days = %v, want %v", days, tt.wantDays)
+			}
+		})
+	}
+}
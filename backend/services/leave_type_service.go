@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var leaveTypeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,29}$`)
+
+// SaveLeaveTypeRequest for creating or updating a company leave type
+type SaveLeaveTypeRequest struct {
+	Code                 string   `json:"code"` // required on create, ignored on update
+	Name                 string   `json:"name" validate:"required"`
+	Description          string   `json:"description"`
+	Paid                 *bool    `json:"paid"` // defaults to true
+	RequiresDocument     bool     `json:"requires_document"`
+	DocumentAfterDays    float64  `json:"document_after_days"`    // leaves longer than this need a document, 0 = never
+	DocumentDeadlineDays int      `json:"document_deadline_days"` // days after the leave ends to submit it, 0 = when applying
+	MinNoticeDays        int      `json:"min_notice_days"`
+	MaxConsecutiveDays   int      `json:"max_consecutive_days"`
+	EligibleGenders      []string `json:"eligible_genders"`
+	MinTenureMonths      int      `json:"min_tenure_months"`
+	IsActive             *bool    `json:"is_active"` // defaults to true
+
+	ApprovalChain []models.ApprovalStepRule `json:"approval_chain"` // empty uses the default chain
+}
+
+// ListLeaveTypes returns the company's leave types, seeding the defaults on first use
+func (s *LeaveService) ListLeaveTypes(companyID primitive.ObjectID, includeInactive bool) ([]models.CompanyLeaveType, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	leaveTypes, err := loadLeaveTypes(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if includeInactive {
+		return leaveTypes, nil
+	}
+
+	active := make([]models.CompanyLeaveType, 0, len(leaveTypes))
+	for _, leaveType := range leaveTypes {
+		if leaveType.IsActive {
+			active = append(active, leaveType)
+		}
+	}
+
+	return active, nil
+}
+
+// CreateLeaveType adds a new leave type for the company
+func (s *LeaveService) CreateLeaveType(req *SaveLeaveTypeRequest, companyID, userID primitive.ObjectID) (*models.CompanyLeaveType, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	leaveTypesCollection := databases.MongoDBDatabase.Collection(collections.LeaveTypes)
+
+	if !leaveTypeCodePattern.MatchString(req.Code) {
+		return nil, errors.New("code must be 2-30 lowercase letters, digits or underscores and start with a letter")
+	}
+
+	// Make sure the defaults exist so a custom type cannot take one of their codes first
+	if _, err := loadLeaveTypes(ctx, companyID); err != nil {
+		return nil, err
+	}
+
+	count, err := leaveTypesCollection.CountDocuments(ctx, bson.M{"company": companyID, "code": req.Code})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("a leave type with this code already exists")
+	}
+
+	leaveType := models.CompanyLeaveType{
+		ID:       primitive.NewObjectID(),
+		Company:  companyID,
+		Code:     models.LeaveType(req.Code),
+		Paid:     true,
+		IsActive: true,
+	}
+	if err := applyLeaveTypeRequest(&leaveType, req); err != nil {
+		return nil, err
+	}
+	leaveType.CreatedAt, leaveType.CreatedBy = helpers.SetCreatedTimestamp(userID)
+	leaveType.UpdatedAt, leaveType.UpdatedBy = helpers.SetUpdatedTimestamp(userID)
+
+	if _, err = leaveTypesCollection.InsertOne(ctx, leaveType); err != nil {
+		return nil, fmt.Errorf("failed to create leave type: %w", err)
+	}
+
+	return &leaveType, nil
+}
+
+// UpdateLeaveType changes the attributes of an existing leave type. The code cannot change
+// because leaves, policies and ledger entries refer to it.
+func (s *LeaveService) UpdateLeaveType(code string, req *SaveLeaveTypeRequest, companyID, userID primitive.ObjectID) (*models.CompanyLeaveType, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	leaveTypesCollection := databases.MongoDBDatabase.Collection(collections.LeaveTypes)
+
+	if _, err := loadLeaveTypes(ctx, companyID); err != nil {
+		return nil, err
+	}
+
+	var leaveType models.CompanyLeaveType
+	err := leaveTypesCollection.FindOne(ctx, bson.M{"company": companyID, "code": code}).Decode(&leaveType)
+	if err != nil {
+		return nil, errors.New("leave type not found")
+	}
+
+	if err := applyLeaveTypeRequest(&leaveType, req); err != nil {
+		return nil, err
+	}
+	leaveType.UpdatedAt, leaveType.UpdatedBy = helpers.SetUpdatedTimestamp(userID)
+
+	if _, err = leaveTypesCollection.ReplaceOne(ctx, bson.M{"_id": leaveType.ID}, leaveType); err != nil {
+		return nil, fmt.Errorf("failed to update leave type: %w", err)
+	}
+
+	return &leaveType, nil
+}
+
+// applyLeaveTypeRequest validates the request and copies it onto the leave type
+func applyLeaveTypeRequest(leaveType *models.CompanyLeaveType, req *SaveLeaveTypeRequest) error {
+	if req.Name == "" {
+		return errors.New("name is required")
+	}
+	if req.MinNoticeDays < 0 || req.MaxConsecutiveDays < 0 || req.MinTenureMonths < 0 {
+		return errors.New("notice, consecutive days and tenure cannot be negative")
+	}
+	if req.DocumentAfterDays < 0 || req.DocumentDeadlineDays < 0 {
+		return errors.New("document days and deadline cannot be negative")
+	}
+	if req.DocumentDeadlineDays > 90 {
+		return errors.New("document deadline cannot exceed 90 days")
+	}
+
+	genders := make([]models.Gender, 0, len(req.EligibleGenders))
+	for _, gender := range req.EligibleGenders {
+		if !models.IsValidGender(models.Gender(gender)) {
+			return fmt.Errorf("invalid gender %q", gender)
+		}
+		genders = append(genders, models.Gender(gender))
+	}
+
+	if len(req.ApprovalChain) > 5 {
+		return errors.New("an approval chain can have at most 5 steps")
+	}
+	approvers := make(map[models.ApproverRole]bool, len(req.ApprovalChain))
+	for _, step := range req.ApprovalChain {
+		if !models.IsValidApproverRole(step.Approver) {
+			return fmt.Errorf("invalid approver %q", step.Approver)
+		}
+		if approvers[step.Approver] {
+			return fmt.Errorf("approver %q appears twice in the chain", step.Approver)
+		}
+		if step.OverDays < 0 {
+			return errors.New("over days cannot be negative")
+		}
+		approvers[step.Approver] = true
+	}
+
+	leaveType.Name = req.Name
+	leaveType.Description = req.Description
+	leaveType.RequiresDocument = req.RequiresDocument
+	leaveType.DocumentAfterDays = req.DocumentAfterDays
+	leaveType.DocumentDeadlineDays = req.DocumentDeadlineDays
+	leaveType.MinNoticeDays = req.MinNoticeDays
+	leaveType.MaxConsecutiveDays = req.MaxConsecutiveDays
+	leaveType.EligibleGenders = genders
+	leaveType.MinTenureMonths = req.MinTenureMonths
+	leaveType.ApprovalChain = req.ApprovalChain
+	if req.Paid != nil {
+		leaveType.Paid = *req.Paid
+	}
+	if req.IsActive != nil {
+		leaveType.IsActive = *req.IsActive
+	}
+
+	return nil
+}
+
+// loadLeaveTypes returns all leave types of a company. Companies without any are seeded with the defaults.
+func loadLeaveTypes(ctx context.Context, companyID primitive.ObjectID) ([]models.CompanyLeaveType, error) {
+	leaveTypesCollection := databases.MongoDBDatabase.Collection(collections.LeaveTypes)
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := leaveTypesCollection.Find(ctx, bson.M{"company": companyID}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load leave types: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	leaveTypes := []models.CompanyLeaveType{}
+	if err = cursor.All(ctx, &leaveTypes); err != nil {
+		return nil, err
+	}
+	if len(leaveTypes) > 0 {
+		return leaveTypes, nil
+	}
+
+	// Upsert by code so concurrent first requests do not seed twice
+	for _, leaveType := range models.DefaultLeaveTypes(companyID) {
+		leaveType.ID = primitive.NewObjectID()
+		leaveType.CreatedAt, leaveType.CreatedBy = helpers.SetCreatedTimestamp(primitive.NilObjectID)
+		leaveType.UpdatedAt, leaveType.UpdatedBy = helpers.SetUpdatedTimestamp(primitive.NilObjectID)
+
+		_, err := leaveTypesCollection.UpdateOne(ctx,
+			bson.M{"company": companyID, "code": leaveType.Code},
+			bson.M{"$setOnInsert": leaveType},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to seed leave types: %w", err)
+		}
+	}
+
+	cursor, err = leaveTypesCollection.Find(ctx, bson.M{"company": companyID}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load leave types: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	if err = cursor.All(ctx, &leaveTypes); err != nil {
+		return nil, err
+	}
+
+	return leaveTypes, nil
+}
+
+// loadLeaveType returns an active leave type of the company by code
+func loadLeaveType(ctx context.Context, companyID primitive.ObjectID, code models.LeaveType) (*models.CompanyLeaveType, error) {
+	leaveTypes, err := loadLeaveTypes(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range leaveTypes {
+		if leaveTypes[i].Code == code && leaveTypes[i].IsActive {
+			return &leaveTypes[i], nil
+		}
+	}
+
+	return nil, errors.New("invalid leave type")
+}
+
+// checkLeaveEligibility applies the notice, length, gender and tenure rules of a leave type
+func checkLeaveEligibility(leaveType *models.CompanyLeaveType, employee *models.User, startDate time.Time, days float64) error {
+	today, _ := helpers.ParseDate(helpers.FormatDate(time.Now()))
+
+	if leaveType.MinNoticeDays > 0 {
+		notice := int(startDate.Sub(today).Hours() / 24)
+		if notice < leaveType.MinNoticeDays {
+			return fmt.Errorf("%s requires %d day(s) notice", leaveType.Name, leaveType.MinNoticeDays)
+		}
+	}
+
+	if leaveType.MaxConsecutiveDays > 0 && days > float64(leaveType.MaxConsecutiveDays) {
+		return fmt.Errorf("%s cannot exceed %d consecutive day(s)", leaveType.Name, leaveType.MaxConsecutiveDays)
+	}
+
+	if !leaveType.IsEligibleGender(employee.Gender) {
+		return fmt.Errorf("you are not eligible for %s", leaveType.Name)
+	}
+
+	if leaveType.MinTenureMonths > 0 {
+		joinDate := employee.CreatedAt.Time()
+		if employee.DateOfJoin != "" {
+			if parsed, err := helpers.ParseDate(employee.DateOfJoin); err == nil {
+				joinDate = parsed
+			}
+		}
+		if joinDate.AddDate(0, leaveType.MinTenureMonths, 0).After(startDate) {
+			return fmt.Errorf("%s is available after %d month(s) of service", leaveType.Name, leaveType.MinTenureMonths)
+		}
+	}
+
+	return nil
+}
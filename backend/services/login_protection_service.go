@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	loginFailureWindow   = 15 * time.Minute // failures older than this are forgotten
+	loginDelayAfter      = 3                // wrong passwords after which each further attempt waits
+	loginMaxDelay        = 30 * time.Second
+	accountLockThreshold = 10
+	accountLockDuration  = 15 * time.Minute
+	ipLockThreshold      = 50 // across all accounts, so one address cannot spray many of them
+	ipLockDuration       = 15 * time.Minute
+)
+
+// LoginThrottledError is returned while an account or IP address must wait before logging in again
+type LoginThrottledError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return e.Message
+}
+
+// UnlockUser lifts a lockout and forgets the failed logins and wrong two-factor codes of a user of the
+// admin's company
+func (s *UserService) UnlockUser(userID primitive.ObjectID, actor *models.User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	filter := bson.M{"_id": userID}
+	if !actor.IsSuperAdmin {
+		filter["company"] = actor.Company
+	}
+
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(actor.ID)
+	result, err := usersCollection.UpdateOne(ctx,
+		helpers.AddNotDeletedFilter(filter),
+		bson.M{
+			"$set":   bson.M{"updated_at": updatedAt, "updated_by": updatedBy},
+			"$unset": bson.M{"failed_logins": "", "last_failed_login": "", "locked_until": "", "two_factor_attempts": ""},
+		},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// RunLoginAttemptCleanup drops the failed-login counters of IP addresses that are neither locked nor
+// recently active (used by the scheduler)
+func (s *AuthService) RunLoginAttemptCleanup(now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	loginAttemptsCollection := databases.MongoDBDatabase.Collection(collections.LoginAttempts)
+
+	_, err := loginAttemptsCollection.DeleteMany(ctx, bson.M{
+		"last_failed_at": bson.M{"$lt": primitive.NewDateTimeFromTime(now.Add(-loginFailureWindow))},
+		"$or": []bson.M{
+			{"locked_until": bson.M{"$exists": false}},
+			{"locked_until": bson.M{"$lt": primitive.NewDateTimeFromTime(now)}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clean up login attempts: %w", err)
+	}
+
+	return nil
+}
+
+// checkIPThrottle refuses logins from an IP address locked for too many failures
+func checkIPThrottle(ctx context.Context, ipAddress string, now time.Time) error {
+	loginAttemptsCollection := databases.MongoDBDatabase.Collection(collections.LoginAttempts)
+
+	if ipAddress == "" {
+		return nil
+	}
+
+	var attempt models.LoginAttempt
+	if err := loginAttemptsCollection.FindOne(ctx, bson.M{"ip_address": ipAddress}).Decode(&attempt); err != nil {
+		return nil
+	}
+	if wait := attempt.LockedUntil.Time().Sub(now); wait > 0 {
+		return throttled("too many failed logins from this network, try again in %s", wait)
+	}
+
+	return nil
+}
+
+// checkAccountThrottle refuses a login while the account is locked or must wait after wrong passwords
+func checkAccountThrottle(user *models.User, now time.Time) error {
+	if wait := user.LockedUntil.Time().Sub(now); wait > 0 {
+		return throttled("account is temporarily locked after too many failed logins, try again in %s", wait)
+	}
+
+	if now.Sub(user.LastFailedLogin.Time()) > loginFailureWindow {
+		return nil
+	}
+	if wait := user.LastFailedLogin.Time().Add(loginDelay(user.FailedLogins)).Sub(now); wait > 0 {
+		return throttled("too many failed logins, try again in %s", wait)
+	}
+
+	return nil
+}
+
+// recordIPFailure counts a failed login from an IP address and locks it at the threshold
+func recordIPFailure(ctx context.Context, ipAddress string, now time.Time) {
+	loginAttemptsCollection := databases.MongoDBDatabase.Collection(collections.LoginAttempts)
+
+	if ipAddress == "" {
+		return
+	}
+
+	// Failures outside the window start a new count
+	update := bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$set": bson.M{"last_failed_at": primitive.NewDateTimeFromTime(now)},
+	}
+	var attempt models.LoginAttempt
+	err := loginAttemptsCollection.FindOne(ctx, bson.M{"ip_address": ipAddress}).Decode(&attempt)
+	if err != nil || now.Sub(attempt.LastFailedAt.Time()) > loginFailureWindow {
+		update = bson.M{"$set": bson.M{"failures": 1, "last_failed_at": primitive.NewDateTimeFromTime(now)}}
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err = loginAttemptsCollection.FindOneAndUpdate(ctx, bson.M{"ip_address": ipAddress}, update, opts).Decode(&attempt)
+	if err != nil || attempt.Failures < ipLockThreshold {
+		return
+	}
+
+	loginAttemptsCollection.UpdateOne(ctx,
+		bson.M{"_id": attempt.ID},
+		bson.M{"$set": bson.M{"failures": 0, "locked_until": primitive.NewDateTimeFromTime(now.Add(ipLockDuration))}},
+	)
+}
+
+// recordAccountFailure counts a wrong password for a user, locks the account at the threshold and
+// tells the user by email
+func recordAccountFailure(ctx context.Context, user *models.User, ipAddress string, now time.Time) error {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	// Failures outside the window start a new count
+	update := bson.M{
+		"$inc": bson.M{"failed_logins": 1},
+		"$set": bson.M{"last_failed_login": primitive.NewDateTimeFromTime(now)},
+	}
+	if now.Sub(user.LastFailedLogin.Time()) > loginFailureWindow {
+		update = bson.M{"$set": bson.M{"failed_logins": 1, "last_failed_login": primitive.NewDateTimeFromTime(now)}}
+	}
+
+	var updated models.User
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := usersCollection.FindOneAndUpdate(ctx, bson.M{"_id": user.ID}, update, opts).Decode(&updated); err != nil {
+		return nil
+	}
+	if updated.FailedLogins < accountLockThreshold {
+		return nil
+	}
+
+	return lockAccount(ctx, user, bson.M{"failed_logins": bson.M{"$gte": accountLockThreshold}}, bson.M{"failed_logins": ""}, ipAddress, now)
+}
+
+// lockAccount locks the user's account for accountLockDuration, unsets the counters that led to it and
+// tells the user by email. The filter on those counters makes sure only the failure that crossed the
+// threshold locks, so the email goes out once.
+func lockAccount(ctx context.Context, user *models.User, filter, unset bson.M, ipAddress string, now time.Time) error {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	lockedUntil := now.Add(accountLockDuration)
+	filter["_id"] = user.ID
+	result, err := usersCollection.UpdateOne(ctx,
+		filter,
+		bson.M{
+			"$set":   bson.M{"locked_until": primitive.NewDateTimeFromTime(lockedUntil)},
+			"$unset": unset,
+		},
+	)
+	if err != nil || result.ModifiedCount == 0 {
+		return nil
+	}
+
+	// Send account locked email (non-blocking)
+	go func() {
+		if err := helpers.SendAccountLockedEmail(user.Email, user.FirstName, ipAddress, lockedUntil); err != nil {
+			fmt.Printf("Failed to send account locked email to %s: %v\n", user.Email, err)
+		}
+	}()
+
+	return throttled("account is temporarily locked after too many failed logins, try again in %s", accountLockDuration)
+}
+
+// clearAccountFailures forgets a user's failed logins after a successful one
+func clearAccountFailures(ctx context.Context, user *models.User) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	if user.FailedLogins == 0 && user.LockedUntil == 0 {
+		return
+	}
+	usersCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{"$unset": bson.M{"failed_logins": "", "last_failed_login": "", "locked_until": ""}},
+	)
+}
+
+// loginDelay is how long an account waits after its latest wrong password: nothing for the first few,
+// then doubling from one second up to loginMaxDelay
+func loginDelay(failures int) time.Duration {
+	if failures < loginDelayAfter {
+		return 0
+	}
+	exponent := math.Min(float64(failures-loginDelayAfter), 10)
+	delay := time.Duration(math.Pow(2, exponent)) * time.Second
+	if delay > loginMaxDelay {
+		return loginMaxDelay
+	}
+	return delay
+}
+
+// throttled builds a LoginThrottledError with the wait rounded up to whole seconds
+func throttled(format string, wait time.Duration) *LoginThrottledError {
+	if wait%time.Second != 0 {
+		wait = wait.Truncate(time.Second) + time.Second
+	}
+	return &LoginThrottledError{Message: fmt.Sprintf(format, wait), RetryAfter: wait}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{loginDelayAfter - 1, 0},
+		{loginDelayAfter, time.Second},
+		{loginDelayAfter + 1, 2 * time.Second},
+		{loginDelayAfter + 2, 4 * time.Second},
+		{loginDelayAfter + 4, 16 * time.Second},
+		{loginDelayAfter + 5, loginMaxDelay},
+		{loginDelayAfter + 50, loginMaxDelay},
+	}
+
+	for _, tt := range tests {
+		if got := loginDelay(tt.failures); got != tt.want {
+			t.Errorf("loginDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestThrottled(t *testing.T) {
+	tests := []struct {
+		wait time.Duration
+		want time.Duration
+	}{
+		{time.Millisecond, time.Second},
+		{999 * time.Millisecond, time.Second},
+		{time.Second, time.Second},
+		{1500 * time.Millisecond, 2 * time.Second},
+		{14*time.Minute + 59*time.Second + time.Nanosecond, 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		err := throttled("try again in %s", tt.wait)
+		if err.RetryAfter != tt.want {
+			t.Errorf("throttled(%v).RetryAfter = %v, want %v", tt.wait, err.RetryAfter, tt.want)
+		}
+		if want := "try again in " + tt.want.String(); err.Error() != want {
+			t.Errorf("throttled(%v) message = %q, want %q", tt.wait, err.Error(), want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/encryptions"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	minPasswordLength  = 8   // no policy can ask for less
+	maxPasswordLength  = 128 // bounds the hashing work per login
+	maxPasswordHistory = 24  // earlier hashes kept per user, the most a policy can check
+	maxPasswordAgeDays = 365
+)
+
+// ExpiredPasswordRequest sets a new password during a login whose password has expired
+type ExpiredPasswordRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	NewPassword    string `json:"new_password" validate:"required"`
+}
+
+// ChangeExpiredPassword replaces an expired password with the challenge token from the login and
+// completes the login
+func (s *AuthService) ChangeExpiredPassword(req *ExpiredPasswordRequest, client LoginClient) (*LoginResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	user, err := loadLoginChallenge(ctx, req.ChallengeToken, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// The account may have changed since the password step
+	company, err := checkLoginAllowed(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPasswordPolicy(req.NewPassword, company.PasswordPolicy(), user); err != nil {
+		return nil, err
+	}
+
+	// The challenge is single-use, even under concurrent requests
+	update := passwordUpdate(user, req.NewPassword,
+		bson.M{"timestamp.updated_at": primitive.NewDateTimeFromTime(time.Now())},
+		loginChallengeFields,
+	)
+	result, err := usersCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "two_factor_challenge": user.TwoFactorChallenge},
+		update,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update password: %w", err)
+	}
+	if result.ModifiedCount == 0 {
+		return nil, errors.New("invalid or expired challenge")
+	}
+
+	if err := revokeSessions(ctx, bson.M{"user_id": user.ID}, models.SessionPasswordChange); err != nil {
+		return nil, err
+	}
+
+	return completeLogin(ctx, user, company, client)
+}
+
+// startPasswordChangeChallenge ends a login whose password has expired with a challenge token that
+// only works to set a new password
+func startPasswordChangeChallenge(ctx context.Context, user *models.User) (*LoginResponse, error) {
+	token, err := startLoginChallenge(ctx, user, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{PasswordChangeRequired: true, ChallengeToken: token}, nil
+}
+
+// loadPasswordPolicy returns the password policy of the user's company
+func loadPasswordPolicy(ctx context.Context, user *models.User) (models.PasswordPolicy, error) {
+	company, err := loadUserCompany(ctx, user)
+	if err != nil {
+		return models.PasswordPolicy{}, err
+	}
+	return company.PasswordPolicy(), nil
+}
+
+// checkPasswordPolicy rejects a new password for the user that breaks the policy. For signup, the
+// user is the one about to be created.
+func checkPasswordPolicy(password string, policy models.PasswordPolicy, user *models.User) error {
+	minLength := max(policy.MinLength, minPasswordLength)
+	if len(password) < minLength {
+		return fmt.Errorf("password must be at least %d characters", minLength)
+	}
+	if len(password) > maxPasswordLength {
+		return fmt.Errorf("password must be at most %d characters", maxPasswordLength)
+	}
+	if strings.TrimSpace(password) == "" {
+		return errors.New("password cannot be blank")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if policy.RequireUppercase && !hasUpper {
+		return errors.New("password must contain an uppercase letter")
+	}
+	if policy.RequireLowercase && !hasLower {
+		return errors.New("password must contain a lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		return errors.New("password must contain a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		return errors.New("password must contain a symbol")
+	}
+
+	if policy.DisallowCommon && helpers.IsCommonPassword(password) {
+		return errors.New("password is too common, choose a less predictable one")
+	}
+	if policy.DisallowPersonalInfo {
+		lower := strings.ToLower(password)
+		for _, part := range personalInfoParts(user) {
+			if strings.Contains(lower, part) {
+				return errors.New("password must not contain your name, username or email")
+			}
+		}
+	}
+
+	if policy.HistoryCount > 0 {
+		// The current password counts as the latest one
+		recent := append([]string{user.Password}, user.PasswordHistory...)
+		for _, hash := range recent[:min(policy.HistoryCount, len(recent))] {
+			if hash != "" && encryptions.ComparePassword(password, hash) {
+				return fmt.Errorf("password must not be one of your last %d passwords", policy.HistoryCount)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkPasswordPolicyRules rejects a policy with settings outside the supported range
+func checkPasswordPolicyRules(policy *models.PasswordPolicy) error {
+	if policy.MinLength == 0 {
+		policy.MinLength = minPasswordLength
+	}
+	if policy.MinLength < minPasswordLength || policy.MinLength > maxPasswordLength {
+		return fmt.Errorf("minimum length must be between %d and %d", minPasswordLength, maxPasswordLength)
+	}
+	if policy.HistoryCount < 0 || policy.HistoryCount > maxPasswordHistory {
+		return fmt.Errorf("password history must be between 0 and %d", maxPasswordHistory)
+	}
+	if policy.MaxAgeDays < 0 || policy.MaxAgeDays > maxPasswordAgeDays {
+		return fmt.Errorf("maximum password age must be between 0 and %d days", maxPasswordAgeDays)
+	}
+	return nil
+}
+
+// passwordExpired reports whether the user's password is older than the policy allows
+func passwordExpired(user *models.User, policy models.PasswordPolicy, now time.Time) bool {
+	if policy.MaxAgeDays <= 0 {
+		return false
+	}
+
+	changedAt := user.PasswordChangedAt
+	if changedAt == 0 {
+		changedAt = user.CreatedAt
+	}
+	return now.After(changedAt.Time().AddDate(0, 0, policy.MaxAgeDays))
+}
+
+// passwordUpdate builds the update that gives a user a new password, along with the other fields to
+// set (the password fields are added to it) and unset: the old hash moves into the history and the
+// password age starts over
+func passwordUpdate(user *models.User, password string, set, unset bson.M) bson.M {
+	set["password"] = encryptions.HashPassword(password)
+	set["password_changed_at"] = primitive.NewDateTimeFromTime(time.Now())
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if user.Password != "" {
+		update["$push"] = bson.M{"password_history": bson.M{
+			"$each":     []string{user.Password},
+			"$position": 0,
+			"$slice":    maxPasswordHistory,
+		}}
+	}
+	return update
+}
+
+// personalInfoParts lists the lowercased parts of a user's username, name and email that a password
+// must not contain; parts under 3 characters are too common to reject
+func personalInfoParts(user *models.User) []string {
+	email := user.Email
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		email = email[:at]
+	}
+
+	var parts []string
+	for _, value := range []string{user.Username, user.FirstName, user.LastName, email} {
+		words := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, word := range words {
+			if len(word) >= 3 {
+				parts = append(parts, word)
+			}
+		}
+	}
+	return parts
+}
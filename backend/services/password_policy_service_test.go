@@ -0,0 +1,107 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/encryptions"
+)
+
+func TestCheckPasswordPolicy(t *testing.T) {
+	user := &models.User{
+		Username:  "jdoe",
+		FirstName: "Jane",
+		LastName:  "Doe-Smith",
+		Email:     "jane.doe@example.com",
+		Password:  encryptions.HashPassword("Current#Pass1"),
+		PasswordHistory: []string{
+			encryptions.HashPassword("Previous#Pass1"),
+			encryptions.HashPassword("Oldest#Pass1"),
+		},
+	}
+
+	strict := models.PasswordPolicy{
+		MinLength:            12,
+		RequireUppercase:     true,
+		RequireLowercase:     true,
+		RequireDigit:         true,
+		RequireSymbol:        true,
+		DisallowCommon:       true,
+		DisallowPersonalInfo: true,
+		HistoryCount:         2,
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		policy   models.PasswordPolicy
+		wantErr  string // substring of the error, empty for none
+	}{
+		{"meets every rule", "Tidal#Harbor42", strict, ""},
+		{"below the hard minimum", "Ab#1xyz", models.PasswordPolicy{}, "at least 8"},
+		{"below the policy minimum", "Tidal#Harb4", strict, "at least 12"},
+		{"above the maximum", strings.Repeat("a", maxPasswordLength+1), models.PasswordPolicy{}, "at most 128"},
+		{"only spaces", strings.Repeat(" ", 10), models.PasswordPolicy{}, "blank"},
+		{"no uppercase", "tidal#harbor42", strict, "uppercase"},
+		{"no lowercase", "TIDAL#HARBOR42", strict, "lowercase"},
+		{"no digit", "Tidal#Harbor!!", strict, "digit"},
+		{"no symbol", "TidalHarbor421", strict, "symbol"},
+		{"space is not a symbol", "Tidal Harbor42", strict, "symbol"},
+		{"common password", "Password123", models.PasswordPolicy{DisallowCommon: true}, "too common"},
+		{"common password allowed", "Password123", models.PasswordPolicy{}, ""},
+		{"contains the first name", "xxJANExx#42Tide", strict, "your name"},
+		{"contains part of a hyphenated last name", "Smith#Harbor42", strict, "your name"},
+		{"contains the email local part", "Tide#doe#Harbor4", strict, "your name"},
+		{"short name parts are ignored", "Tidal#Harbor42jd", strict, ""},
+		{"current password", "Current#Pass1", models.PasswordPolicy{HistoryCount: 1}, "last 1 passwords"},
+		{"previous password within the history", "Previous#Pass1", models.PasswordPolicy{HistoryCount: 2}, "last 2 passwords"},
+		{"password beyond the history", "Oldest#Pass1", models.PasswordPolicy{HistoryCount: 2}, ""},
+		{"reuse allowed without history", "Current#Pass1", models.PasswordPolicy{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPasswordPolicy(tt.password, tt.policy, user)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckPasswordPolicyRules(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        models.PasswordPolicy
+		wantErr       bool
+		wantMinLength int
+	}{
+		{"defaults the minimum length", models.PasswordPolicy{}, false, minPasswordLength},
+		{"keeps a valid minimum length", models.PasswordPolicy{MinLength: 14}, false, 14},
+		{"minimum length too low", models.PasswordPolicy{MinLength: 6}, true, 6},
+		{"minimum length too high", models.PasswordPolicy{MinLength: maxPasswordLength + 1}, true, maxPasswordLength + 1},
+		{"negative history", models.PasswordPolicy{HistoryCount: -1}, true, minPasswordLength},
+		{"history too long", models.PasswordPolicy{HistoryCount: maxPasswordHistory + 1}, true, minPasswordLength},
+		{"maximum age too long", models.PasswordPolicy{MaxAgeDays: maxPasswordAgeDays + 1}, true, minPasswordLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := tt.policy
+			err := checkPasswordPolicyRules(&policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if policy.MinLength != tt.wantMinLength {
+				t.Errorf("MinLength = %d, want %d", policy.MinLength, tt.wantMinLength)
+			}
+		})
+	}
+}
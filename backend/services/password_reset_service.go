@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/encryptions"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	passwordResetTTL      = 30 * time.Minute
+	passwordResetCooldown = 2 * time.Minute // one reset email per account in this window
+)
+
+// ForgotPasswordRequest asks for a password reset link
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest sets a new password with the token from the reset email
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+// ForgotPassword emails a single-use reset link to the active accounts registered with the email.
+// The outcome is the same whether or not the email is known, so it cannot be used to find accounts.
+func (s *AuthService) ForgotPassword(req *ForgotPasswordRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		return errors.New("email is required")
+	}
+
+	cursor, err := usersCollection.Find(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"email":  email,
+		"status": models.UserActive,
+	}))
+	if err != nil {
+		fmt.Printf("Failed to look up users for password reset: %v\n", err)
+		return nil
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		fmt.Printf("Failed to look up users for password reset: %v\n", err)
+		return nil
+	}
+
+	now := time.Now()
+	for i := range users {
+		user := users[i]
+		if now.Sub(user.PasswordResetSentAt.Time()) < passwordResetCooldown {
+			continue
+		}
+
+		token, err := helpers.GenerateSecretToken()
+		if err != nil {
+			fmt.Printf("Failed to generate password reset token: %v\n", err)
+			continue
+		}
+		tokenHash, err := encryptions.Hash256(token)
+		if err != nil {
+			fmt.Printf("Failed to hash password reset token: %v\n", err)
+			continue
+		}
+
+		// A new token replaces any earlier one, so only the latest email works
+		_, err = usersCollection.UpdateOne(ctx,
+			bson.M{"_id": user.ID},
+			bson.M{"$set": bson.M{
+				"password_reset_token":   tokenHash,
+				"password_reset_expiry":  primitive.NewDateTimeFromTime(now.Add(passwordResetTTL)),
+				"password_reset_sent_at": primitive.NewDateTimeFromTime(now),
+			}},
+		)
+		if err != nil {
+			fmt.Printf("Failed to store password reset token for %s: %v\n", user.Username, err)
+			continue
+		}
+
+		// Send password reset email (non-blocking)
+		go func() {
+			if err := helpers.SendPasswordResetEmail(user.Email, user.FirstName, user.Username, token, passwordResetTTL); err != nil {
+				fmt.Printf("Failed to send password reset email to %s: %v\n", user.Email, err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password with a forgot-password token. The token works once, and every
+// session of the user ends.
+func (s *AuthService) ResetPassword(req *ResetPasswordRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	if req.Token == "" {
+		return errors.New("invalid or expired reset link")
+	}
+
+	tokenHash, err := encryptions.Hash256(req.Token)
+	if err != nil {
+		return errors.New("invalid or expired reset link")
+	}
+
+	var user models.User
+	err = usersCollection.FindOne(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"password_reset_token":  tokenHash,
+		"password_reset_expiry": bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())},
+		"status":                models.UserActive,
+	})).Decode(&user)
+	if err != nil {
+		return errors.New("invalid or expired reset link")
+	}
+
+	policy, err := loadPasswordPolicy(ctx, &user)
+	if err != nil {
+		return err
+	}
+	if err := checkPasswordPolicy(req.NewPassword, policy, &user); err != nil {
+		return err
+	}
+
+	// Consuming the token and setting the password is one update, so a token cannot be used twice
+	result, err := usersCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "password_reset_token": tokenHash},
+		passwordUpdate(&user, req.NewPassword,
+			bson.M{"timestamp.updated_at": primitive.NewDateTimeFromTime(time.Now())},
+			bson.M{"password_reset_token": "", "password_reset_expiry": ""},
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+	if result.ModifiedCount == 0 {
+		return errors.New("invalid or expired reset link")
+	}
+
+	return revokeSessions(ctx, bson.M{"user_id": user.ID}, models.SessionPasswordChange)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"api.workzen.odoo/databases"
@@ -12,6 +13,7 @@ import (
 	"api.workzen.odoo/helpers"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -111,9 +113,14 @@ func (s *PayrollService) CreatePayrun(req *CreatePayrunRequest, companyID, gener
 	payrunCollection := databases.MongoDBDatabase.Collection(collections.Payruns)
 	configCollection := databases.MongoDBDatabase.Collection(collections.PayrollConfigurations)
 
+	monthStart, err := time.Parse("2006-01", req.Month)
+	if err != nil {
+		return nil, errors.New("invalid month format, expected YYYY-MM")
+	}
+
 	// Get payroll configuration
 	var config models.PayrollConfiguration
-	err := configCollection.FindOne(ctx, bson.M{"company": companyID}).Decode(&config)
+	err = configCollection.FindOne(ctx, bson.M{"company": companyID}).Decode(&config)
 	if err != nil {
 		config = models.PayrollConfiguration{
 			PFEmployeePercent: 12.0,
@@ -151,6 +158,17 @@ func (s *PayrollService) CreatePayrun(req *CreatePayrunRequest, companyID, gener
 	payrun.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
 	payrun.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
 
+	leaveDays, err := approvedLeaveDaysInMonth(ctx, companyID, monthStart)
+	if err != nil {
+		return nil, err
+	}
+	daysInMonth := monthStart.AddDate(0, 1, -1).Day()
+
+	adjustments, err := pendingPayrollAdjustments(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
 	var totalPayroll float64
 	processedCount := 0
 	missingBankCount := 0
@@ -171,6 +189,24 @@ func (s *PayrollService) CreatePayrun(req *CreatePayrunRequest, companyID, gener
 		// Calculate deductions
 		pfEmployee, pfEmployer, profTax := helpers.CalculateDeductions(salary.BasicSalary.Amount, &config)
 
+		// Unpaid leave is deducted pro rata on calendar days
+		leave := leaveDays[emp.ID]
+		lopDeduction := math.Round(salary.TotalEarnings/float64(daysInMonth)*leave.Unpaid*100) / 100
+		totalDeductions := pfEmployee + profTax + lopDeduction
+
+		// One-off earnings such as leave encashment are added on top of the salary structure
+		var earnings []models.PayrollEarning
+		var additionalEarnings float64
+		for _, adjustment := range adjustments[emp.ID] {
+			earnings = append(earnings, models.PayrollEarning{
+				Code:        adjustment.Code,
+				Description: adjustment.Description,
+				Amount:      adjustment.Amount,
+			})
+			additionalEarnings += adjustment.Amount
+		}
+		grossSalary := salary.TotalEarnings + additionalEarnings
+
 		// Check warnings
 		hasBankAccount := emp.BankDetails != nil && emp.BankDetails.AccountNumber != ""
 		hasManager := !emp.ManagerID.IsZero()
@@ -195,12 +231,16 @@ func (s *PayrollService) CreatePayrun(req *CreatePayrunRequest, companyID, gener
 			PerformanceBonus:     salary.PerformanceBonus.Amount,
 			LeaveTravelAllowance: salary.LeaveTravelAllowance.Amount,
 			FixedAllowance:       salary.FixedAllowance.Amount,
-			GrossSalary:          salary.TotalEarnings,
+			AdditionalEarnings:   earnings,
+			GrossSalary:          grossSalary,
 			PFEmployee:           pfEmployee,
 			PFEmployer:           pfEmployer,
 			ProfessionalTax:      profTax,
-			TotalDeductions:      pfEmployee + profTax,
-			NetPay:               salary.TotalEarnings - (pfEmployee + profTax),
+			LOPDeduction:         lopDeduction,
+			TotalDeductions:      totalDeductions,
+			NetPay:               grossSalary - totalDeductions,
+			LeaveDays:            roundDays(leave.Paid),
+			UnpaidLeaveDays:      roundDays(leave.Unpaid),
 			HasBankAccount:       hasBankAccount,
 			HasManager:           hasManager,
 			Status:               models.PayrollProcessed,
@@ -214,6 +254,9 @@ func (s *PayrollService) CreatePayrun(req *CreatePayrunRequest, companyID, gener
 		if err == nil {
 			processedCount++
 			totalPayroll += payroll.NetPay
+			if err := applyPayrollAdjustments(ctx, adjustments[emp.ID], &payroll); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -231,6 +274,121 @@ func (s *PayrollService) CreatePayrun(req *CreatePayrunRequest, companyID, gener
 	return &payrun, nil
 }
 
+// pendingPayrollAdjustments returns the adjustments waiting for the next payrun, by employee
+func pendingPayrollAdjustments(ctx context.Context, companyID primitive.ObjectID) (map[primitive.ObjectID][]models.PayrollAdjustment, error) {
+	adjustmentsCollection := databases.MongoDBDatabase.Collection(collections.PayrollAdjustments)
+
+	cursor, err := adjustmentsCollection.Find(ctx, bson.M{
+		"company": companyID,
+		"status":  models.AdjustmentPending,
+	}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payroll adjustments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var adjustments []models.PayrollAdjustment
+	if err = cursor.All(ctx, &adjustments); err != nil {
+		return nil, err
+	}
+
+	byEmployee := make(map[primitive.ObjectID][]models.PayrollAdjustment)
+	for _, adjustment := range adjustments {
+		byEmployee[adjustment.EmployeeID] = append(byEmployee[adjustment.EmployeeID], adjustment)
+	}
+	return byEmployee, nil
+}
+
+// applyPayrollAdjustments marks adjustments as paid by a payroll
+func applyPayrollAdjustments(ctx context.Context, adjustments []models.PayrollAdjustment, payroll *models.Payroll) error {
+	if len(adjustments) == 0 {
+		return nil
+	}
+
+	adjustmentsCollection := databases.MongoDBDatabase.Collection(collections.PayrollAdjustments)
+
+	ids := make([]primitive.ObjectID, 0, len(adjustments))
+	for _, adjustment := range adjustments {
+		ids = append(ids, adjustment.ID)
+	}
+
+	_, err := adjustmentsCollection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "status": models.AdjustmentPending},
+		bson.M{"$set": bson.M{
+			"status":     models.AdjustmentApplied,
+			"payrun_id":  payroll.PayrunID,
+			"payroll_id": payroll.ID,
+			"updated_at": primitive.NewDateTimeFromTime(time.Now()),
+		}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to apply payroll adjustments: %w", err)
+	}
+	return nil
+}
+
+// monthLeaveDays counts the approved leave days of an employee that fall in a month
+type monthLeaveDays struct {
+	Paid   float64
+	Unpaid float64
+}
+
+// approvedLeaveDaysInMonth returns the approved leave days per employee, clipped to the month
+func approvedLeaveDaysInMonth(ctx context.Context, companyID primitive.ObjectID, monthStart time.Time) (map[primitive.ObjectID]monthLeaveDays, error) {
+	leavesCollection := databases.MongoDBDatabase.Collection(collections.Leaves)
+
+	monthEnd := monthStart.AddDate(0, 1, -1)
+	cursor, err := leavesCollection.Find(ctx, bson.M{
+		"company":    companyID,
+		"status":     models.LeaveApproved,
+		"start_date": bson.M{"$lte": helpers.FormatDate(monthEnd)},
+		"end_date":   bson.M{"$gte": helpers.FormatDate(monthStart)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load leaves: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var leaves []models.Leave
+	if err = cursor.All(ctx, &leaves); err != nil {
+		return nil, err
+	}
+
+	days := make(map[primitive.ObjectID]monthLeaveDays)
+	for _, leave := range leaves {
+		start, err := helpers.ParseDate(leave.StartDate)
+		if err != nil {
+			continue
+		}
+		end, err := helpers.ParseDate(leave.EndDate)
+		if err != nil {
+			continue
+		}
+		if start.Before(monthStart) {
+			start = monthStart
+		}
+		if end.After(monthEnd) {
+			end = monthEnd
+		}
+
+		// Half-day and hourly leave cover a single date and carry their fractional days
+		count := leave.Days
+		if leave.Duration == "" || leave.Duration == models.LeaveFullDay {
+			count = end.Sub(start).Hours()/24 + 1
+		}
+
+		total := days[leave.EmployeeID]
+		if leave.Unpaid {
+			total.Unpaid += count
+		} else {
+			total.Paid += count
+		}
+		days[leave.EmployeeID] = total
+	}
+
+	return days, nil
+}
+
 // ListPayruns retrieves payruns with pagination
 func (s *PayrollService) ListPayruns(companyID primitive.ObjectID, page, limit int64) ([]models.Payrun, int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -306,3 +464,26 @@ func (s *PayrollService) MarkAsPaid(payrollID primitive.ObjectID) error {
 
 	return nil
 }
+
+// lockedPayrunMonth returns the first of the months (YYYY-MM) that already has a payrun, or ""
+func lockedPayrunMonth(ctx context.Context, companyID primitive.ObjectID, months []string) (string, error) {
+	if len(months) == 0 {
+		return "", nil
+	}
+
+	payrunCollection := databases.MongoDBDatabase.Collection(collections.Payruns)
+
+	var payrun models.Payrun
+	err := payrunCollection.FindOne(ctx, bson.M{
+		"company": companyID,
+		"month":   bson.M{"$in": months},
+	}, options.FindOne().SetSort(bson.D{{Key: "month", Value: 1}})).Decode(&payrun)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to check payruns: %w", err)
+	}
+
+	return payrun.Month, nil
+}
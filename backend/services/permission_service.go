@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// LoadPermissions resolves the user's permissions into user.Permissions, so every check on the user
+// after it is answered without the database (AuthMiddleware calls it once per request)
+func LoadPermissions(user *models.User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	permissions, err := userPermissions(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to load permissions: %w", err)
+	}
+	user.Permissions = models.NewPermissionSet(permissions)
+
+	return nil
+}
+
+// HasPermission reports whether the user holds the permission in the company. SuperAdmin holds every
+// permission in every company; other users hold theirs only in their own company. Users that did not
+// come through AuthMiddleware have their permissions loaded on the first check.
+func HasPermission(user *models.User, permission models.Permission, companyID primitive.ObjectID) bool {
+	if user.IsSuperAdmin {
+		return true
+	}
+	if user.Company.IsZero() || user.Company != companyID {
+		return false
+	}
+
+	if user.Permissions == nil {
+		if err := LoadPermissions(user); err != nil {
+			fmt.Printf("Failed to load permissions of %s: %v\n", user.Username, err)
+			return false
+		}
+	}
+	return user.Permissions.Has(permission)
+}
+
+// can reports whether the user holds the permission in their own company
+func can(user *models.User, permission models.Permission) bool {
+	return HasPermission(user, permission, user.Company)
+}
+
+// userPermissions returns the permissions of the user's custom role, or else the default bundle of
+// their built-in role
+func userPermissions(ctx context.Context, user *models.User) ([]models.Permission, error) {
+	customRolesCollection := databases.MongoDBDatabase.Collection(collections.CustomRoles)
+
+	if user.IsSuperAdmin {
+		return models.AllPermissions, nil
+	}
+	if user.CustomRoleID.IsZero() {
+		return models.DefaultRolePermissions[user.Role], nil
+	}
+
+	var role models.CustomRole
+	err := customRolesCollection.FindOne(ctx, helpers.AddNotDeletedFilter(bson.M{
+		"_id":     user.CustomRoleID,
+		"company": user.Company,
+	})).Decode(&role)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// The custom role was deleted; its users fall back to their built-in role
+		return models.DefaultRolePermissions[user.Role], nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return role.Permissions, nil
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"strings"
 
 	"api.workzen.odoo/databases/models"
 	"api.workzen.odoo/encryptions"
@@ -23,23 +24,209 @@ type AttendanceResponse struct {
 	UpdatedAt  primitive.DateTime      `json:"updated_at,omitempty"`
 }
 
+// AttendanceRegularizationResponse represents a regularization request with encrypted IDs
+type AttendanceRegularizationResponse struct {
+	ID                string                      `json:"id,omitempty"`
+	EmployeeID        string                      `json:"employee_id"`
+	Company           string                      `json:"company"`
+	AttendanceID      string                      `json:"attendance_id,omitempty"`
+	Date              string                      `json:"date"`
+	RequestedCheckIn  string                      `json:"requested_check_in,omitempty"`
+	RequestedCheckOut string                      `json:"requested_check_out,omitempty"`
+	RequestedStatus   models.AttendanceStatus     `json:"requested_status"`
+	Reason            string                      `json:"reason"`
+	Status            models.RegularizationStatus `json:"status"`
+	Original          *models.AttendanceSnapshot  `json:"original,omitempty"`
+	ReviewedBy        string                      `json:"reviewed_by,omitempty"`
+	ReviewedAt        string                      `json:"reviewed_at,omitempty"`
+	ReviewRemarks     string                      `json:"review_remarks,omitempty"`
+	CreatedAt         primitive.DateTime          `json:"created_at,omitempty"`
+	UpdatedAt         primitive.DateTime          `json:"updated_at,omitempty"`
+}
+
+// PunchErrorResponse represents a queued device punch with encrypted IDs
+type PunchErrorResponse struct {
+	ID           string                  `json:"id,omitempty"`
+	DeviceUserID string                  `json:"device_user_id,omitempty"`
+	DeviceID     string                  `json:"device_id,omitempty"`
+	Timestamp    string                  `json:"timestamp,omitempty"`
+	RawLine      string                  `json:"raw_line,omitempty"`
+	Source       models.PunchSource      `json:"source"`
+	Reason       string                  `json:"reason"`
+	Status       models.PunchErrorStatus `json:"status"`
+	ReviewedBy   string                  `json:"reviewed_by,omitempty"`
+	ReviewedAt   string                  `json:"reviewed_at,omitempty"`
+	CreatedAt    primitive.DateTime      `json:"created_at,omitempty"`
+}
+
+// LeaveLedgerEntryResponse represents a leave ledger entry with encrypted IDs
+type LeaveLedgerEntryResponse struct {
+	ID            string                 `json:"id,omitempty"`
+	EmployeeID    string                 `json:"employee_id"`
+	LeaveType     models.LeaveType       `json:"leave_type"`
+	EntryType     models.LedgerEntryType `json:"entry_type"`
+	Days          float64                `json:"days"`
+	Period        string                 `json:"period,omitempty"`
+	LeaveID       string                 `json:"leave_id,omitempty"`
+	CompOffID     string                 `json:"comp_off_id,omitempty"`
+	EffectiveDate string                 `json:"effective_date"`
+	Remarks       string                 `json:"remarks,omitempty"`
+	CreatedAt     primitive.DateTime     `json:"created_at,omitempty"`
+}
+
+// LeaveChangeResponse represents a cancellation or date change of a leave with encrypted IDs
+type LeaveChangeResponse struct {
+	Type          models.LeaveChangeType `json:"type"`
+	StartDate     string                 `json:"start_date,omitempty"`
+	EndDate       string                 `json:"end_date,omitempty"`
+	Days          float64                `json:"days,omitempty"`
+	Reason        string                 `json:"reason"`
+	Status        models.LeaveStatus     `json:"status"`
+	PreviousStart string                 `json:"previous_start"`
+	PreviousEnd   string                 `json:"previous_end"`
+	RequestedBy   string                 `json:"requested_by"`
+	RequestedAt   string                 `json:"requested_at"`
+	ReviewedBy    string                 `json:"reviewed_by,omitempty"`
+	ReviewedAt    string                 `json:"reviewed_at,omitempty"`
+	ReviewRemarks string                 `json:"review_remarks,omitempty"`
+}
+
+// LeaveApprovalStepResponse represents a step of a leave's approval chain with encrypted IDs
+type LeaveApprovalStepResponse struct {
+	Approver   models.ApproverRole   `json:"approver"`
+	ApproverID string                `json:"approver_id,omitempty"`
+	Status     models.ApprovalStatus `json:"status"`
+}
+
+// LeaveApprovalActionResponse represents an approval history entry with encrypted IDs
+type LeaveApprovalActionResponse struct {
+	Step       int                   `json:"step"`
+	Approver   models.ApproverRole   `json:"approver"`
+	Action     models.ApprovalStatus `json:"action"`
+	By         string                `json:"by,omitempty"`
+	OnBehalfOf string                `json:"on_behalf_of,omitempty"`
+	At         string                `json:"at"`
+	Remarks    string                `json:"remarks,omitempty"`
+}
+
+// LeaveApprovalResponse is returned when an approver approves a step of a leave
+type LeaveApprovalResponse struct {
+	Leave     *LeaveResponse  `json:"leave"`
+	Conflicts *LeaveConflicts `json:"conflicts"` // acknowledged conflicts, if any
+}
+
+// LeaveDelegationResponse represents a leave approval delegation with encrypted IDs
+type LeaveDelegationResponse struct {
+	ID          string             `json:"id,omitempty"`
+	DelegatorID string             `json:"delegator_id"`
+	DelegateID  string             `json:"delegate_id"`
+	StartDate   string             `json:"start_date"`
+	EndDate     string             `json:"end_date"`
+	Reason      string             `json:"reason,omitempty"`
+	IsActive    bool               `json:"is_active"`
+	CreatedAt   primitive.DateTime `json:"created_at,omitempty"`
+}
+
+// LeaveCalendarResponse is the leave visible to the user over a date range
+type LeaveCalendarResponse struct {
+	From   string                       `json:"from"`
+	To     string                       `json:"to"`
+	Scope  string                       `json:"scope"` // company | department | team
+	Leaves []LeaveCalendarEntryResponse `json:"leaves"`
+}
+
+// LeaveCalendarEntryResponse is one leave on the calendar with encrypted IDs; the reason is left out
+type LeaveCalendarEntryResponse struct {
+	ID           string               `json:"id"`
+	EmployeeID   string               `json:"employee_id"`
+	EmployeeName string               `json:"employee_name"`
+	Designation  string               `json:"designation,omitempty"`
+	ProfilePic   string               `json:"profile_pic,omitempty"`
+	LeaveType    models.LeaveType     `json:"leave_type"`
+	StartDate    string               `json:"start_date"`
+	EndDate      string               `json:"end_date"`
+	Duration     models.LeaveDuration `json:"duration,omitempty"`
+	Hours        float64              `json:"hours,omitempty"`
+	Days         float64              `json:"days"`
+	Status       models.LeaveStatus   `json:"status"`
+}
+
+// CompOffCreditResponse represents a comp-off credit with encrypted IDs
+type CompOffCreditResponse struct {
+	ID            string               `json:"id,omitempty"`
+	EmployeeID    string               `json:"employee_id"`
+	AttendanceID  string               `json:"attendance_id,omitempty"`
+	WorkDate      string               `json:"work_date"`
+	WorkHours     float64              `json:"work_hours"`
+	Days          float64              `json:"days"`
+	ExpiresOn     string               `json:"expires_on,omitempty"`
+	Reason        string               `json:"reason,omitempty"`
+	Status        models.CompOffStatus `json:"status"`
+	ReviewedBy    string               `json:"reviewed_by,omitempty"`
+	ReviewedAt    string               `json:"reviewed_at,omitempty"`
+	ReviewRemarks string               `json:"review_remarks,omitempty"`
+	LapsedDays    float64              `json:"lapsed_days,omitempty"`
+	CreatedAt     primitive.DateTime   `json:"created_at,omitempty"`
+}
+
+// LeaveRolloverResponse represents a leave year rollover with encrypted IDs
+type LeaveRolloverResponse struct {
+	ID                    string                      `json:"id,omitempty"`
+	Year                  int                         `json:"year"`
+	Status                models.LeaveRolloverStatus  `json:"status"`
+	Lines                 []LeaveRolloverLineResponse `json:"lines"`
+	TotalCarriedForward   float64                     `json:"total_carried_forward"`
+	TotalLapsed           float64                     `json:"total_lapsed"`
+	TotalEncashed         float64                     `json:"total_encashed"`
+	TotalEncashmentAmount float64                     `json:"total_encashment_amount"`
+	ReversedAt            string                      `json:"reversed_at,omitempty"`
+	CreatedAt             primitive.DateTime          `json:"created_at,omitempty"`
+}
+
+// LeaveRolloverLineResponse is one employee's year-end outcome with an encrypted employee ID
+type LeaveRolloverLineResponse struct {
+	EmployeeID       string           `json:"employee_id"`
+	LeaveType        models.LeaveType `json:"leave_type"`
+	Accrued          float64          `json:"accrued"`
+	Used             float64          `json:"used"`
+	Balance          float64          `json:"balance"`
+	CarriedForward   float64          `json:"carried_forward"`
+	Lapsed           float64          `json:"lapsed"`
+	Encashed         float64          `json:"encashed"`
+	EncashmentAmount float64          `json:"encashment_amount"`
+}
+
 // LeaveResponse represents leave data with encrypted IDs
 type LeaveResponse struct {
-	ID         string             `json:"id,omitempty"`
-	EmployeeID string             `json:"employee_id"`
-	Company    string             `json:"company"`
-	LeaveType  models.LeaveType   `json:"leave_type"`
-	Reason     string             `json:"reason"`
-	StartDate  string             `json:"start_date"`
-	EndDate    string             `json:"end_date"`
-	Days       int                `json:"days"`
-	Status     models.LeaveStatus `json:"status"`
-	ApprovedBy string             `json:"approved_by,omitempty"`
-	RejectedBy string             `json:"rejected_by,omitempty"`
-	ReviewedAt string             `json:"reviewed_at,omitempty"`
-	User       *UserResponse      `json:"user,omitempty"`
-	CreatedAt  primitive.DateTime `json:"created_at,omitempty"`
-	UpdatedAt  primitive.DateTime `json:"updated_at,omitempty"`
+	ID                string                        `json:"id,omitempty"`
+	EmployeeID        string                        `json:"employee_id"`
+	Company           string                        `json:"company"`
+	LeaveType         models.LeaveType              `json:"leave_type"`
+	Reason            string                        `json:"reason"`
+	StartDate         string                        `json:"start_date"`
+	EndDate           string                        `json:"end_date"`
+	Duration          models.LeaveDuration          `json:"duration,omitempty"`
+	Hours             float64                       `json:"hours,omitempty"`
+	Days              float64                       `json:"days"`
+	Unpaid            bool                          `json:"unpaid,omitempty"`
+	Documents         []string                      `json:"documents,omitempty"`
+	Attachments       []DocumentResponse            `json:"attachments,omitempty"` // populated when listing
+	DocumentStatus    models.LeaveDocumentStatus    `json:"document_status,omitempty"`
+	DocumentDueDate   string                        `json:"document_due_date,omitempty"`
+	Status            models.LeaveStatus            `json:"status"`
+	ApprovedBy        string                        `json:"approved_by,omitempty"`
+	RejectedBy        string                        `json:"rejected_by,omitempty"`
+	ReviewedAt        string                        `json:"reviewed_at,omitempty"`
+	ApprovalSteps     []LeaveApprovalStepResponse   `json:"approval_steps,omitempty"`
+	CurrentStep       int                           `json:"current_step"`
+	CurrentApprover   models.ApproverRole           `json:"current_approver,omitempty"`
+	CurrentApproverID string                        `json:"current_approver_id,omitempty"`
+	ApprovalHistory   []LeaveApprovalActionResponse `json:"approval_history,omitempty"`
+	PendingChange     *LeaveChangeResponse          `json:"pending_change,omitempty"`
+	ChangeHistory     []LeaveChangeResponse         `json:"change_history,omitempty"`
+	User              *UserResponse                 `json:"user,omitempty"`
+	CreatedAt         primitive.DateTime            `json:"created_at,omitempty"`
+	UpdatedAt         primitive.DateTime            `json:"updated_at,omitempty"`
 }
 
 // SalaryStructureResponse represents salary structure data with encrypted IDs
@@ -86,36 +273,39 @@ type DocumentResponse struct {
 
 // PayrollResponse represents payroll data with encrypted IDs
 type PayrollResponse struct {
-	ID                   string               `json:"id,omitempty"`
-	EmployeeID           string               `json:"employee_id"`
-	Company              string               `json:"company"`
-	PayrunID             string               `json:"payrun_id"`
-	Month                string               `json:"month"`
-	BasicSalary          float64              `json:"basic_salary"`
-	HouseRentAllowance   float64              `json:"house_rent_allowance"`
-	StandardAllowance    float64              `json:"standard_allowance"`
-	PerformanceBonus     float64              `json:"performance_bonus"`
-	LeaveTravelAllowance float64              `json:"leave_travel_allowance"`
-	FixedAllowance       float64              `json:"fixed_allowance"`
-	GrossSalary          float64              `json:"gross_salary"`
-	TotalDeductions      float64              `json:"total_deductions"`
-	NetPay               float64              `json:"net_pay"`
-	PFEmployee           float64              `json:"pf_employee"`
-	PFEmployer           float64              `json:"pf_employer"`
-	ProfessionalTax      float64              `json:"professional_tax"`
-	WorkingDays          int                  `json:"working_days"`
-	PresentDays          int                  `json:"present_days"`
-	LeaveDays            int                  `json:"leave_days"`
-	AbsentDays           int                  `json:"absent_days"`
-	HasBankAccount       bool                 `json:"has_bank_account"`
-	HasManager           bool                 `json:"has_manager"`
-	GeneratedBy          string               `json:"generated_by"`
-	GeneratedAt          string               `json:"generated_at"`
-	Status               models.PayrollStatus `json:"status"`
-	PaidAt               string               `json:"paid_at,omitempty"`
-	PayslipURL           string               `json:"payslip_url,omitempty"`
-	CreatedAt            primitive.DateTime   `json:"created_at,omitempty"`
-	UpdatedAt            primitive.DateTime   `json:"updated_at,omitempty"`
+	ID                   string                  `json:"id,omitempty"`
+	EmployeeID           string                  `json:"employee_id"`
+	Company              string                  `json:"company"`
+	PayrunID             string                  `json:"payrun_id"`
+	Month                string                  `json:"month"`
+	BasicSalary          float64                 `json:"basic_salary"`
+	HouseRentAllowance   float64                 `json:"house_rent_allowance"`
+	StandardAllowance    float64                 `json:"standard_allowance"`
+	PerformanceBonus     float64                 `json:"performance_bonus"`
+	LeaveTravelAllowance float64                 `json:"leave_travel_allowance"`
+	FixedAllowance       float64                 `json:"fixed_allowance"`
+	AdditionalEarnings   []models.PayrollEarning `json:"additional_earnings,omitempty"`
+	GrossSalary          float64                 `json:"gross_salary"`
+	TotalDeductions      float64                 `json:"total_deductions"`
+	NetPay               float64                 `json:"net_pay"`
+	PFEmployee           float64                 `json:"pf_employee"`
+	PFEmployer           float64                 `json:"pf_employer"`
+	ProfessionalTax      float64                 `json:"professional_tax"`
+	LOPDeduction         float64                 `json:"lop_deduction"`
+	WorkingDays          int                     `json:"working_days"`
+	PresentDays          int                     `json:"present_days"`
+	LeaveDays            float64                 `json:"leave_days"`
+	UnpaidLeaveDays      float64                 `json:"unpaid_leave_days"`
+	AbsentDays           int                     `json:"absent_days"`
+	HasBankAccount       bool                    `json:"has_bank_account"`
+	HasManager           bool                    `json:"has_manager"`
+	GeneratedBy          string                  `json:"generated_by"`
+	GeneratedAt          string                  `json:"generated_at"`
+	Status               models.PayrollStatus    `json:"status"`
+	PaidAt               string                  `json:"paid_at,omitempty"`
+	PayslipURL           string                  `json:"payslip_url,omitempty"`
+	CreatedAt            primitive.DateTime      `json:"created_at,omitempty"`
+	UpdatedAt            primitive.DateTime      `json:"updated_at,omitempty"`
 }
 
 // PayrunResponse represents payrun data with encrypted IDs
@@ -183,6 +373,157 @@ func ConvertAttendanceToResponse(attendance *models.Attendance) (*AttendanceResp
 	return response, nil
 }
 
+// ConvertRegularizationToResponse converts AttendanceRegularization model to response with encrypted IDs
+func ConvertRegularizationToResponse(regularization *models.AttendanceRegularization) (*AttendanceRegularizationResponse, error) {
+	if regularization == nil {
+		return nil, nil
+	}
+
+	response := &AttendanceRegularizationResponse{
+		Date:              regularization.Date,
+		RequestedCheckIn:  regularization.RequestedCheckIn,
+		RequestedCheckOut: regularization.RequestedCheckOut,
+		RequestedStatus:   regularization.RequestedStatus,
+		Reason:            regularization.Reason,
+		Status:            regularization.Status,
+		Original:          regularization.Original,
+		ReviewedAt:        regularization.ReviewedAt,
+		ReviewRemarks:     regularization.ReviewRemarks,
+		CreatedAt:         regularization.CreatedAt,
+		UpdatedAt:         regularization.UpdatedAt,
+	}
+
+	if !regularization.ID.IsZero() {
+		encID, err := encryptions.EncryptID(regularization.ID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt regularization ID: %w", err)
+		}
+		response.ID = encID
+	}
+
+	if !regularization.EmployeeID.IsZero() {
+		encID, err := encryptions.EncryptID(regularization.EmployeeID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt employee ID: %w", err)
+		}
+		response.EmployeeID = encID
+	}
+
+	if !regularization.Company.IsZero() {
+		encID, err := encryptions.EncryptID(regularization.Company.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt company ID: %w", err)
+		}
+		response.Company = encID
+	}
+
+	if !regularization.AttendanceID.IsZero() {
+		encID, err := encryptions.EncryptID(regularization.AttendanceID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt attendance ID: %w", err)
+		}
+		response.AttendanceID = encID
+	}
+
+	if !regularization.ReviewedBy.IsZero() {
+		encID, err := encryptions.EncryptID(regularization.ReviewedBy.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt reviewed by ID: %w", err)
+		}
+		response.ReviewedBy = encID
+	}
+
+	return response, nil
+}
+
+// ConvertPunchErrorToResponse converts PunchError model to response with encrypted IDs
+func ConvertPunchErrorToResponse(punchError *models.PunchError) (*PunchErrorResponse, error) {
+	if punchError == nil {
+		return nil, nil
+	}
+
+	response := &PunchErrorResponse{
+		DeviceUserID: punchError.DeviceUserID,
+		DeviceID:     punchError.DeviceID,
+		Timestamp:    punchError.Timestamp,
+		RawLine:      punchError.RawLine,
+		Source:       punchError.Source,
+		Reason:       punchError.Reason,
+		Status:       punchError.Status,
+		ReviewedAt:   punchError.ReviewedAt,
+		CreatedAt:    punchError.CreatedAt,
+	}
+
+	if !punchError.ID.IsZero() {
+		encID, err := encryptions.EncryptID(punchError.ID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt punch error ID: %w", err)
+		}
+		response.ID = encID
+	}
+
+	if !punchError.ReviewedBy.IsZero() {
+		encID, err := encryptions.EncryptID(punchError.ReviewedBy.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt reviewed by ID: %w", err)
+		}
+		response.ReviewedBy = encID
+	}
+
+	return response, nil
+}
+
+// ConvertLedgerEntryToResponse converts LeaveLedgerEntry model to response with encrypted IDs
+func ConvertLedgerEntryToResponse(entry *models.LeaveLedgerEntry) (*LeaveLedgerEntryResponse, error) {
+	if entry == nil {
+		return nil, nil
+	}
+
+	response := &LeaveLedgerEntryResponse{
+		LeaveType:     entry.LeaveType,
+		EntryType:     entry.EntryType,
+		Days:          entry.Days,
+		Period:        entry.Period,
+		EffectiveDate: entry.EffectiveDate,
+		Remarks:       entry.Remarks,
+		CreatedAt:     entry.CreatedAt,
+	}
+
+	if !entry.ID.IsZero() {
+		encID, err := encryptions.EncryptID(entry.ID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt ledger entry ID: %w", err)
+		}
+		response.ID = encID
+	}
+
+	if !entry.EmployeeID.IsZero() {
+		encID, err := encryptions.EncryptID(entry.EmployeeID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt employee ID: %w", err)
+		}
+		response.EmployeeID = encID
+	}
+
+	if !entry.LeaveID.IsZero() {
+		encID, err := encryptions.EncryptID(entry.LeaveID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt leave ID: %w", err)
+		}
+		response.LeaveID = encID
+	}
+
+	if !entry.CompOffID.IsZero() {
+		encID, err := encryptions.EncryptID(entry.CompOffID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt comp-off ID: %w", err)
+		}
+		response.CompOffID = encID
+	}
+
+	return response, nil
+}
+
 // ConvertLeaveToResponse converts Leave model to LeaveResponse with encrypted IDs
 func ConvertLeaveToResponse(leave *models.Leave) (*LeaveResponse, error) {
 	if leave == nil {
@@ -190,15 +531,28 @@ func ConvertLeaveToResponse(leave *models.Leave) (*LeaveResponse, error) {
 	}
 
 	response := &LeaveResponse{
-		LeaveType:  leave.LeaveType,
-		Reason:     leave.Reason,
-		StartDate:  leave.StartDate,
-		EndDate:    leave.EndDate,
-		Days:       leave.Days,
-		Status:     leave.Status,
-		ReviewedAt: leave.ReviewedAt,
-		CreatedAt:  leave.CreatedAt,
-		UpdatedAt:  leave.UpdatedAt,
+		LeaveType:       leave.LeaveType,
+		Reason:          leave.Reason,
+		StartDate:       leave.StartDate,
+		EndDate:         leave.EndDate,
+		Duration:        leave.Duration,
+		Hours:           leave.Hours,
+		Days:            leave.Days,
+		Unpaid:          leave.Unpaid,
+		DocumentStatus:  leave.DocumentStatus,
+		DocumentDueDate: leave.DocumentDueDate,
+		Status:          leave.Status,
+		ReviewedAt:      leave.ReviewedAt,
+		CreatedAt:       leave.CreatedAt,
+		UpdatedAt:       leave.UpdatedAt,
+	}
+
+	for _, documentID := range leave.Documents {
+		encID, err := encryptions.EncryptID(documentID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt document ID: %w", err)
+		}
+		response.Documents = append(response.Documents, encID)
 	}
 
 	if !leave.ID.IsZero() {
@@ -241,6 +595,284 @@ func ConvertLeaveToResponse(leave *models.Leave) (*LeaveResponse, error) {
 		response.RejectedBy = encID
 	}
 
+	response.CurrentStep = leave.CurrentStep
+	response.CurrentApprover = leave.CurrentApprover
+	if !leave.CurrentApproverID.IsZero() {
+		encID, err := encryptions.EncryptID(leave.CurrentApproverID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt approver ID: %w", err)
+		}
+		response.CurrentApproverID = encID
+	}
+
+	for _, step := range leave.ApprovalSteps {
+		stepResp := LeaveApprovalStepResponse{Approver: step.Approver, Status: step.Status}
+		if !step.ApproverID.IsZero() {
+			encID, err := encryptions.EncryptID(step.ApproverID.Hex())
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt approver ID: %w", err)
+			}
+			stepResp.ApproverID = encID
+		}
+		response.ApprovalSteps = append(response.ApprovalSteps, stepResp)
+	}
+
+	for _, action := range leave.ApprovalHistory {
+		actionResp := LeaveApprovalActionResponse{
+			Step:     action.Step,
+			Approver: action.Approver,
+			Action:   action.Action,
+			At:       action.At,
+			Remarks:  action.Remarks,
+		}
+		if !action.By.IsZero() {
+			encID, err := encryptions.EncryptID(action.By.Hex())
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt approver ID: %w", err)
+			}
+			actionResp.By = encID
+		}
+		if !action.OnBehalfOf.IsZero() {
+			encID, err := encryptions.EncryptID(action.OnBehalfOf.Hex())
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt delegator ID: %w", err)
+			}
+			actionResp.OnBehalfOf = encID
+		}
+		response.ApprovalHistory = append(response.ApprovalHistory, actionResp)
+	}
+
+	if leave.PendingChange != nil {
+		change, err := convertLeaveChangeToResponse(leave.PendingChange)
+		if err != nil {
+			return nil, err
+		}
+		response.PendingChange = change
+	}
+
+	for i := range leave.ChangeHistory {
+		change, err := convertLeaveChangeToResponse(&leave.ChangeHistory[i])
+		if err != nil {
+			return nil, err
+		}
+		response.ChangeHistory = append(response.ChangeHistory, *change)
+	}
+
+	return response, nil
+}
+
+// convertLeaveChangeToResponse converts LeaveChange to LeaveChangeResponse with encrypted IDs
+func convertLeaveChangeToResponse(change *models.LeaveChange) (*LeaveChangeResponse, error) {
+	response := &LeaveChangeResponse{
+		Type:          change.Type,
+		StartDate:     change.StartDate,
+		EndDate:       change.EndDate,
+		Days:          change.Days,
+		Reason:        change.Reason,
+		Status:        change.Status,
+		PreviousStart: change.PreviousStart,
+		PreviousEnd:   change.PreviousEnd,
+		RequestedAt:   change.RequestedAt,
+		ReviewedAt:    change.ReviewedAt,
+		ReviewRemarks: change.ReviewRemarks,
+	}
+
+	if !change.RequestedBy.IsZero() {
+		encID, err := encryptions.EncryptID(change.RequestedBy.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt requested by ID: %w", err)
+		}
+		response.RequestedBy = encID
+	}
+
+	if !change.ReviewedBy.IsZero() {
+		encID, err := encryptions.EncryptID(change.ReviewedBy.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt reviewed by ID: %w", err)
+		}
+		response.ReviewedBy = encID
+	}
+
+	return response, nil
+}
+
+// ConvertLeaveDelegationToResponse converts LeaveDelegation model to response with encrypted IDs
+func ConvertLeaveDelegationToResponse(delegation *models.LeaveDelegation) (*LeaveDelegationResponse, error) {
+	if delegation == nil {
+		return nil, nil
+	}
+
+	response := &LeaveDelegationResponse{
+		StartDate: delegation.StartDate,
+		EndDate:   delegation.EndDate,
+		Reason:    delegation.Reason,
+		IsActive:  delegation.IsActive,
+		CreatedAt: delegation.CreatedAt,
+	}
+
+	encID, err := encryptions.EncryptID(delegation.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt delegation ID: %w", err)
+	}
+	response.ID = encID
+
+	encID, err = encryptions.EncryptID(delegation.DelegatorID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt delegator ID: %w", err)
+	}
+	response.DelegatorID = encID
+
+	encID, err = encryptions.EncryptID(delegation.DelegateID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt delegate ID: %w", err)
+	}
+	response.DelegateID = encID
+
+	return response, nil
+}
+
+// ConvertLeaveCalendarToResponse converts a LeaveCalendar to LeaveCalendarResponse with encrypted IDs
+func ConvertLeaveCalendarToResponse(calendar *LeaveCalendar) (*LeaveCalendarResponse, error) {
+	if calendar == nil {
+		return nil, nil
+	}
+
+	response := &LeaveCalendarResponse{
+		From:   calendar.From,
+		To:     calendar.To,
+		Scope:  calendar.Scope,
+		Leaves: make([]LeaveCalendarEntryResponse, 0, len(calendar.Leaves)),
+	}
+
+	for _, leave := range calendar.Leaves {
+		entry := LeaveCalendarEntryResponse{
+			LeaveType: leave.LeaveType,
+			StartDate: leave.StartDate,
+			EndDate:   leave.EndDate,
+			Duration:  leave.Duration,
+			Hours:     leave.Hours,
+			Days:      leave.Days,
+			Status:    leave.Status,
+		}
+		if leave.User != nil {
+			entry.EmployeeName = strings.TrimSpace(leave.User.FirstName + " " + leave.User.LastName)
+			entry.Designation = leave.User.Designation
+			entry.ProfilePic = leave.User.ProfilePic
+		}
+
+		encID, err := encryptions.EncryptID(leave.ID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt leave ID: %w", err)
+		}
+		entry.ID = encID
+
+		encID, err = encryptions.EncryptID(leave.EmployeeID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt employee ID: %w", err)
+		}
+		entry.EmployeeID = encID
+
+		response.Leaves = append(response.Leaves, entry)
+	}
+
+	return response, nil
+}
+
+// ConvertCompOffToResponse converts CompOffCredit model to CompOffCreditResponse with encrypted IDs
+func ConvertCompOffToResponse(credit *models.CompOffCredit) (*CompOffCreditResponse, error) {
+	if credit == nil {
+		return nil, nil
+	}
+
+	response := &CompOffCreditResponse{
+		WorkDate:      credit.WorkDate,
+		WorkHours:     credit.WorkHours,
+		Days:          credit.Days,
+		ExpiresOn:     credit.ExpiresOn,
+		Reason:        credit.Reason,
+		Status:        credit.Status,
+		ReviewedAt:    credit.ReviewedAt,
+		ReviewRemarks: credit.ReviewRemarks,
+		LapsedDays:    credit.LapsedDays,
+		CreatedAt:     credit.CreatedAt,
+	}
+
+	encID, err := encryptions.EncryptID(credit.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt comp-off ID: %w", err)
+	}
+	response.ID = encID
+
+	encID, err = encryptions.EncryptID(credit.EmployeeID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt employee ID: %w", err)
+	}
+	response.EmployeeID = encID
+
+	if !credit.AttendanceID.IsZero() {
+		encID, err := encryptions.EncryptID(credit.AttendanceID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt attendance ID: %w", err)
+		}
+		response.AttendanceID = encID
+	}
+
+	if !credit.ReviewedBy.IsZero() {
+		encID, err := encryptions.EncryptID(credit.ReviewedBy.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt reviewed by ID: %w", err)
+		}
+		response.ReviewedBy = encID
+	}
+
+	return response, nil
+}
+
+// ConvertLeaveRolloverToResponse converts LeaveRollover model to LeaveRolloverResponse with encrypted IDs
+func ConvertLeaveRolloverToResponse(rollover *models.LeaveRollover) (*LeaveRolloverResponse, error) {
+	if rollover == nil {
+		return nil, nil
+	}
+
+	response := &LeaveRolloverResponse{
+		Year:                  rollover.Year,
+		Status:                rollover.Status,
+		Lines:                 make([]LeaveRolloverLineResponse, 0, len(rollover.Lines)),
+		TotalCarriedForward:   rollover.TotalCarriedForward,
+		TotalLapsed:           rollover.TotalLapsed,
+		TotalEncashed:         rollover.TotalEncashed,
+		TotalEncashmentAmount: rollover.TotalEncashmentAmount,
+		ReversedAt:            rollover.ReversedAt,
+		CreatedAt:             rollover.CreatedAt,
+	}
+
+	// A dry run has no ID
+	if !rollover.ID.IsZero() {
+		encID, err := encryptions.EncryptID(rollover.ID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt rollover ID: %w", err)
+		}
+		response.ID = encID
+	}
+
+	for _, line := range rollover.Lines {
+		encID, err := encryptions.EncryptID(line.EmployeeID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt employee ID: %w", err)
+		}
+		response.Lines = append(response.Lines, LeaveRolloverLineResponse{
+			EmployeeID:       encID,
+			LeaveType:        line.LeaveType,
+			Accrued:          line.Accrued,
+			Used:             line.Used,
+			Balance:          line.Balance,
+			CarriedForward:   line.CarriedForward,
+			Lapsed:           line.Lapsed,
+			Encashed:         line.Encashed,
+			EncashmentAmount: line.EncashmentAmount,
+		})
+	}
+
 	return response, nil
 }
 
@@ -265,6 +897,14 @@ func ConvertLeaveToResponseWithUser(leave *LeaveWithUser) (*LeaveResponse, error
 		response.User = userResp
 	}
 
+	for i := range leave.Attachments {
+		documentResp, err := ConvertDocumentToResponse(&leave.Attachments[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert attachment: %w", err)
+		}
+		response.Attachments = append(response.Attachments, *documentResp)
+	}
+
 	return response, nil
 }
 
@@ -389,15 +1029,18 @@ func ConvertPayrollToResponse(payroll *models.Payroll) (*PayrollResponse, error)
 		PerformanceBonus:     payroll.PerformanceBonus,
 		LeaveTravelAllowance: payroll.LeaveTravelAllowance,
 		FixedAllowance:       payroll.FixedAllowance,
+		AdditionalEarnings:   payroll.AdditionalEarnings,
 		GrossSalary:          payroll.GrossSalary,
 		TotalDeductions:      payroll.TotalDeductions,
 		NetPay:               payroll.NetPay,
 		PFEmployee:           payroll.PFEmployee,
 		PFEmployer:           payroll.PFEmployer,
 		ProfessionalTax:      payroll.ProfessionalTax,
+		LOPDeduction:         payroll.LOPDeduction,
 		WorkingDays:          payroll.WorkingDays,
 		PresentDays:          payroll.PresentDays,
 		LeaveDays:            payroll.LeaveDays,
+		UnpaidLeaveDays:      payroll.UnpaidLeaveDays,
 		AbsentDays:           payroll.AbsentDays,
 		HasBankAccount:       payroll.HasBankAccount,
 		HasManager:           payroll.HasManager,
@@ -499,4 +1142,3 @@ func ConvertPayrunToResponse(payrun *models.Payrun) (*PayrunResponse, error) {
 
 	return response, nil
 }
-
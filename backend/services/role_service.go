@@ -0,0 +1,469 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/encryptions"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RoleService struct{}
+
+func NewRoleService() *RoleService {
+	return &RoleService{}
+}
+
+// builtInRoles are listed alongside a company's custom roles, in this order
+var builtInRoles = []struct {
+	Role        models.Role
+	Name        string
+	Description string
+}{
+	{models.RoleAdmin, "Admin", "Manages the company, its people and its settings"},
+	{models.RoleHR, "HR Officer", "Manages employees, attendance and leave"},
+	{models.RolePayroll, "Payroll Officer", "Manages salaries and runs payroll"},
+	{models.RoleEmployee, "Employee", "Marks attendance and manages their own requests"},
+}
+
+// RoleResponse is a built-in role or a custom role of the company, with its permissions
+type RoleResponse struct {
+	ID          string              `json:"id,omitempty"`   // custom roles only
+	Role        models.Role         `json:"role,omitempty"` // built-in roles only
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	BuiltIn     bool                `json:"built_in"`
+	Permissions []models.Permission `json:"permissions"`
+	CreatedAt   int64               `json:"created_at,omitempty"`
+	UpdatedAt   int64               `json:"updated_at,omitempty"`
+}
+
+// SaveRoleRequest for creating or updating a custom role
+type SaveRoleRequest struct {
+	Name        string              `json:"name" validate:"required"`
+	Description string              `json:"description"`
+	Permissions []models.Permission `json:"permissions" validate:"required"`
+}
+
+// AssignRoleRequest gives a user a custom role; an empty ID returns them to their built-in role
+type AssignRoleRequest struct {
+	CustomRoleID string `json:"custom_role_id"`
+}
+
+// ListPermissions returns every permission a role can hold
+func (s *RoleService) ListPermissions() []models.Permission {
+	return models.AllPermissions
+}
+
+// ListRoles returns the built-in roles with their default permissions, then the company's custom roles
+func (s *RoleService) ListRoles(companyID primitive.ObjectID) ([]RoleResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	customRolesCollection := databases.MongoDBDatabase.Collection(collections.CustomRoles)
+
+	roles := make([]RoleResponse, 0, len(builtInRoles))
+	for _, builtIn := range builtInRoles {
+		roles = append(roles, RoleResponse{
+			Role:        builtIn.Role,
+			Name:        builtIn.Name,
+			Description: builtIn.Description,
+			BuiltIn:     true,
+			Permissions: models.DefaultRolePermissions[builtIn.Role],
+		})
+	}
+
+	cursor, err := customRolesCollection.Find(ctx,
+		helpers.AddNotDeletedFilter(bson.M{"company": companyID}),
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch roles: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var customRoles []models.CustomRole
+	if err := cursor.All(ctx, &customRoles); err != nil {
+		return nil, fmt.Errorf("failed to decode roles: %w", err)
+	}
+
+	for i := range customRoles {
+		response, err := convertCustomRoleToResponse(&customRoles[i])
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *response)
+	}
+
+	return roles, nil
+}
+
+// CreateRole adds a custom role to the company. The actor can only grant permissions they hold.
+func (s *RoleService) CreateRole(companyID primitive.ObjectID, actor *models.User, req *SaveRoleRequest) (*RoleResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	customRolesCollection := databases.MongoDBDatabase.Collection(collections.CustomRoles)
+
+	name, permissions, err := checkRoleRequest(ctx, companyID, primitive.NilObjectID, actor, req)
+	if err != nil {
+		return nil, err
+	}
+
+	role := models.CustomRole{
+		ID:          primitive.NewObjectID(),
+		Company:     companyID,
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+		Permissions: permissions,
+	}
+	role.CreatedAt, role.CreatedBy = helpers.SetCreatedTimestamp(actor.ID)
+	role.UpdatedAt, role.UpdatedBy = helpers.SetUpdatedTimestamp(actor.ID)
+
+	if _, err := customRolesCollection.InsertOne(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	return convertCustomRoleToResponse(&role)
+}
+
+// UpdateRole renames a custom role or changes its permissions; its users get them on their next request.
+// The actor must hold every permission the role has now and will have after the update.
+func (s *RoleService) UpdateRole(roleID, companyID primitive.ObjectID, actor *models.User, req *SaveRoleRequest) (*RoleResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	customRolesCollection := databases.MongoDBDatabase.Collection(collections.CustomRoles)
+
+	current, err := loadCustomRole(ctx, roleID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkActorHolds(actor, companyID, current.Permissions); err != nil {
+		return nil, err
+	}
+
+	name, permissions, err := checkRoleRequest(ctx, companyID, roleID, actor, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if containsPermission(current.Permissions, models.PermRoleManage) && !containsPermission(permissions, models.PermRoleManage) {
+		if err := ensureRoleManagerRemains(ctx, companyID, primitive.NilObjectID, roleID); err != nil {
+			return nil, err
+		}
+	}
+
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(actor.ID)
+
+	var role models.CustomRole
+	err = customRolesCollection.FindOneAndUpdate(ctx,
+		helpers.AddNotDeletedFilter(bson.M{"_id": roleID, "company": companyID}),
+		bson.M{"$set": bson.M{
+			"name":        name,
+			"description": strings.TrimSpace(req.Description),
+			"permissions": permissions,
+			"updated_at":  updatedAt,
+			"updated_by":  updatedBy,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&role)
+	if err != nil {
+		return nil, errors.New("role not found")
+	}
+
+	return convertCustomRoleToResponse(&role)
+}
+
+// DeleteRole removes a custom role; its users return to their built-in role's permissions. The actor
+// must hold every permission of the role.
+func (s *RoleService) DeleteRole(roleID, companyID primitive.ObjectID, actor *models.User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	customRolesCollection := databases.MongoDBDatabase.Collection(collections.CustomRoles)
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	current, err := loadCustomRole(ctx, roleID, companyID)
+	if err != nil {
+		return err
+	}
+	if err := checkActorHolds(actor, companyID, current.Permissions); err != nil {
+		return err
+	}
+	if containsPermission(current.Permissions, models.PermRoleManage) {
+		if err := ensureRoleManagerRemains(ctx, companyID, primitive.NilObjectID, roleID); err != nil {
+			return err
+		}
+	}
+
+	deletedAt, deletedBy := helpers.SetDeletedTimestamp(actor.ID)
+
+	result, err := customRolesCollection.UpdateOne(ctx,
+		helpers.AddNotDeletedFilter(bson.M{"_id": roleID, "company": companyID}),
+		bson.M{"$set": bson.M{
+			"is_deleted": true,
+			"deleted_at": &deletedAt,
+			"deleted_by": &deletedBy,
+			"updated_at": deletedAt,
+			"updated_by": deletedBy,
+		}},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		return errors.New("role not found or already deleted")
+	}
+
+	_, err = usersCollection.UpdateMany(ctx,
+		bson.M{"company": companyID, "custom_role_id": roleID},
+		bson.M{
+			"$set":   bson.M{"updated_at": deletedAt, "updated_by": deletedBy},
+			"$unset": bson.M{"custom_role_id": ""},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to unassign role: %w", err)
+	}
+
+	return nil
+}
+
+// AssignRole gives an employee a custom role of the company, or takes it away when roleID is zero.
+// Admins keep their built-in permissions, nobody changes their own role, and the actor can only
+// assign a role whose permissions they hold.
+func (s *RoleService) AssignRole(userID, roleID, companyID primitive.ObjectID, actor *models.User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	var target models.User
+	err := usersCollection.FindOne(ctx, helpers.AddNotDeletedFilter(bson.M{"_id": userID, "company": companyID})).Decode(&target)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if target.ID == actor.ID {
+		return errors.New("you cannot change your own role")
+	}
+	if target.IsSuperAdmin || target.Role == models.RoleAdmin || target.Role == models.RoleSuperAdmin {
+		return errors.New("admins cannot be given a custom role")
+	}
+
+	// The actor holds role.manage and stays as they are, so the company keeps a role manager
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(actor.ID)
+	update := bson.M{
+		"$set":   bson.M{"updated_at": updatedAt, "updated_by": updatedBy},
+		"$unset": bson.M{"custom_role_id": ""},
+	}
+
+	if !roleID.IsZero() {
+		role, err := loadCustomRole(ctx, roleID, companyID)
+		if err != nil {
+			return err
+		}
+		if err := checkActorHolds(actor, companyID, role.Permissions); err != nil {
+			return err
+		}
+		update = bson.M{"$set": bson.M{"custom_role_id": roleID, "updated_at": updatedAt, "updated_by": updatedBy}}
+	}
+
+	result, err := usersCollection.UpdateOne(ctx,
+		helpers.AddNotDeletedFilter(bson.M{"_id": userID, "company": companyID}),
+		update,
+	)
+	if err != nil || result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// loadCustomRole returns a custom role of the company that has not been deleted
+func loadCustomRole(ctx context.Context, roleID, companyID primitive.ObjectID) (*models.CustomRole, error) {
+	customRolesCollection := databases.MongoDBDatabase.Collection(collections.CustomRoles)
+
+	var role models.CustomRole
+	err := customRolesCollection.FindOne(ctx,
+		helpers.AddNotDeletedFilter(bson.M{"_id": roleID, "company": companyID}),
+	).Decode(&role)
+	if err != nil {
+		return nil, errors.New("role not found")
+	}
+
+	return &role, nil
+}
+
+// checkActorHolds refuses when the actor lacks any of the permissions, so nobody hands out more than
+// they have
+func checkActorHolds(actor *models.User, companyID primitive.ObjectID, permissions []models.Permission) error {
+	for _, permission := range permissions {
+		if !HasPermission(actor, permission, companyID) {
+			return fmt.Errorf("you cannot grant %q, which you do not hold", permission)
+		}
+	}
+	return nil
+}
+
+// ensureRoleManagerRemains refuses a change when no active user of the company would hold role.manage
+// without exceptUserID and the users of exceptRoleID
+func ensureRoleManagerRemains(ctx context.Context, companyID, exceptUserID, exceptRoleID primitive.ObjectID) error {
+	customRolesCollection := databases.MongoDBDatabase.Collection(collections.CustomRoles)
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	roleFilter := bson.M{"company": companyID, "permissions": models.PermRoleManage}
+	if !exceptRoleID.IsZero() {
+		roleFilter["_id"] = bson.M{"$ne": exceptRoleID}
+	}
+	cursor, err := customRolesCollection.Find(ctx,
+		helpers.AddNotDeletedFilter(roleFilter),
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to check role managers: %w", err)
+	}
+	var managerRoles []models.CustomRole
+	if err := cursor.All(ctx, &managerRoles); err != nil {
+		return fmt.Errorf("failed to check role managers: %w", err)
+	}
+	managerRoleIDs := make([]primitive.ObjectID, 0, len(managerRoles))
+	for _, role := range managerRoles {
+		managerRoleIDs = append(managerRoleIDs, role.ID)
+	}
+
+	var builtInManagers []models.Role
+	for role, permissions := range models.DefaultRolePermissions {
+		if containsPermission(permissions, models.PermRoleManage) {
+			builtInManagers = append(builtInManagers, role)
+		}
+	}
+
+	userFilter := bson.M{
+		"company": companyID,
+		"status":  models.UserActive,
+		"$or": []bson.M{
+			{"custom_role_id": bson.M{"$in": managerRoleIDs}},
+			{"custom_role_id": bson.M{"$exists": false}, "role": bson.M{"$in": builtInManagers}},
+		},
+	}
+	if !exceptUserID.IsZero() {
+		userFilter["_id"] = bson.M{"$ne": exceptUserID}
+	}
+	count, err := usersCollection.CountDocuments(ctx, helpers.AddNotDeletedFilter(userFilter))
+	if err != nil {
+		return fmt.Errorf("failed to check role managers: %w", err)
+	}
+	if count == 0 {
+		return errors.New("this would leave the company without anyone who can manage roles")
+	}
+
+	return nil
+}
+
+// keepRoleManager refuses a change that takes role.manage away from the user while nobody else in
+// their company holds it
+func keepRoleManager(ctx context.Context, user *models.User) error {
+	if user.IsSuperAdmin || user.Company.IsZero() || user.Status != models.UserActive {
+		return nil
+	}
+
+	permissions, err := userPermissions(ctx, user)
+	if err != nil {
+		return fmt.Errorf("failed to load permissions: %w", err)
+	}
+	if !containsPermission(permissions, models.PermRoleManage) {
+		return nil
+	}
+
+	return ensureRoleManagerRemains(ctx, user.Company, user.ID, primitive.NilObjectID)
+}
+
+// containsPermission reports whether the list holds the permission
+func containsPermission(permissions []models.Permission, permission models.Permission) bool {
+	for _, held := range permissions {
+		if held == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// roleNamePattern limits role names to readable labels
+var roleNamePattern = regexp.MustCompile(`^[\p{L}\p{N} ._&/-]{2,50}$`)
+
+// checkRoleRequest validates a custom role and returns its trimmed name and deduplicated permissions.
+// roleID is the role being updated, so it does not clash with its own name; the actor must hold every
+// permission requested.
+func checkRoleRequest(ctx context.Context, companyID, roleID primitive.ObjectID, actor *models.User, req *SaveRoleRequest) (string, []models.Permission, error) {
+	customRolesCollection := databases.MongoDBDatabase.Collection(collections.CustomRoles)
+
+	name := strings.TrimSpace(req.Name)
+	if !roleNamePattern.MatchString(name) {
+		return "", nil, errors.New("role name must be 2-50 letters, digits, spaces or . _ & / -")
+	}
+	for _, builtIn := range builtInRoles {
+		if strings.EqualFold(name, builtIn.Name) || strings.EqualFold(name, string(builtIn.Role)) {
+			return "", nil, fmt.Errorf("%q is a built-in role", name)
+		}
+	}
+
+	if len(req.Permissions) == 0 {
+		return "", nil, errors.New("a role needs at least one permission")
+	}
+	seen := map[models.Permission]bool{}
+	permissions := make([]models.Permission, 0, len(req.Permissions))
+	for _, permission := range req.Permissions {
+		if !models.IsValidPermission(permission) {
+			return "", nil, fmt.Errorf("unknown permission %q", permission)
+		}
+		if !HasPermission(actor, permission, companyID) {
+			return "", nil, fmt.Errorf("you cannot grant %q, which you do not hold", permission)
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+
+	filter := bson.M{
+		"company": companyID,
+		"name":    primitive.Regex{Pattern: "^" + regexp.QuoteMeta(name) + "$", Options: "i"},
+	}
+	if !roleID.IsZero() {
+		filter["_id"] = bson.M{"$ne": roleID}
+	}
+	count, err := customRolesCollection.CountDocuments(ctx, helpers.AddNotDeletedFilter(filter))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to check role name: %w", err)
+	}
+	if count > 0 {
+		return "", nil, fmt.Errorf("a role named %q already exists", name)
+	}
+
+	return name, permissions, nil
+}
+
+// convertCustomRoleToResponse converts a custom role to a response with an encrypted ID
+func convertCustomRoleToResponse(role *models.CustomRole) (*RoleResponse, error) {
+	encryptedID, err := encryptions.EncryptID(role.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt role ID: %w", err)
+	}
+
+	return &RoleResponse{
+		ID:          encryptedID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+		CreatedAt:   role.CreatedAt.Time().Unix(),
+		UpdatedAt:   role.UpdatedAt.Time().Unix(),
+	}, nil
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"testing"
+
+	"api.workzen.odoo/databases/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCheckActorHolds(t *testing.T) {
+	company := primitive.NewObjectID()
+	hr := &models.User{
+		ID:          primitive.NewObjectID(),
+		Role:        models.RoleHR,
+		Company:     company,
+		Permissions: models.NewPermissionSet(models.DefaultRolePermissions[models.RoleHR]),
+	}
+	superAdmin := &models.User{ID: primitive.NewObjectID(), IsSuperAdmin: true}
+
+	tests := []struct {
+		name    string
+		actor   *models.User
+		company primitive.ObjectID
+		grant   []models.Permission
+		wantErr bool
+	}{
+		{"HR grants the employee bundle", hr, company, models.DefaultRolePermissions[models.RoleEmployee], false},
+		{"HR grants its own bundle", hr, company, models.DefaultRolePermissions[models.RoleHR], false},
+		{"HR grants the admin bundle", hr, company, models.DefaultRolePermissions[models.RoleAdmin], true},
+		{"HR grants role.manage", hr, company, []models.Permission{models.PermRoleManage}, true},
+		{"HR grants in another company", hr, primitive.NewObjectID(), []models.Permission{models.PermUserViewAll}, true},
+		{"nothing to grant", hr, company, nil, false},
+		{"SuperAdmin grants the admin bundle", superAdmin, company, models.DefaultRolePermissions[models.RoleAdmin], false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkActorHolds(tt.actor, tt.company, tt.grant)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkActorHolds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
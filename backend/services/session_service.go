@@ -0,0 +1,421 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/encryptions"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// rotated-out refresh tokens remembered per session for reuse detection
+const sessionUsedTokens = 50
+
+// RefreshTokenRequest carries the refresh token of a session
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LoginClient describes where a login or token refresh comes from
+type LoginClient struct {
+	IPAddress string
+	UserAgent string
+}
+
+// SessionResponse represents an active session with encrypted IDs
+type SessionResponse struct {
+	ID          string             `json:"id"`
+	UserID      string             `json:"user_id"`
+	Device      string             `json:"device"`
+	IPAddress   string             `json:"ip_address,omitempty"`
+	UserAgent   string             `json:"user_agent,omitempty"`
+	Current     bool               `json:"current"` // the session the request was made with
+	CreatedAt   primitive.DateTime `json:"created_at"`
+	LastSeenAt  primitive.DateTime `json:"last_seen_at,omitempty"`
+	RefreshedAt primitive.DateTime `json:"refreshed_at,omitempty"`
+	ExpiresAt   primitive.DateTime `json:"expires_at"`
+}
+
+// RefreshSession exchanges a refresh token for a new access token and a new refresh token. Presenting
+// a refresh token that was already rotated out means it leaked, so the whole session is revoked.
+func (s *AuthService) RefreshSession(req *RefreshTokenRequest, client LoginClient) (*LoginResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sessionsCollection := databases.MongoDBDatabase.Collection(collections.Sessions)
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	if req.RefreshToken == "" {
+		return nil, errors.New("refresh token is required")
+	}
+	tokenHash, err := encryptions.Hash256(req.RefreshToken)
+	if err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	var session models.Session
+	err = sessionsCollection.FindOne(ctx, bson.M{"refresh_token_hash": tokenHash}).Decode(&session)
+	if err != nil {
+		// A rotated-out token: whoever holds the current one may be an attacker, so end the session
+		err = sessionsCollection.FindOne(ctx, bson.M{"used_token_hashes": tokenHash}).Decode(&session)
+		if err == nil {
+			revokeSessions(ctx, bson.M{"_id": session.ID}, models.SessionTokenReuse)
+			return nil, errors.New("refresh token was already used, please log in again")
+		}
+		return nil, errors.New("invalid refresh token")
+	}
+
+	now := time.Now()
+	if session.RevokedAt != 0 || now.After(session.ExpiresAt.Time()) {
+		return nil, errors.New("session has expired, please log in again")
+	}
+
+	var user models.User
+	err = usersCollection.FindOne(ctx, helpers.AddNotDeletedFilter(bson.M{"_id": session.UserID})).Decode(&user)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if _, err := checkLoginAllowed(ctx, &user); err != nil {
+		revokeSessions(ctx, bson.M{"_id": session.ID}, models.SessionDeactivated)
+		return nil, err
+	}
+
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the holder of the current token may rotate it, even under concurrent requests
+	result, err := sessionsCollection.UpdateOne(ctx,
+		bson.M{"_id": session.ID, "refresh_token_hash": tokenHash, "revoked_at": bson.M{"$exists": false}},
+		bson.M{
+			"$set": bson.M{
+				"refresh_token_hash": refreshHash,
+				"refreshed_at":       primitive.NewDateTimeFromTime(now),
+				"last_seen_at":       primitive.NewDateTimeFromTime(now),
+				"ip_address":         client.IPAddress,
+				"updated_at":         primitive.NewDateTimeFromTime(now),
+			},
+			"$push": bson.M{"used_token_hashes": bson.M{"$each": []string{tokenHash}, "$slice": -sessionUsedTokens}},
+		},
+	)
+	if err != nil || result.MatchedCount == 0 {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	accessToken, err := generateAccessToken(&user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(helpers.JWTAccessExpireDuration.Seconds()),
+	}, nil
+}
+
+// Logout ends the session the request was made with
+func (s *AuthService) Logout(userID, sessionID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return revokeSessions(ctx, bson.M{"_id": sessionID, "user_id": userID}, models.SessionLogout)
+}
+
+// LogoutAll ends every session of the user, on all devices
+func (s *AuthService) LogoutAll(userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return revokeSessions(ctx, bson.M{"user_id": userID}, models.SessionLogoutAll)
+}
+
+// ListSessions returns the user's active sessions, most recently used first
+func (s *AuthService) ListSessions(userID, currentSessionID primitive.ObjectID) ([]SessionResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return listActiveSessions(ctx, bson.M{"user_id": userID}, currentSessionID)
+}
+
+// RevokeSession signs one of the user's devices out
+func (s *AuthService) RevokeSession(userID, sessionID, currentSessionID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	reason := models.SessionRevoked
+	if sessionID == currentSessionID {
+		reason = models.SessionLogout
+	}
+
+	return revokeSession(ctx, bson.M{"_id": sessionID, "user_id": userID}, reason)
+}
+
+// ListUserSessions returns the active sessions of an employee of the actor's company, or of any
+// user for a SuperAdmin
+func (s *UserService) ListUserSessions(actor *models.User, userID primitive.ObjectID) ([]SessionResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := findSessionUser(ctx, actor, userID); err != nil {
+		return nil, err
+	}
+
+	return listActiveSessions(ctx, bson.M{"user_id": userID}, primitive.NilObjectID)
+}
+
+// RevokeUserSession ends one session of an employee of the actor's company
+func (s *UserService) RevokeUserSession(actor *models.User, userID, sessionID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := findSessionUser(ctx, actor, userID); err != nil {
+		return err
+	}
+
+	return revokeSession(ctx, bson.M{"_id": sessionID, "user_id": userID}, models.SessionAdminRevoked)
+}
+
+// RevokeUserSessions signs an employee of the actor's company out of every device
+func (s *UserService) RevokeUserSessions(actor *models.User, userID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := findSessionUser(ctx, actor, userID); err != nil {
+		return err
+	}
+
+	return revokeSessions(ctx, bson.M{"user_id": userID}, models.SessionAdminRevoked)
+}
+
+// findSessionUser checks that the actor may manage the user's sessions: the user is in the actor's
+// company, or the actor is a SuperAdmin. Sessions are then matched by user alone, since the
+// session's company is the user's.
+func findSessionUser(ctx context.Context, actor *models.User, userID primitive.ObjectID) error {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	filter := bson.M{"_id": userID}
+	if !actor.IsSuperAdmin {
+		filter["company"] = actor.Company
+	}
+
+	count, err := usersCollection.CountDocuments(ctx, helpers.AddNotDeletedFilter(filter))
+	if err != nil {
+		return fmt.Errorf("failed to fetch user: %w", err)
+	}
+	if count == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// createSession starts a session for a user who just signed in and returns its refresh token
+func createSession(ctx context.Context, user *models.User, client LoginClient) (*models.Session, string, error) {
+	sessionsCollection := databases.MongoDBDatabase.Collection(collections.Sessions)
+
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		ID:               primitive.NewObjectID(),
+		UserID:           user.ID,
+		Company:          user.Company,
+		Device:           helpers.DescribeUserAgent(client.UserAgent),
+		IPAddress:        client.IPAddress,
+		UserAgent:        client.UserAgent,
+		LastSeenAt:       primitive.NewDateTimeFromTime(now),
+		RefreshTokenHash: refreshHash,
+		ExpiresAt:        primitive.NewDateTimeFromTime(now.Add(helpers.JWTExpireDuration)),
+	}
+	session.CreatedAt, session.CreatedBy = helpers.SetCreatedTimestamp(user.ID)
+	session.UpdatedAt, session.UpdatedBy = helpers.SetUpdatedTimestamp(user.ID)
+
+	newDevice := isNewDevice(ctx, user.ID, session.Device)
+
+	if _, err := sessionsCollection.InsertOne(ctx, session); err != nil {
+		return nil, "", fmt.Errorf("failed to create session: %w", err)
+	}
+
+	// Warn the user in case someone else signed in with their credentials (non-blocking)
+	if newDevice && user.Email != "" {
+		go func() {
+			if err := helpers.SendNewDeviceLoginEmail(user.Email, user.FirstName, session.Device, session.IPAddress, now); err != nil {
+				fmt.Printf("Failed to send new device email to %s: %v\n", user.Email, err)
+			}
+		}()
+	}
+
+	return &session, refreshToken, nil
+}
+
+// isNewDevice reports whether the user has signed in before, but never from this kind of device
+func isNewDevice(ctx context.Context, userID primitive.ObjectID, device string) bool {
+	sessionsCollection := databases.MongoDBDatabase.Collection(collections.Sessions)
+
+	previous, err := sessionsCollection.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil || previous == 0 {
+		return false
+	}
+
+	known, err := sessionsCollection.CountDocuments(ctx, bson.M{"user_id": userID, "device": device})
+	return err == nil && known == 0
+}
+
+// listActiveSessions returns the sessions matching the filter that are neither revoked nor expired
+func listActiveSessions(ctx context.Context, filter bson.M, currentSessionID primitive.ObjectID) ([]SessionResponse, error) {
+	sessionsCollection := databases.MongoDBDatabase.Collection(collections.Sessions)
+
+	filter["revoked_at"] = bson.M{"$exists": false}
+	filter["expires_at"] = bson.M{"$gt": primitive.NewDateTimeFromTime(time.Now())}
+
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}})
+	cursor, err := sessionsCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to decode sessions: %w", err)
+	}
+
+	responses := make([]SessionResponse, 0, len(sessions))
+	for i := range sessions {
+		response, err := convertSessionToResponse(&sessions[i])
+		if err != nil {
+			return nil, err
+		}
+		response.Current = sessions[i].ID == currentSessionID
+		responses = append(responses, *response)
+	}
+
+	return responses, nil
+}
+
+// convertSessionToResponse converts Session model to SessionResponse with encrypted IDs
+func convertSessionToResponse(session *models.Session) (*SessionResponse, error) {
+	response := &SessionResponse{
+		Device:      session.Device,
+		IPAddress:   session.IPAddress,
+		UserAgent:   session.UserAgent,
+		CreatedAt:   session.CreatedAt,
+		LastSeenAt:  session.LastSeenAt,
+		RefreshedAt: session.RefreshedAt,
+		ExpiresAt:   session.ExpiresAt,
+	}
+
+	encID, err := encryptions.EncryptID(session.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt session ID: %w", err)
+	}
+	response.ID = encID
+
+	encID, err = encryptions.EncryptID(session.UserID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt user ID: %w", err)
+	}
+	response.UserID = encID
+
+	return response, nil
+}
+
+// generateAccessToken signs a short-lived JWT for the user, bound to a session
+func generateAccessToken(user *models.User, sessionID primitive.ObjectID) (string, error) {
+	// Encrypt user ID for JWT
+	encryptedUserID, err := encryptions.EncryptID(user.ID.Hex())
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt user ID for JWT: %w", err)
+	}
+
+	// Encrypt company ID for JWT
+	encryptedCompanyID, err := encryptions.EncryptID(user.Company.Hex())
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt company ID for JWT: %w", err)
+	}
+
+	encryptedSessionID, err := encryptions.EncryptID(sessionID.Hex())
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt session ID for JWT: %w", err)
+	}
+
+	payload := map[string]any{
+		"id":             encryptedUserID,
+		"sid":            encryptedSessionID,
+		"username":       user.Username,
+		"role":           user.Role,
+		"company":        encryptedCompanyID,
+		"is_super_admin": user.IsSuperAdmin,
+	}
+
+	token, err := helpers.GenerateJWT(payload, time.Now().Add(helpers.JWTAccessExpireDuration))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return token, nil
+}
+
+// newRefreshToken generates a refresh token and the hash stored for it
+func newRefreshToken() (string, string, error) {
+	token, err := helpers.GenerateSecretToken()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	hash, err := encryptions.Hash256(token)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash refresh token: %w", err)
+	}
+	return token, hash, nil
+}
+
+// revokeSession ends a single active session, reporting when none matches
+func revokeSession(ctx context.Context, filter bson.M, reason models.SessionRevokeReason) error {
+	sessionsCollection := databases.MongoDBDatabase.Collection(collections.Sessions)
+
+	filter["revoked_at"] = bson.M{"$exists": false}
+	now := primitive.NewDateTimeFromTime(time.Now())
+	result, err := sessionsCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"revoked_at":     now,
+		"revoked_reason": reason,
+		"updated_at":     now,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("session not found")
+	}
+
+	return nil
+}
+
+// revokeSessions ends the active sessions matching the filter; their access tokens stop working at once
+func revokeSessions(ctx context.Context, filter bson.M, reason models.SessionRevokeReason) error {
+	sessionsCollection := databases.MongoDBDatabase.Collection(collections.Sessions)
+
+	filter["revoked_at"] = bson.M{"$exists": false}
+	now := primitive.NewDateTimeFromTime(time.Now())
+	_, err := sessionsCollection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{
+		"revoked_at":     now,
+		"revoked_reason": reason,
+		"updated_at":     now,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,599 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"api.workzen.odoo/databases"
+	"api.workzen.odoo/databases/collections"
+	"api.workzen.odoo/databases/models"
+	"api.workzen.odoo/encryptions"
+	"api.workzen.odoo/helpers"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	twoFactorIssuer        = "WorkZen"
+	twoFactorChallengeTTL  = 5 * time.Minute
+	twoFactorMaxAttempts   = 5
+	twoFactorRecoveryCodes = 10
+)
+
+var errInvalidTwoFactorCode = errors.New("invalid two-factor code")
+
+// loginChallengeFields are unset when a login challenge is used up or dropped
+var loginChallengeFields = bson.M{
+	"two_factor_challenge": "",
+	"two_factor_expiry":    "",
+	"two_factor_attempts":  "",
+	"password_challenge":   "",
+}
+
+// TwoFactorCodeRequest carries a code from the user's authenticator app, or a recovery code where allowed
+type TwoFactorCodeRequest struct {
+	ChallengeToken string `json:"challenge_token"` // login endpoints only
+	Code           string `json:"code" validate:"required"`
+}
+
+// DisableTwoFactorRequest for turning two-factor authentication off
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"` // authenticator or recovery code
+}
+
+// TwoFactorSetupResponse is shown once while enrolling; the URI is rendered as a QR code
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorEnableResponse returns the recovery codes, shown only this once. Enrolling during a
+// login also completes it.
+type TwoFactorEnableResponse struct {
+	RecoveryCodes []string       `json:"recovery_codes"`
+	Login         *LoginResponse `json:"login,omitempty"`
+}
+
+// SetupTwoFactor generates a new TOTP secret for the user. It takes effect once EnableTwoFactor confirms
+// a code from it.
+func (s *AuthService) SetupTwoFactor(userID primitive.ObjectID) (*TwoFactorSetupResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := loadTwoFactorUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return setupTwoFactor(ctx, user)
+}
+
+// EnableTwoFactor turns two-factor authentication on after the user proves their app has the secret
+func (s *AuthService) EnableTwoFactor(userID primitive.ObjectID, req *TwoFactorCodeRequest) (*TwoFactorEnableResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := loadTwoFactorUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := enableTwoFactor(ctx, user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorEnableResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off, unless the user's company requires it
+func (s *AuthService) DisableTwoFactor(userID primitive.ObjectID, req *DisableTwoFactorRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	user, err := loadTwoFactorUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	company, err := loadUserCompany(ctx, user)
+	if err != nil {
+		return err
+	}
+	if company != nil && company.RequiresTwoFactor(user.Role) {
+		return errors.New("your company requires two-factor authentication")
+	}
+
+	if !encryptions.ComparePassword(req.Password, user.Password) {
+		return errors.New("password is incorrect")
+	}
+	ok, err := verifyTwoFactorCode(ctx, user, req.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errInvalidTwoFactorCode
+	}
+
+	_, err = usersCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{
+			"$set": bson.M{
+				"two_factor_enabled":   false,
+				"timestamp.updated_at": primitive.NewDateTimeFromTime(time.Now()),
+			},
+			"$unset": bson.M{
+				"two_factor_secret":         "",
+				"two_factor_last_step":      "",
+				"two_factor_recovery_codes": "",
+			},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes with a new set
+func (s *AuthService) RegenerateRecoveryCodes(userID primitive.ObjectID, req *TwoFactorCodeRequest) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := loadTwoFactorUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	ok, err := verifyTwoFactorCode(ctx, user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errInvalidTwoFactorCode
+	}
+
+	return storeRecoveryCodes(ctx, user.ID, bson.M{})
+}
+
+// VerifyTwoFactorLogin completes a login: the challenge token from the password step and an
+// authenticator or recovery code are exchanged for the JWT
+func (s *AuthService) VerifyTwoFactorLogin(req *TwoFactorCodeRequest, client LoginClient) (*LoginResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := loadTwoFactorChallenge(ctx, req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication must be set up first")
+	}
+
+	ok, err := verifyTwoFactorCode(ctx, user, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, failTwoFactorChallenge(ctx, user, client.IPAddress)
+	}
+
+	return finishTwoFactorChallenge(ctx, user, client)
+}
+
+// SetupTwoFactorWithChallenge starts enrollment for a user whose company requires two-factor
+// authentication, before they can sign in
+func (s *AuthService) SetupTwoFactorWithChallenge(challengeToken string) (*TwoFactorSetupResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := loadTwoFactorChallenge(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return setupTwoFactor(ctx, user)
+}
+
+// EnableTwoFactorWithChallenge finishes enrollment during a login and completes the login
+func (s *AuthService) EnableTwoFactorWithChallenge(req *TwoFactorCodeRequest, client LoginClient) (*TwoFactorEnableResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := loadTwoFactorChallenge(ctx, req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := enableTwoFactor(ctx, user, req.Code)
+	if errors.Is(err, errInvalidTwoFactorCode) {
+		return nil, failTwoFactorChallenge(ctx, user, client.IPAddress)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	login, err := finishTwoFactorChallenge(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorEnableResponse{RecoveryCodes: codes, Login: login}, nil
+}
+
+// startTwoFactorChallenge ends the password step of a login with a short-lived challenge token
+func startTwoFactorChallenge(ctx context.Context, user *models.User) (*LoginResponse, error) {
+	token, err := startLoginChallenge(ctx, user, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		TwoFactorRequired:      user.TwoFactorEnabled,
+		TwoFactorSetupRequired: !user.TwoFactorEnabled,
+		ChallengeToken:         token,
+	}, nil
+}
+
+// startLoginChallenge stores a short-lived token that continues a login in a further step: the
+// two-factor code, or a new password when passwordChange is set
+func startLoginChallenge(ctx context.Context, user *models.User, passwordChange bool) (string, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	token, err := helpers.GenerateSecretToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate challenge token: %w", err)
+	}
+	tokenHash, err := encryptions.Hash256(token)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash challenge token: %w", err)
+	}
+
+	// A new login replaces any earlier challenge; wrong codes carry over until a login succeeds
+	_, err = usersCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{
+			"two_factor_challenge": tokenHash,
+			"two_factor_expiry":    primitive.NewDateTimeFromTime(time.Now().Add(twoFactorChallengeTTL)),
+			"password_challenge":   passwordChange,
+		}},
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to start login challenge: %w", err)
+	}
+
+	return token, nil
+}
+
+// loadTwoFactorChallenge finds the user a live two-factor challenge token was issued to
+func loadTwoFactorChallenge(ctx context.Context, token string) (*models.User, error) {
+	return loadLoginChallenge(ctx, token, false)
+}
+
+// loadLoginChallenge finds the user a live challenge token was issued to. A challenge only works for
+// the step it was issued for, so a password change challenge cannot skip the two-factor code.
+func loadLoginChallenge(ctx context.Context, token string, passwordChange bool) (*models.User, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	if token == "" {
+		return nil, errors.New("challenge token is required")
+	}
+	tokenHash, err := encryptions.Hash256(token)
+	if err != nil {
+		return nil, errors.New("invalid or expired challenge")
+	}
+
+	filter := bson.M{"two_factor_challenge": tokenHash, "password_challenge": bson.M{"$ne": true}}
+	if passwordChange {
+		filter["password_challenge"] = true
+	}
+
+	var user models.User
+	err = usersCollection.FindOne(ctx, helpers.AddNotDeletedFilter(filter)).Decode(&user)
+	if err != nil {
+		return nil, errors.New("invalid or expired challenge")
+	}
+	now := time.Now()
+	if now.After(user.TwoFactorExpiry.Time()) || user.TwoFactorAttempts >= twoFactorMaxAttempts {
+		return nil, errors.New("invalid or expired challenge")
+	}
+
+	// Locked or delayed accounts wait here too, not only at the password step
+	if err := checkAccountThrottle(&user, now); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// failTwoFactorChallenge counts a wrong code as a failed login. The user's wrong codes carry over
+// between challenges until a login succeeds; the one that reaches twoFactorMaxAttempts drops the
+// challenge and locks the account as too many wrong passwords would.
+func failTwoFactorChallenge(ctx context.Context, user *models.User, ipAddress string) error {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	now := time.Now()
+
+	var updated models.User
+	err := usersCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": user.ID, "two_factor_challenge": user.TwoFactorChallenge},
+		bson.M{"$inc": bson.M{"two_factor_attempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		return errors.New("invalid or expired challenge")
+	}
+
+	if updated.TwoFactorAttempts < twoFactorMaxAttempts {
+		if err := recordAccountFailure(ctx, user, ipAddress, now); err != nil {
+			return err
+		}
+		return errInvalidTwoFactorCode
+	}
+
+	unset := bson.M{"failed_logins": ""}
+	for field := range loginChallengeFields {
+		unset[field] = ""
+	}
+	if err := lockAccount(ctx, user, bson.M{"two_factor_attempts": bson.M{"$gte": twoFactorMaxAttempts}}, unset, ipAddress, now); err != nil {
+		return err
+	}
+	return errors.New("too many invalid codes, please log in again")
+}
+
+// finishTwoFactorChallenge consumes the challenge and issues the JWT
+func finishTwoFactorChallenge(ctx context.Context, user *models.User, client LoginClient) (*LoginResponse, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	// The challenge is single-use, even under concurrent requests
+	result, err := usersCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "two_factor_challenge": user.TwoFactorChallenge},
+		bson.M{"$unset": loginChallengeFields},
+	)
+	if err != nil || result.ModifiedCount == 0 {
+		return nil, errors.New("invalid or expired challenge")
+	}
+	clearAccountFailures(ctx, user)
+
+	// The account may have changed since the password step
+	company, err := checkLoginAllowed(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if passwordExpired(user, company.PasswordPolicy(), time.Now()) {
+		return startPasswordChangeChallenge(ctx, user)
+	}
+
+	return completeLogin(ctx, user, company, client)
+}
+
+// setupTwoFactor stores a new, not yet confirmed, TOTP secret for the user
+func setupTwoFactor(ctx context.Context, user *models.User) (*TwoFactorSetupResponse, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	if user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := helpers.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	encryptedSecret, err := sealTwoFactorSecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	_, err = usersCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "two_factor_enabled": bson.M{"$ne": true}},
+		bson.M{
+			"$set":   bson.M{"two_factor_secret": encryptedSecret},
+			"$unset": bson.M{"two_factor_last_step": ""},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save secret: %w", err)
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Username
+	}
+
+	return &TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: helpers.TOTPProvisioningURI(secret, twoFactorIssuer, account),
+	}, nil
+}
+
+// enableTwoFactor confirms the pending secret with a code and returns fresh recovery codes
+func enableTwoFactor(ctx context.Context, user *models.User, code string) ([]string, error) {
+	if user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if user.TwoFactorSecret == "" {
+		return nil, errors.New("two-factor authentication has not been set up")
+	}
+
+	secret, err := openTwoFactorSecret(user.TwoFactorSecret)
+	if err != nil {
+		return nil, errors.New("failed to read two-factor secret")
+	}
+	step, ok := helpers.VerifyTOTP(secret, code, time.Now(), user.TwoFactorLastStep)
+	if !ok {
+		return nil, errInvalidTwoFactorCode
+	}
+
+	return storeRecoveryCodes(ctx, user.ID, bson.M{
+		"two_factor_enabled":   true,
+		"two_factor_last_step": step,
+	})
+}
+
+// storeRecoveryCodes generates recovery codes and saves their hashes along with the given fields
+func storeRecoveryCodes(ctx context.Context, userID primitive.ObjectID, set bson.M) ([]string, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	codes, err := helpers.GenerateRecoveryCodes(twoFactorRecoveryCodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hash, err := encryptions.Hash256(helpers.NormalizeRecoveryCode(code))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	set["two_factor_recovery_codes"] = hashes
+	set["timestamp.updated_at"] = primitive.NewDateTimeFromTime(time.Now())
+	if _, err := usersCollection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": set}); err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+// verifyTwoFactorCode accepts an authenticator code or an unused recovery code, and consumes it
+func verifyTwoFactorCode(ctx context.Context, user *models.User, code string) (bool, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	secret, err := openTwoFactorSecret(user.TwoFactorSecret)
+	if err != nil {
+		return false, errors.New("failed to read two-factor secret")
+	}
+
+	if step, ok := helpers.VerifyTOTP(secret, code, time.Now(), user.TwoFactorLastStep); ok {
+		// Only move forward, so a code seen by a concurrent request is not accepted twice
+		result, err := usersCollection.UpdateOne(ctx,
+			bson.M{"_id": user.ID, "$or": []bson.M{
+				{"two_factor_last_step": bson.M{"$lt": step}},
+				{"two_factor_last_step": bson.M{"$exists": false}},
+			}},
+			bson.M{"$set": bson.M{"two_factor_last_step": step}},
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to record two-factor code: %w", err)
+		}
+		return result.ModifiedCount == 1, nil
+	}
+
+	hash, err := encryptions.Hash256(helpers.NormalizeRecoveryCode(code))
+	if err != nil {
+		return false, nil
+	}
+	result, err := usersCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "two_factor_recovery_codes": hash},
+		bson.M{"$pull": bson.M{"two_factor_recovery_codes": hash}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// RunTwoFactorSecretEncryption re-encrypts TOTP secrets stored with the legacy unauthenticated cipher
+// under the field key (used by the scheduler); secrets already sealed are skipped
+func (s *AuthService) RunTwoFactorSecretEncryption(now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	cursor, err := usersCollection.Find(ctx,
+		bson.M{"two_factor_secret": bson.M{"$exists": true, "$ne": "", "$not": primitive.Regex{Pattern: "^enc:"}}},
+		options.Find().SetProjection(bson.M{"_id": 1, "two_factor_secret": 1}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to fetch users: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			continue
+		}
+
+		secret, err := openTwoFactorSecret(user.TwoFactorSecret)
+		if err != nil {
+			fmt.Printf("Failed to read two-factor secret of user %s: %v\n", user.ID.Hex(), err)
+			continue
+		}
+		sealed, err := sealTwoFactorSecret(secret)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt two-factor secret: %w", err)
+		}
+
+		// Only replace the value that was read, in case the user enrolled again meanwhile
+		_, err = usersCollection.UpdateOne(ctx,
+			bson.M{"_id": user.ID, "two_factor_secret": user.TwoFactorSecret},
+			bson.M{"$set": bson.M{"two_factor_secret": sealed}},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt two-factor secret: %w", err)
+		}
+	}
+
+	return cursor.Err()
+}
+
+// sealTwoFactorSecret encrypts a TOTP secret for storage under the authenticated field key
+func sealTwoFactorSecret(secret string) (string, error) {
+	return encryptions.EncryptField(secret)
+}
+
+// openTwoFactorSecret decrypts a stored TOTP secret; secrets from before the field key used the legacy
+// cipher until RunTwoFactorSecretEncryption re-encrypts them
+func openTwoFactorSecret(stored string) (string, error) {
+	if encryptions.IsEncryptedField(stored) {
+		return encryptions.DecryptField(stored)
+	}
+	return encryptions.Decrypt(stored)
+}
+
+// loadTwoFactorUser fetches a user for a two-factor change
+func loadTwoFactorUser(ctx context.Context, userID primitive.ObjectID) (*models.User, error) {
+	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+
+	var user models.User
+	if err := usersCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	return &user, nil
+}
+
+// loadUserCompany fetches the company of a user, nil for SuperAdmin
+func loadUserCompany(ctx context.Context, user *models.User) (*models.Company, error) {
+	companiesCollection := databases.MongoDBDatabase.Collection(collections.Companies)
+
+	if user.IsSuperAdmin || user.Company.IsZero() {
+		return nil, nil
+	}
+
+	var company models.Company
+	if err := companiesCollection.FindOne(ctx, bson.M{"_id": user.Company}).Decode(&company); err != nil {
+		return nil, errors.New("company not found")
+	}
+
+	return &company, nil
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"api.workzen.odoo/encryptions"
+)
+
+func TestTwoFactorSecretStorage(t *testing.T) {
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	sealed, err := sealTwoFactorSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !encryptions.IsEncryptedField(sealed) || strings.Contains(sealed, secret) {
+		t.Fatalf("secret not sealed under the field key: %q", sealed)
+	}
+
+	tampered := []byte(sealed)
+	tampered[len(tampered)/2] ^= 1
+
+	legacy, err := encryptions.Encrypt(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		stored  string
+		want    string
+		wantErr bool
+	}{
+		{"sealed", sealed, secret, false},
+		{"legacy cipher", legacy, secret, false},
+		{"tampered", string(tampered), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := openTwoFactorSecret(tt.stored)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("got (%q, %v), want %q (error %v)", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
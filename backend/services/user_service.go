@@ -34,15 +34,28 @@ type CreateUserRequest struct {
 	DepartmentID *primitive.ObjectID `json:"department_id"`
 	ManagerID    *primitive.ObjectID `json:"manager_id"`
 	DateOfJoin   string              `json:"date_of_join"` // YYYY-MM-DD
+	Gender       models.Gender       `json:"gender"`       // male | female | other
+	WorkFromHome bool                `json:"work_from_home_allowed"`
 }
 
-// CreateUser creates a new user within a company
-func (s *UserService) CreateUser(req *CreateUserRequest, companyID, authUserID primitive.ObjectID) (*UserResponse, string, error) {
+// CreateUser creates a new user within a company. The actor can only give the user a built-in role
+// whose permissions they hold themselves.
+func (s *UserService) CreateUser(req *CreateUserRequest, companyID primitive.ObjectID, actor *models.User) (*UserResponse, string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
 
+	if req.Gender != "" && !models.IsValidGender(req.Gender) {
+		return nil, "", errors.New("gender must be male, female or other")
+	}
+	if !models.IsAssignableRole(req.Role) {
+		return nil, "", errors.New("role must be admin, hr, payroll or employee")
+	}
+	if err := checkActorHolds(actor, companyID, models.DefaultRolePermissions[req.Role]); err != nil {
+		return nil, "", err
+	}
+
 	// Check if email already exists
 	count, err := usersCollection.CountDocuments(ctx, bson.M{"email": req.Email, "company": companyID})
 	if err != nil {
@@ -103,12 +116,14 @@ func (s *UserService) CreateUser(req *CreateUserRequest, companyID, authUserID p
 		Role:                   req.Role,
 		Designation:            req.Designation,
 		DateOfJoin:             joinDate.Format("2006-01-02"),
+		Gender:                 req.Gender,
 		Status:                 models.UserActive,
 		Phone:                  req.Phone,
 		Company:                companyID,
 		EmailVerified:          false,
 		EmailVerificationToken: verificationToken,
 		TokenExpiry:            primitive.NewDateTimeFromTime(helpers.VerificationTokenExpiry()),
+		WorkFromHomeAllowed:    req.WorkFromHome,
 	}
 
 	if req.DepartmentID != nil {
@@ -119,8 +134,8 @@ func (s *UserService) CreateUser(req *CreateUserRequest, companyID, authUserID p
 	}
 
 	// Set timestamps with creator information
-	user.CreatedAt, user.CreatedBy = helpers.SetCreatedTimestamp(authUserID)
-	user.UpdatedAt, user.UpdatedBy = helpers.SetUpdatedTimestamp(authUserID)
+	user.CreatedAt, user.CreatedBy = helpers.SetCreatedTimestamp(actor.ID)
+	user.UpdatedAt, user.UpdatedBy = helpers.SetUpdatedTimestamp(actor.ID)
 	user.IsDeleted = false
 
 	_, err = usersCollection.InsertOne(ctx, user)
@@ -230,18 +245,48 @@ type UpdateUserRequest struct {
 	Role         models.Role         `json:"role"`
 	Designation  string              `json:"designation"`
 	DepartmentID *primitive.ObjectID `json:"department_id"`
-	Password     string              `json:"password"` // Optional - only update if provided
+	Password     string              `json:"password"`               // Optional - only update if provided
+	Gender       models.Gender       `json:"gender"`                 // Optional - only update if provided
+	WorkFromHome *bool               `json:"work_from_home_allowed"` // Optional - only update if provided
 }
 
-// UpdateUser updates user details
-func (s *UserService) UpdateUser(userID, authUserID primitive.ObjectID, req *UpdateUserRequest) (*UserResponse, error) {
+// UpdateUser updates the details of a user of the actor's company (any user for a SuperAdmin).
+// Changing someone's role, password or email needs every permission they hold now, and a new role
+// only grants what the actor holds; nobody changes their own role.
+func (s *UserService) UpdateUser(userID primitive.ObjectID, actor *models.User, req *UpdateUserRequest) (*UserResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
 
+	filter := bson.M{"_id": userID}
+	if !actor.IsSuperAdmin {
+		filter["company"] = actor.Company
+	}
+
+	var user models.User
+	if err := usersCollection.FindOne(ctx, helpers.AddNotDeletedFilter(filter)).Decode(&user); err != nil {
+		return nil, errors.New("user not found or update failed")
+	}
+
+	// Taking over someone's credentials or role is as good as holding their permissions
+	changesRole := req.Role != "" && req.Role != user.Role
+	if user.ID != actor.ID && (changesRole || req.Password != "" || (req.Email != "" && req.Email != user.Email)) {
+		if user.IsSuperAdmin && !actor.IsSuperAdmin {
+			return nil, errors.New("you cannot change a super admin")
+		}
+		current, err := userPermissions(ctx, &user)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkActorHolds(actor, user.Company, current); err != nil {
+			return nil, err
+		}
+	}
+
 	// Build update document
 	updateDoc := bson.M{}
+	unsetDoc := bson.M{}
 	if req.FirstName != "" {
 		updateDoc["first_name"] = req.FirstName
 	}
@@ -254,7 +299,28 @@ func (s *UserService) UpdateUser(userID, authUserID primitive.ObjectID, req *Upd
 	if req.Phone != "" {
 		updateDoc["phone"] = req.Phone
 	}
-	if req.Role != "" {
+	if changesRole {
+		if !models.IsAssignableRole(req.Role) {
+			return nil, errors.New("role must be admin, hr, payroll or employee")
+		}
+		if user.ID == actor.ID {
+			return nil, errors.New("you cannot change your own role")
+		}
+		if err := checkActorHolds(actor, user.Company, models.DefaultRolePermissions[req.Role]); err != nil {
+			return nil, err
+		}
+
+		// Without a custom role, the new role's bundle decides whether the user keeps role.manage
+		keepsRoleManage := !user.CustomRoleID.IsZero() || containsPermission(models.DefaultRolePermissions[req.Role], models.PermRoleManage)
+		if !keepsRoleManage {
+			if err := keepRoleManager(ctx, &user); err != nil {
+				return nil, err
+			}
+		}
+		// Admins keep their built-in permissions
+		if req.Role == models.RoleAdmin {
+			unsetDoc["custom_role_id"] = ""
+		}
 		updateDoc["role"] = req.Role
 	}
 	if req.Designation != "" {
@@ -263,23 +329,61 @@ func (s *UserService) UpdateUser(userID, authUserID primitive.ObjectID, req *Upd
 	if req.DepartmentID != nil {
 		updateDoc["department_id"] = *req.DepartmentID
 	}
+	update := bson.M{"$set": updateDoc}
+	if len(unsetDoc) > 0 {
+		update["$unset"] = unsetDoc
+	}
 	if req.Password != "" {
-		updateDoc["password"] = encryptions.HashPassword(req.Password)
+		policy, err := loadPasswordPolicy(ctx, &user)
+		if err != nil {
+			return nil, err
+		}
+
+		// Checked against the names and email the user will have after this update
+		checked := user
+		if req.FirstName != "" {
+			checked.FirstName = req.FirstName
+		}
+		if req.LastName != "" {
+			checked.LastName = req.LastName
+		}
+		if req.Email != "" {
+			checked.Email = req.Email
+		}
+		if err := checkPasswordPolicy(req.Password, policy, &checked); err != nil {
+			return nil, err
+		}
+		update = passwordUpdate(&user, req.Password, updateDoc, unsetDoc)
+	}
+	if req.Gender != "" {
+		if !models.IsValidGender(req.Gender) {
+			return nil, errors.New("gender must be male, female or other")
+		}
+		updateDoc["gender"] = req.Gender
+	}
+	if req.WorkFromHome != nil {
+		updateDoc["work_from_home_allowed"] = *req.WorkFromHome
 	}
 
-	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(authUserID)
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(actor.ID)
 	updateDoc["updated_at"] = updatedAt
 	updateDoc["updated_by"] = updatedBy
 
 	result, err := usersCollection.UpdateOne(
 		ctx,
-		helpers.AddNotDeletedFilter(bson.M{"_id": userID}),
-		bson.M{"$set": updateDoc},
+		helpers.AddNotDeletedFilter(filter),
+		update,
 	)
 	if err != nil || result.MatchedCount == 0 {
 		return nil, errors.New("user not found or update failed")
 	}
 
+	if req.Password != "" {
+		if err := revokeSessions(ctx, bson.M{"user_id": userID}, models.SessionPasswordChange); err != nil {
+			return nil, err
+		}
+	}
+
 	// Fetch updated user
 	return s.GetUserByID(userID)
 }
@@ -291,6 +395,16 @@ func (s *UserService) UpdateUserStatus(userID, authUserID primitive.ObjectID, st
 
 	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
 
+	if status != models.UserActive {
+		var user models.User
+		if err := usersCollection.FindOne(ctx, helpers.AddNotDeletedFilter(bson.M{"_id": userID})).Decode(&user); err != nil {
+			return errors.New("user not found")
+		}
+		if err := keepRoleManager(ctx, &user); err != nil {
+			return err
+		}
+	}
+
 	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(authUserID)
 
 	result, err := usersCollection.UpdateOne(
@@ -308,6 +422,11 @@ func (s *UserService) UpdateUserStatus(userID, authUserID primitive.ObjectID, st
 		return errors.New("user not found")
 	}
 
+	// A deactivated user is signed out everywhere
+	if status != models.UserActive {
+		return revokeSessions(ctx, bson.M{"user_id": userID}, models.SessionDeactivated)
+	}
+
 	return nil
 }
 
@@ -321,27 +440,98 @@ type UpdateBankDetailsRequest struct {
 	UANNo         string `json:"uan_no"`
 }
 
-// UpdateBankDetails updates user's bank information
-func (s *UserService) UpdateBankDetails(userID, authUserID primitive.ObjectID, req *UpdateBankDetailsRequest) error {
+// UpdateBankDetails updates user's bank information. Users update their own; changing someone
+// else's needs salary.manage. Every change is logged.
+func (s *UserService) UpdateBankDetails(userID primitive.ObjectID, actor *models.User, ipAddress string, req *UpdateBankDetailsRequest) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
+	activityLogsCollection := databases.MongoDBDatabase.Collection(collections.ActivityLogs)
+
+	if userID != actor.ID && !can(actor, models.PermSalaryManage) {
+		return errors.New("you do not have permission to update these bank details")
+	}
+
+	filter := bson.M{"_id": userID}
+	if !actor.IsSuperAdmin {
+		filter["company"] = actor.Company
+	}
+
+	var user models.User
+	if err := usersCollection.FindOne(ctx, helpers.AddNotDeletedFilter(filter)).Decode(&user); err != nil {
+		return errors.New("user not found")
+	}
 
 	bankDetails := models.BankDetails{
-		AccountNumber: req.AccountNumber,
-		BankName:      req.BankName,
-		IFSCCode:      req.IFSCCode,
-		BranchName:    req.BranchName,
-		PANNo:         req.PANNo,
-		UANNo:         req.UANNo,
+		BankName:   req.BankName,
+		IFSCCode:   req.IFSCCode,
+		BranchName: req.BranchName,
 	}
 
-	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(authUserID)
+	stored := user.BankDetails
+	if stored == nil {
+		stored = &models.BankDetails{}
+	}
+
+	var changed []string
+	for _, field := range []struct {
+		name          string
+		value, stored string
+	}{
+		{"bank_name", req.BankName, stored.BankName},
+		{"ifsc_code", req.IFSCCode, stored.IFSCCode},
+		{"branch_name", req.BranchName, stored.BranchName},
+	} {
+		if field.value != field.stored {
+			changed = append(changed, field.name)
+		}
+	}
+	for _, field := range []struct {
+		name          string
+		target        *string
+		value, stored string
+	}{
+		{"account_number", &bankDetails.AccountNumber, req.AccountNumber, stored.AccountNumber},
+		{"pan_no", &bankDetails.PANNo, req.PANNo, stored.PANNo},
+		{"uan_no", &bankDetails.UANNo, req.UANNo, stored.UANNo},
+	} {
+		sealed, err := sealBankField(field.value, field.stored)
+		if err != nil {
+			return err
+		}
+		*field.target = sealed
+
+		// A masked value sent back keeps the stored one
+		if field.value != maskField(field.stored) {
+			changed = append(changed, field.name)
+		}
+	}
+
+	// The change is only made once it is on record
+	if len(changed) > 0 {
+		log := models.ActivityLog{
+			ID:        primitive.NewObjectID(),
+			UserID:    actor.ID,
+			Company:   user.Company,
+			Action:    "update_bank_details",
+			Module:    "user",
+			Resource:  user.ID.Hex(),
+			IPAddress: ipAddress,
+			Metadata:  bson.M{"fields": changed},
+		}
+		log.CreatedAt, log.CreatedBy = helpers.SetCreatedTimestamp(actor.ID)
+		log.UpdatedAt, log.UpdatedBy = helpers.SetUpdatedTimestamp(actor.ID)
+		if _, err := activityLogsCollection.InsertOne(ctx, log); err != nil {
+			return fmt.Errorf("failed to log bank details update: %w", err)
+		}
+	}
+
+	updatedAt, updatedBy := helpers.SetUpdatedTimestamp(actor.ID)
 
 	result, err := usersCollection.UpdateOne(
 		ctx,
-		helpers.AddNotDeletedFilter(bson.M{"_id": userID}),
+		helpers.AddNotDeletedFilter(filter),
 		bson.M{
 			"$set": bson.M{
 				"bank_details": bankDetails,
@@ -364,6 +554,14 @@ func (s *UserService) DeleteUser(userID, authUserID primitive.ObjectID) error {
 
 	usersCollection := databases.MongoDBDatabase.Collection(collections.Users)
 
+	var user models.User
+	if err := usersCollection.FindOne(ctx, helpers.AddNotDeletedFilter(bson.M{"_id": userID})).Decode(&user); err != nil {
+		return errors.New("user not found or already deleted")
+	}
+	if err := keepRoleManager(ctx, &user); err != nil {
+		return err
+	}
+
 	deletedAt, deletedBy := helpers.SetDeletedTimestamp(authUserID)
 
 	result, err := usersCollection.UpdateOne(
@@ -384,7 +582,7 @@ func (s *UserService) DeleteUser(userID, authUserID primitive.ObjectID) error {
 		return errors.New("user not found or already deleted")
 	}
 
-	return nil
+	return revokeSessions(ctx, bson.M{"user_id": userID}, models.SessionDeactivated)
 }
 
 // generateRandomPassword generates a random password